@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/JCorners68/cssguard/pkg/baseline"
+	"github.com/JCorners68/cssguard/pkg/validator"
+)
+
+func baselineCmd(args []string) {
+	if len(args) < 1 || args[0] != "create" {
+		fmt.Fprintln(os.Stderr, "Usage: cssguard baseline create --html <dir> (--config <file> | --css <paths>) [--output <file>]")
+		os.Exit(1)
+	}
+
+	fs := flag.NewFlagSet("baseline create", flag.ExitOnError)
+	htmlDir := fs.String("html", "", "HTML directory to scan")
+	configPath := fs.String("config", "cssguard.json", "Trained config file (used unless --css is given)")
+	cssDir := fs.String("css", "", "CSS directory or file(s) to compare against directly")
+	output := fs.String("output", "cssguard-baseline.json", "Output baseline file")
+	ignore := fs.String("ignore", "", "Class globs that are never orphans (comma-separated, e.g. js-*,wp-block-*)")
+	jobs := addJobsFlag(fs)
+	cacheDir := addCacheFlag(fs)
+	projectPath := addProjectFlag(fs)
+
+	// Source scanning flags
+	src := addSrcFlags(fs)
+
+	fs.Parse(args[1:])
+	proj := applyProject(fs, *projectPath, map[string]string{"output": "baseline"})
+
+	if *htmlDir == "" {
+		fmt.Fprintln(os.Stderr, "Error: --html is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	c := openCache(*cacheDir)
+	html := loadHTML(*htmlDir, src, nil, *jobs, c)
+
+	var result *validator.Result
+	if *cssDir != "" {
+		result = newValidator(ruleConfig(proj, *ignore)).ValidateDirectly(html.classes, loadCSS(*cssDir, *jobs, c).classes)
+	} else {
+		result = loadValidator(*configPath, ruleConfig(proj, *ignore)).ValidateAgainstPatterns(html.classes)
+	}
+	html.annotate(result)
+
+	if err := baseline.FromResult(result).Save(*output); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving baseline: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Baseline saved to %s\n", *output)
+	fmt.Printf("  Orphans: %d\n", result.OrphanCount)
+}
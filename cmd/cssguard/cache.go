@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/JCorners68/cssguard/pkg/cache"
+)
+
+func cacheCmd(args []string) {
+	if len(args) < 1 || args[0] != "clean" {
+		fmt.Fprintln(os.Stderr, "Usage: cssguard cache clean [--cache-dir <dir>]")
+		os.Exit(1)
+	}
+
+	fs := flag.NewFlagSet("cache clean", flag.ExitOnError)
+	cacheDir := addCacheFlag(fs)
+	projectPath := addProjectFlag(fs)
+	fs.Parse(args[1:])
+	applyProject(fs, *projectPath, nil)
+
+	if *cacheDir == "" {
+		fmt.Fprintln(os.Stderr, "Error: --cache-dir is required (or cache_dir in cssguard.yaml)")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	if err := cache.Clean(*cacheDir); err != nil {
+		fmt.Fprintf(os.Stderr, "Error cleaning cache: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Cache cleaned: %s\n", *cacheDir)
+}
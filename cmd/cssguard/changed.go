@@ -0,0 +1,122 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/JCorners68/cssguard/pkg/gitdiff"
+	"github.com/JCorners68/cssguard/pkg/parser"
+	"github.com/JCorners68/cssguard/pkg/srcscan"
+	"github.com/JCorners68/cssguard/pkg/validator"
+)
+
+// addChangedSinceFlag registers --changed-since on fs.
+func addChangedSinceFlag(fs *flag.FlagSet) *string {
+	return fs.String("changed-since", "", "Check only the pages and source files changed since this git ref and report findings on changed lines (full scan if CSS changed)")
+}
+
+// loadChanges lists the changes since ref using git. It returns nil, meaning
+// a full scan, if ref is empty or a stylesheet or one of the files in
+// fullScan changed, since then any page may have gained or lost orphans. It
+// exits on error.
+func loadChanges(ref string, fullScan ...string) *gitdiff.Diff {
+	if ref == "" {
+		return nil
+	}
+	diff, err := gitdiff.Since(ref)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing changes since %s: %v\n", ref, err)
+		os.Exit(1)
+	}
+	for _, path := range diff.Files() {
+		if parser.IsStylesheet(path) {
+			fmt.Fprintf(os.Stderr, "CSS changed since %s (%s), checking the whole site\n", ref, relPath(path))
+			return nil
+		}
+	}
+	for _, path := range fullScan {
+		if path != "" && diff.Changed(path) {
+			fmt.Fprintf(os.Stderr, "%s changed since %s, checking the whole site\n", path, ref)
+			return nil
+		}
+	}
+	return diff
+}
+
+// changedSources lists the files below roots that changed in diff and that
+// a scan of roots with s would reach.
+func changedSources(s *srcscan.Scanner, roots []string, diff *gitdiff.Diff) []string {
+	var files []string
+	for _, root := range roots {
+		for _, path := range diff.Under(root) {
+			if scannedBelow(s, root, path) {
+				files = append(files, path)
+			}
+		}
+	}
+	return files
+}
+
+// changedPages lists the HTML pages under htmlDir that changed in diff.
+func changedPages(htmlDir string, diff *gitdiff.Diff) []string {
+	var pages []string
+	for _, path := range diff.Under(htmlDir) {
+		if strings.HasSuffix(strings.ToLower(path), ".html") {
+			pages = append(pages, path)
+		}
+	}
+	return pages
+}
+
+// scannedBelow reports whether a scan of root would reach path: a file given
+// as root is always scanned, and otherwise no directory between root and
+// path may be excluded and path needs a scanned extension.
+func scannedBelow(s *srcscan.Scanner, root, path string) bool {
+	if path == filepath.Clean(root) {
+		return true
+	}
+	rel, err := filepath.Rel(root, filepath.Dir(path))
+	if err != nil {
+		return false
+	}
+	if rel != "." {
+		for _, dir := range strings.Split(rel, string(filepath.Separator)) {
+			if !s.Includes(dir, true) {
+				return false
+			}
+		}
+	}
+	return s.Includes(path, false)
+}
+
+// restrictToChanges keeps only the findings on lines changed in diff, with
+// the counts of the classes used on those lines. A nil diff keeps
+// everything.
+func restrictToChanges(result *validator.Result, diff *gitdiff.Diff, html *htmlInput) {
+	if diff == nil {
+		return
+	}
+	used, suppressed := html.locations()
+	for class, locs := range suppressed {
+		used[class] = append(used[class], locs...)
+	}
+	result.Restrict(func(loc validator.Location) bool {
+		return diff.Touches(loc.File, loc.Line)
+	}, used)
+}
+
+// relPath shows an absolute path relative to the working directory when it
+// is inside it.
+func relPath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
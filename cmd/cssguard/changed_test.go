@@ -0,0 +1,29 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/JCorners68/cssguard/pkg/srcscan"
+)
+
+func TestScannedBelow(t *testing.T) {
+	s := srcscan.New(srcscan.DefaultOptions())
+	tests := []struct {
+		root, path string
+		want       bool
+	}{
+		{"src", "src/App.tsx", true},
+		{"src", "src/components/Card.vue", true},
+		{"src", "src/main.go", false},
+		{"src", "src/node_modules/pkg/index.js", false},
+		{"src", "src/dist/app.js", false},
+		{"./src/App.tsx", "src/App.tsx", true},
+		{"src/notes.txt", "src/notes.txt", true}, // Files given explicitly are always scanned
+	}
+	for _, tt := range tests {
+		if got := scannedBelow(s, tt.root, filepath.FromSlash(tt.path)); got != tt.want {
+			t.Errorf("scannedBelow(%s, %s) = %v, want %v", tt.root, tt.path, got, tt.want)
+		}
+	}
+}
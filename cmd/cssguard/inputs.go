@@ -0,0 +1,321 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/JCorners68/cssguard/pkg/baseline"
+	"github.com/JCorners68/cssguard/pkg/cache"
+	"github.com/JCorners68/cssguard/pkg/extractor"
+	"github.com/JCorners68/cssguard/pkg/gitdiff"
+	"github.com/JCorners68/cssguard/pkg/parser"
+	"github.com/JCorners68/cssguard/pkg/project"
+	"github.com/JCorners68/cssguard/pkg/srcscan"
+	"github.com/JCorners68/cssguard/pkg/trainer"
+	"github.com/JCorners68/cssguard/pkg/validator"
+)
+
+// srcFlags holds the source scanning flags shared by several commands.
+type srcFlags struct {
+	paths   srcPathsFlag
+	ext     *string
+	exclude *string
+}
+
+// addSrcFlags registers --src, --src-ext and --src-exclude on fs.
+func addSrcFlags(fs *flag.FlagSet) *srcFlags {
+	s := &srcFlags{}
+	fs.Var(&s.paths, "src", "Source directory/file to scan for class tokens (repeatable)")
+	s.ext = fs.String("src-ext", "", "Source file extensions (default: .js,.ts,.jsx,.tsx,.astro,.vue,.svelte,.md,.mdx)")
+	s.exclude = fs.String("src-exclude", "", "Directories to exclude (default: node_modules,dist,.next,build,.git)")
+	return s
+}
+
+// htmlInput holds the classes used by the scanned HTML and source files.
+type htmlInput struct {
+	occurrences    []extractor.Occurrence
+	srcOccurrences []srcscan.Occurrence
+	classes        map[string]struct{} // HTML and source classes
+	srcClassCount  int
+}
+
+// loadHTML extracts classes from an HTML directory and merges in the class
+// tokens harvested from --src paths. A non-nil diff limits both to the files
+// that changed in it. It exits on error.
+func loadHTML(htmlDir string, src *srcFlags, diff *gitdiff.Diff, jobs int, c *cache.Cache) *htmlInput {
+	var occurrences []extractor.Occurrence
+	var err error
+	if diff != nil {
+		occurrences, err = extractor.ExtractOccurrencesCached(changedPages(htmlDir, diff), jobs, c)
+	} else {
+		occurrences, err = extractor.ExtractOccurrencesFromDirCached(htmlDir, jobs, c)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error extracting HTML classes: %v\n", err)
+		os.Exit(1)
+	}
+	in := &htmlInput{
+		occurrences: occurrences,
+		classes:     extractor.ClassSet(occurrences),
+	}
+
+	// Extract source classes if --src provided
+	if len(src.paths) > 0 {
+		opts := srcscan.Options{
+			Extensions: srcscan.ParseExtensions(*src.ext),
+			Excludes:   srcscan.ParseExcludes(*src.exclude),
+			Jobs:       jobs,
+			Cache:      c,
+		}
+		scanner := srcscan.New(opts)
+		paths := []string(src.paths)
+		if diff != nil {
+			paths = changedSources(scanner, src.paths, diff)
+		}
+		srcOccurrences, err := scanner.ScanOccurrences(paths)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error scanning source files: %v\n", err)
+			os.Exit(1)
+		}
+		in.srcOccurrences = srcOccurrences
+		srcClasses := srcscan.ClassSet(srcOccurrences)
+		in.srcClassCount = len(srcClasses)
+		// Merge source classes into HTML classes
+		for c := range srcClasses {
+			in.classes[c] = struct{}{}
+		}
+	}
+	return in
+}
+
+// locations groups the HTML and source class occurrences by class name,
+// keeping those silenced by inline cssguard-ignore comments apart.
+func (in *htmlInput) locations() (used, suppressed map[string][]validator.Location) {
+	used = make(map[string][]validator.Location)
+	suppressed = make(map[string][]validator.Location)
+	add := func(class string, loc validator.Location, isSuppressed bool) {
+		if isSuppressed {
+			suppressed[class] = append(suppressed[class], loc)
+		} else {
+			used[class] = append(used[class], loc)
+		}
+	}
+	for _, o := range in.occurrences {
+		add(o.Class, validator.Location{File: o.File, Line: o.Line, Column: o.Column, Tag: o.Tag}, o.Suppressed)
+	}
+	for _, o := range in.srcOccurrences {
+		add(o.Class, validator.Location{File: o.File, Line: o.Line, Column: o.Column}, o.Suppressed)
+	}
+	return used, suppressed
+}
+
+// annotate sets aside the orphans in result that are silenced by inline
+// comments and records where the others are used.
+func (in *htmlInput) annotate(result *validator.Result) {
+	used, suppressed := in.locations()
+	result.Suppress(suppressed, used)
+	result.AddLocations(used)
+}
+
+// annotateContexts records the at-rule contexts of the used classes defined
+// only inside at-rules, and where classes defined only for print are used.
+func (in *htmlInput) annotateContexts(result *validator.Result, contexts map[string][]string, printOnly map[string]struct{}) {
+	used, _ := in.locations()
+	result.AddContexts(contexts, printOnly, used)
+}
+
+// pageClasses groups the classes of the HTML occurrences by file, leaving out
+// uses silenced by inline comments.
+func (in *htmlInput) pageClasses() map[string]map[string]struct{} {
+	pages := make(map[string]map[string]struct{})
+	for _, o := range in.occurrences {
+		if o.Suppressed {
+			continue
+		}
+		if pages[o.File] == nil {
+			pages[o.File] = make(map[string]struct{})
+		}
+		pages[o.File][o.Class] = struct{}{}
+	}
+	return pages
+}
+
+// loadPages builds the element trees of the HTML pages in htmlDir, or only
+// of those changed in diff if it is not nil. It exits on error.
+func loadPages(htmlDir string, diff *gitdiff.Diff, jobs int, c *cache.Cache) []extractor.Page {
+	var pages []extractor.Page
+	var err error
+	if diff != nil {
+		pages, err = extractor.ExtractPagesCached(changedPages(htmlDir, diff), jobs, c)
+	} else {
+		pages, err = extractor.ExtractPagesFromDirCached(htmlDir, jobs, c)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error extracting HTML structure: %v\n", err)
+		os.Exit(1)
+	}
+	return pages
+}
+
+// cssInput holds the classes defined by a set of CSS paths.
+type cssInput struct {
+	classes     map[string]struct{}
+	fileClasses map[string]map[string]struct{}  // path -> class keys per at-rule context (for redundancy)
+	definitions map[string]parser.Definition    // class -> first definition
+	locations   map[string][]validator.Location // class -> every definition
+	contexts    map[string][]string             // class defined only inside at-rules -> those at-rules
+	printOnly   map[string]struct{}             // classes defined only for print
+	all         []parser.Definition             // every definition, in order
+}
+
+// loadCSS parses a comma-separated list of CSS files and directories,
+// tracking classes per path for redundancy detection. Paths that cannot be
+// parsed are reported as warnings and skipped.
+func loadCSS(cssPaths string, jobs int, c *cache.Cache) *cssInput {
+	in := &cssInput{
+		classes:     make(map[string]struct{}),
+		fileClasses: make(map[string]map[string]struct{}),
+		definitions: make(map[string]parser.Definition),
+		locations:   make(map[string][]validator.Location),
+	}
+	var parseErrors []string
+	var all []parser.Definition
+
+	for _, path := range strings.Split(cssPaths, ",") {
+		path = strings.TrimSpace(path)
+		defs, err := parseCSS(path, jobs, c)
+		if err != nil {
+			parseErrors = append(parseErrors, fmt.Sprintf("%s: %v", path, err))
+			continue
+		}
+		all = append(all, defs...)
+
+		classes := parser.ClassSet(defs)
+		in.fileClasses[path] = parser.KeySet(defs)
+		for c := range classes {
+			in.classes[c] = struct{}{}
+		}
+		for c, d := range parser.FirstDefinitions(defs) {
+			if _, ok := in.definitions[c]; !ok {
+				in.definitions[c] = d
+			}
+		}
+		for _, d := range defs {
+			in.locations[d.Class] = append(in.locations[d.Class], validator.Location{
+				File:   d.File,
+				Line:   d.Line,
+				Column: d.Column,
+				Entry:  d.Entry,
+			})
+		}
+	}
+	in.contexts = parser.ClassContexts(all)
+	in.printOnly = parser.PrintOnlyClasses(all)
+	in.all = all
+
+	if len(parseErrors) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d CSS path(s) had errors:\n", len(parseErrors))
+		for _, e := range parseErrors {
+			fmt.Fprintf(os.Stderr, "  - %s\n", e)
+		}
+	}
+	return in
+}
+
+// parseCSS parses a CSS file, or the CSS files in a directory, following
+// their local @import rules. Imports that cannot be followed are reported as
+// warnings.
+func parseCSS(path string, jobs int, c *cache.Cache) ([]parser.Definition, error) {
+	defs, warnings, err := parser.ParseWithImports(path, jobs, c)
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+	return defs, err
+}
+
+// addJobsFlag registers --jobs on fs.
+func addJobsFlag(fs *flag.FlagSet) *int {
+	return fs.Int("jobs", runtime.GOMAXPROCS(0), "Files to parse in parallel")
+}
+
+// addCacheFlag registers --cache-dir on fs.
+func addCacheFlag(fs *flag.FlagSet) *string {
+	return fs.String("cache-dir", "", "Directory for caching the classes extracted from each file between runs (default: no cache)")
+}
+
+// openCache returns the cache in dir, or nil if dir is empty.
+func openCache(dir string) *cache.Cache {
+	if dir == "" {
+		return nil
+	}
+	return cache.New(dir, version)
+}
+
+// addSuggestFlag registers --suggest-threshold on fs.
+func addSuggestFlag(fs *flag.FlagSet) *float64 {
+	return fs.Float64("suggest-threshold", validator.DefaultSuggestThreshold, "Similarity (0-1) needed for \"did you mean\" suggestions; 0 disables them")
+}
+
+// ruleConfig collects the ignore and safelist rules of the project config and
+// the --ignore globs.
+func ruleConfig(c *project.Config, ignore string) *trainer.Config {
+	rules := &trainer.Config{}
+	if c != nil {
+		rules.IgnoreRules = append(rules.IgnoreRules, c.IgnoreRules...)
+		rules.Safelist = append(rules.Safelist, c.Safelist...)
+	}
+	for _, glob := range splitList(ignore) {
+		rules.IgnoreRules = append(rules.IgnoreRules, trainer.Rule{Glob: glob})
+	}
+	return rules
+}
+
+// loadValidator loads a trained config and builds a validator, adding the
+// rules given on the command line to the config's own. It exits on error.
+func loadValidator(configPath string, rules *trainer.Config) *validator.Validator {
+	config, err := trainedConfig(configPath, rules)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		fmt.Fprintln(os.Stderr, "Run 'cssguard train' first to generate config")
+		os.Exit(1)
+	}
+	return newValidator(config)
+}
+
+// trainedConfig loads a trained config and adds rules to its own.
+func trainedConfig(configPath string, rules *trainer.Config) (*trainer.Config, error) {
+	config, err := trainer.LoadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	config.IgnoreRules = append(config.IgnoreRules, rules.IgnoreRules...)
+	config.Safelist = append(config.Safelist, rules.Safelist...)
+	return config, nil
+}
+
+// newValidator builds a validator. It exits on error.
+func newValidator(config *trainer.Config) *validator.Validator {
+	v, err := validator.New(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating validator: %v\n", err)
+		os.Exit(1)
+	}
+	return v
+}
+
+// applyBaseline compares the result with a baseline file, if one is given.
+// It exits on error.
+func applyBaseline(result *validator.Result, path string) {
+	if path == "" {
+		return
+	}
+	b, err := baseline.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading baseline: %v\n", err)
+		os.Exit(1)
+	}
+	b.Apply(result)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/JCorners68/cssguard/pkg/lsp"
+	"github.com/JCorners68/cssguard/pkg/parser"
+	"github.com/JCorners68/cssguard/pkg/srcscan"
+	"github.com/JCorners68/cssguard/pkg/validator"
+)
+
+func lspCmd(args []string) {
+	fs := flag.NewFlagSet("lsp", flag.ExitOnError)
+	configPath := fs.String("config", "", "Trained config file to check classes against")
+	cssDir := fs.String("css", "", "CSS directory or file(s) to check classes against and to complete and describe classes from (comma-separated)")
+	ignore := fs.String("ignore", "", "Class globs that are never orphans (comma-separated, e.g. js-*,wp-block-*)")
+	suggestThreshold := addSuggestFlag(fs)
+	severity := addSeverityFlag(fs)
+	srcExt := fs.String("src-ext", "", "Extensions of non-HTML documents to check (default: .js,.ts,.jsx,.tsx,.astro,.vue,.svelte,.md,.mdx)")
+	jobs := addJobsFlag(fs)
+	projectPath := addProjectFlag(fs)
+
+	fs.Parse(args)
+	proj := applyProject(fs, *projectPath, nil)
+
+	// Standard output carries the protocol, so everything else goes to stderr
+	if *configPath == "" && *cssDir == "" {
+		fmt.Fprintln(os.Stderr, "Error: --config or --css is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	var defs []parser.Definition
+	for _, path := range splitList(*cssDir) {
+		d, err := parseCSS(path, *jobs, nil)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", path, err)
+			continue
+		}
+		defs = append(defs, d...)
+	}
+
+	rules := ruleConfig(proj, *ignore)
+	var v *validator.Validator
+	if *configPath != "" {
+		v = loadValidator(*configPath, rules)
+	} else {
+		v = newValidator(rules)
+	}
+
+	server := lsp.NewServer(lsp.Options{
+		Version:          version,
+		Validator:        v,
+		Trained:          *configPath != "",
+		Definitions:      defs,
+		Scanner:          srcscan.New(srcscan.Options{Extensions: srcscan.ParseExtensions(*srcExt)}),
+		SuggestThreshold: *suggestThreshold,
+		Severity:         severity.levels,
+	})
+	if err := server.Serve(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -10,13 +10,15 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 
-	"github.com/JCorners68/cssguard/pkg/extractor"
 	"github.com/JCorners68/cssguard/pkg/parser"
-	"github.com/JCorners68/cssguard/pkg/srcscan"
+	"github.com/JCorners68/cssguard/pkg/report"
 	"github.com/JCorners68/cssguard/pkg/trainer"
 	"github.com/JCorners68/cssguard/pkg/validator"
 )
@@ -38,6 +40,16 @@ func main() {
 		directCmd(os.Args[2:])
 	case "redundancy":
 		redundancyCmd(os.Args[2:])
+	case "baseline":
+		baselineCmd(os.Args[2:])
+	case "config":
+		configCmd(os.Args[2:])
+	case "cache":
+		cacheCmd(os.Args[2:])
+	case "watch":
+		watchCmd(os.Args[2:])
+	case "lsp":
+		lspCmd(os.Args[2:])
 	case "version":
 		fmt.Printf("cssguard v%s\n", version)
 	case "help", "-h", "--help":
@@ -60,6 +72,11 @@ COMMANDS:
     validate    Validate HTML classes against trained patterns (fast, for CI)
     direct      Direct comparison without patterns (slower but no training)
     redundancy  Find duplicate classes across CSS files (identify removable libraries)
+    baseline    Snapshot current orphans so validate/direct fail only on new ones
+    config      Print the effective settings ('config print')
+    cache       Remove cached extraction results ('cache clean')
+    watch       Poll for changes, re-validate and print new and resolved orphans
+    lsp         Run a language server that flags orphans in the editor
     version     Print version
     help        Print this help
 
@@ -76,20 +93,42 @@ EXAMPLES:
     # Find redundant CSS across multiple files
     cssguard redundancy --css ./main.css,./vendor/flowbite.min.css
 
+    # Accept existing orphans, then fail only on new ones
+    cssguard baseline create --html ./public --config cssguard.json
+    cssguard validate --html ./public --config cssguard.json --baseline cssguard-baseline.json
+
+    # Re-check while editing templates
+    cssguard watch --html ./public --css ./public/css --src ./src
+
+    # Report only orphans on lines changed since the branch left main
+    cssguard direct --html ./templates --css ./public/css --changed-since origin/main
+
+    # Skip re-parsing unchanged files on the next run
+    cssguard validate --html ./public --config cssguard.json --cache-dir .cssguard-cache
+
+    # Show the settings a cssguard.yaml project config resolves to
+    cssguard config print
+
 NOTES:
     - If you add a new CSS pattern/utility that doesn't match the trained
       regex, it won't be checked. Re-run 'train' when adding new patterns.
     - For Tailwind/utility-first CSS, train against the PURGED output.
+    - Settings are read from cssguard.yaml in the current or a parent
+      directory (or --project). Flags override file values.
 
 More info: https://github.com/JCorners68/cssguard`)
 }
 
 func trainCmd(args []string) {
 	fs := flag.NewFlagSet("train", flag.ExitOnError)
-	cssDir := fs.String("css", "", "CSS directory or file(s) to parse (comma-separated)")
+	cssDir := fs.String("css", "", "CSS, SCSS or LESS directory or file(s) to parse (comma-separated)")
 	output := fs.String("output", "cssguard.json", "Output config file")
 	verbose := fs.Bool("verbose", false, "Verbose output")
+	jobs := addJobsFlag(fs)
+	cacheDir := addCacheFlag(fs)
+	projectPath := addProjectFlag(fs)
 	fs.Parse(args)
+	applyProject(fs, *projectPath, map[string]string{"output": "config"})
 
 	if *cssDir == "" {
 		fmt.Fprintln(os.Stderr, "Error: --css is required")
@@ -98,36 +137,19 @@ func trainCmd(args []string) {
 	}
 
 	// Parse CSS files
+	c := openCache(*cacheDir)
 	cssClasses := make(map[string]struct{})
+	var all []parser.Definition
 	for _, path := range strings.Split(*cssDir, ",") {
 		path = strings.TrimSpace(path)
-		info, err := os.Stat(path)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: cannot stat %s: %v\n", path, err)
-			continue
-		}
-
-		var classes map[string]struct{}
-		if info.IsDir() {
-			classes, err = parser.ParseFromDir(path)
-		} else {
-			classList, err2 := parser.ParseFromFile(path)
-			if err2 != nil {
-				err = err2
-			} else {
-				classes = make(map[string]struct{})
-				for _, c := range classList {
-					classes[c] = struct{}{}
-				}
-			}
-		}
-
+		defs, err := parseCSS(path, *jobs, c)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: error parsing %s: %v\n", path, err)
 			continue
 		}
+		all = append(all, defs...)
 
-		for c := range classes {
+		for c := range parser.ClassSet(defs) {
 			cssClasses[c] = struct{}{}
 		}
 	}
@@ -144,6 +166,7 @@ func trainCmd(args []string) {
 	// Train
 	t := trainer.New()
 	t.AddClasses(cssClasses)
+	t.AddContexts(parser.ClassContexts(all), parser.PrintOnlyClasses(all))
 	config := t.Train()
 
 	if *verbose {
@@ -178,212 +201,460 @@ func validateCmd(args []string) {
 	fs := flag.NewFlagSet("validate", flag.ExitOnError)
 	htmlDir := fs.String("html", "", "HTML directory to scan")
 	configPath := fs.String("config", "cssguard.json", "Trained config file")
-	jsonOutput := fs.Bool("json", false, "Output JSON")
-	failOnOrphans := fs.Bool("fail", true, "Exit with code 1 if orphans found")
+	jsonOutput := fs.Bool("json", false, "Output JSON (same as --format json)")
+	format := addFormatFlag(fs, outputFormats)
+	output := addOutputFlag(fs)
+	failOnOrphans := fs.Bool("fail", true, "Exit with code 1 if error-level findings (by default orphans) remain")
 	verbose := fs.Bool("verbose", false, "Show all orphan classes")
+	baselinePath := fs.String("baseline", "", "Baseline file of accepted orphans (fail only on new ones)")
+	ignore := fs.String("ignore", "", "Class globs that are never orphans (comma-separated, e.g. js-*,wp-block-*)")
+	suggestThreshold := addSuggestFlag(fs)
+	severity := addSeverityFlag(fs)
+	jobs := addJobsFlag(fs)
+	cacheDir := addCacheFlag(fs)
+	changedSince := addChangedSinceFlag(fs)
+	projectPath := addProjectFlag(fs)
 
 	// Source scanning flags
-	var srcPaths srcPathsFlag
-	fs.Var(&srcPaths, "src", "Source directory/file to scan for class tokens (repeatable)")
-	srcExt := fs.String("src-ext", "", "Source file extensions (default: .js,.ts,.jsx,.tsx,.astro,.vue,.svelte,.md,.mdx)")
-	srcExclude := fs.String("src-exclude", "", "Directories to exclude (default: node_modules,dist,.next,build,.git)")
+	src := addSrcFlags(fs)
 
 	fs.Parse(args)
+	proj := applyProject(fs, *projectPath, nil)
 
 	if *htmlDir == "" {
 		fmt.Fprintln(os.Stderr, "Error: --html is required")
 		fs.Usage()
 		os.Exit(1)
 	}
+	checkFormat(*format, outputFormats)
 
-	// Load config
-	config, err := trainer.LoadConfig(*configPath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
-		fmt.Fprintln(os.Stderr, "Run 'cssguard train' first to generate config")
-		os.Exit(1)
+	v := loadValidator(*configPath, ruleConfig(proj, *ignore))
+	c := openCache(*cacheDir)
+	changes := loadChanges(*changedSince, *configPath, projectFile(proj))
+	html := loadHTML(*htmlDir, src, changes, *jobs, c)
+
+	// Validate
+	result := v.ValidateAgainstPatterns(html.classes)
+	html.annotate(result)
+	contexts, printOnly := v.Contexts()
+	html.annotateContexts(result, contexts, printOnly)
+	if *suggestThreshold > 0 {
+		result.Suggest(v.KnownClasses(), *suggestThreshold)
+	}
+	applyBaseline(result, *baselinePath)
+	outFormat := outputFormat(*format, *jsonOutput)
+	var site *validator.SiteReport
+	if outFormat == "junit" {
+		// Every page becomes a testcase; without CSS there are no CSS shares
+		site = result.PerPage(*htmlDir, html.pageClasses(), nil)
+	}
+	restrictToChanges(result, changes, html)
+
+	// Output
+	switch outFormat {
+	case "json":
+		writeOutput(*output, func(w io.Writer) error {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(result)
+		})
+	case "sarif":
+		opts := report.Options{ToolVersion: version, Severity: severity.levels}
+		writeOutput(*output, func(w io.Writer) error {
+			return report.WriteSARIF(w, result, opts)
+		})
+	case "html":
+		opts := report.Options{ToolVersion: version, Severity: severity.levels}
+		writeOutput(*output, func(w io.Writer) error {
+			return report.WriteHTML(w, result, opts)
+		})
+	case "junit":
+		opts := report.Options{ToolVersion: version, Severity: severity.levels, Site: site}
+		writeOutput(*output, func(w io.Writer) error {
+			return report.WriteJUnit(w, result, opts)
+		})
+	case "github":
+		opts := report.Options{ToolVersion: version, Severity: severity.levels}
+		writeOutput(*output, func(w io.Writer) error {
+			return report.WriteGitHub(w, result, opts)
+		})
+	case "gitlab":
+		opts := report.Options{ToolVersion: version, Severity: severity.levels}
+		writeOutput(*output, func(w io.Writer) error {
+			return report.WriteGitLab(w, result, opts)
+		})
+	case "markdown":
+		opts := report.Options{ToolVersion: version, Severity: severity.levels}
+		opts.LinkBase, opts.LinkRoot = githubLinks()
+		writeMarkdown(*output, func(w io.Writer) error {
+			return report.WriteMarkdown(w, result, opts)
+		})
+	default:
+		if html.srcClassCount > 0 {
+			fmt.Printf("Source Classes: %d\n", html.srcClassCount)
+		}
+		fmt.Print(result.Summary())
+		if *verbose && result.HasOrphans() {
+			fmt.Println("\nOrphan classes:")
+			for _, class := range result.Orphans {
+				printOrphan(result, class)
+			}
+		}
+		printPrintOnly(result, severity.levels)
+		printRules(result, *verbose)
+		printResolvedBaseline(result)
 	}
 
-	// Extract HTML classes
-	htmlClasses, err := extractor.ExtractFromDir(*htmlDir)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error extracting HTML classes: %v\n", err)
+	if *failOnOrphans && failed(result, nil, severity.levels) {
 		os.Exit(1)
 	}
+}
 
-	// Extract source classes if --src provided
-	var srcClassCount int
-	if len(srcPaths) > 0 {
-		opts := srcscan.Options{
-			Extensions: srcscan.ParseExtensions(*srcExt),
-			Excludes:   srcscan.ParseExcludes(*srcExclude),
+// outputFormats are the values accepted by --format.
+var outputFormats = []string{"text", "json", "sarif", "html", "junit", "github", "gitlab", "markdown"}
+
+// redundancyFormats are the values the redundancy command accepts for --format.
+var redundancyFormats = []string{"text", "json", "junit"}
+
+// outputFormat resolves --format, honouring the older --json flag.
+func outputFormat(format string, jsonOutput bool) string {
+	if jsonOutput {
+		return "json"
+	}
+	return format
+}
+
+// addFormatFlag registers --format on fs, listing formats in its help.
+func addFormatFlag(fs *flag.FlagSet, formats []string) *string {
+	return fs.String("format", "text", "Output format: "+strings.Join(formats, ", "))
+}
+
+// checkFormat exits with an error if format is not one of formats.
+func checkFormat(format string, formats []string) {
+	for _, f := range formats {
+		if format == f {
+			return
 		}
-		scanner := srcscan.New(opts)
-		srcClasses, err := scanner.ScanPaths(srcPaths)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error scanning source files: %v\n", err)
+	}
+	fmt.Fprintf(os.Stderr, "Error: unknown format %q (want one of: %s)\n", format, strings.Join(formats, ", "))
+	os.Exit(1)
+}
+
+// addOutputFlag registers --output on fs.
+func addOutputFlag(fs *flag.FlagSet) *string {
+	return fs.String("output", "", "File to write the report to, for formats other than text (default: stdout)")
+}
+
+// writeOutput writes a report to path, or to stdout if path is empty. It
+// exits on error.
+func writeOutput(path string, write func(io.Writer) error) {
+	if path == "" {
+		if err := write(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
 			os.Exit(1)
 		}
-		srcClassCount = len(srcClasses)
-		// Merge source classes into HTML classes
-		for c := range srcClasses {
-			htmlClasses[c] = struct{}{}
+		return
+	}
+	f, err := os.Create(path)
+	if err == nil {
+		err = write(f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
 		}
 	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+		os.Exit(1)
+	}
+}
 
-	// Validate
-	v, err := validator.New(config)
+// writeMarkdown writes a Markdown report like writeOutput and, when running
+// in GitHub Actions, also appends it to the job summary. It exits on error.
+func writeMarkdown(path string, write func(io.Writer) error) {
+	writeOutput(path, write)
+	summary := os.Getenv("GITHUB_STEP_SUMMARY")
+	if summary == "" {
+		return
+	}
+	f, err := os.OpenFile(summary, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err == nil {
+		err = write(f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating validator: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error writing job summary: %v\n", err)
 		os.Exit(1)
 	}
+}
 
-	result := v.ValidateAgainstPatterns(htmlClasses)
+// githubLinks returns the URL that paths relative to the repository root
+// are appended to to link them at the checked-out commit when running in
+// GitHub Actions, and that root; base is "" outside GitHub Actions.
+func githubLinks() (base, root string) {
+	server, repo, sha := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_SHA")
+	if server == "" || repo == "" || sha == "" {
+		return "", ""
+	}
+	root = os.Getenv("GITHUB_WORKSPACE")
+	if out, err := exec.Command("git", "rev-parse", "--show-toplevel").Output(); err == nil {
+		root = strings.TrimSpace(string(out))
+	}
+	return server + "/" + repo + "/blob/" + sha + "/", root
+}
 
-	// Output
-	if *jsonOutput {
-		enc := json.NewEncoder(os.Stdout)
-		enc.SetIndent("", "  ")
-		enc.Encode(result)
-	} else {
-		if srcClassCount > 0 {
-			fmt.Printf("Source Classes: %d\n", srcClassCount)
+// maxOrphanLocations limits how many usage sites are listed per orphan in text output.
+const maxOrphanLocations = 5
+
+// printOrphan prints an orphan class, what is missing for variant-prefixed
+// classes, and the places it is used.
+func printOrphan(result *validator.Result, class string) {
+	line := "  - " + class
+	if issue, ok := result.VariantIssues[class]; ok {
+		line += fmt.Sprintf(" (%s)", issue.Reason())
+	}
+	if result.IsKnownOrphan(class) {
+		line += " [baseline]"
+	}
+	fmt.Println(line)
+	if s := result.Suggestions[class]; len(s) > 0 {
+		fmt.Printf("      %s\n", report.DidYouMean(s))
+	}
+	printLocations(result.OrphanLocations[class])
+}
+
+// printLocations lists the first few places a class is used.
+func printLocations(locs []validator.Location) {
+	for i, loc := range locs {
+		if i >= maxOrphanLocations {
+			fmt.Printf("      ... and %d more\n", len(locs)-maxOrphanLocations)
+			break
 		}
-		fmt.Print(result.Summary())
-		if *verbose && result.HasOrphans() {
-			fmt.Println("\nOrphan classes:")
-			for _, class := range result.Orphans {
-				fmt.Printf("  - %s\n", class)
-			}
+		if loc.Tag != "" {
+			fmt.Printf("      %s <%s>\n", loc, loc.Tag)
+		} else {
+			fmt.Printf("      %s\n", loc)
 		}
 	}
+}
 
-	if *failOnOrphans && result.HasOrphans() {
-		os.Exit(1)
+// printPrintOnly lists the used classes that are defined only for print,
+// unless the print-only rule is off.
+func printPrintOnly(result *validator.Result, severity report.Severity) {
+	if len(result.PrintOnly) == 0 || severity.Level(report.RulePrintOnly) == report.LevelOff {
+		return
+	}
+	fmt.Println("\n⚠ Print-only classes (used, but defined only for print):")
+	for _, class := range result.PrintOnlyClasses() {
+		fmt.Printf("  - %s (%s)\n", class, strings.Join(result.Contexts[class], ", "))
+		printLocations(result.PrintOnly[class])
+	}
+}
+
+// printContextOrphans lists the classes used outside the structure their
+// selectors need, with those selectors, unless the context-orphan rule is
+// off.
+func printContextOrphans(result *validator.Result, severity report.Severity) {
+	if len(result.ContextOrphans) == 0 || severity.Level(report.RuleContextOrphan) == report.LevelOff {
+		return
+	}
+	fmt.Println("\n⚠ Context orphans (used where no selector defining them matches):")
+	for _, class := range result.ContextOrphanClasses() {
+		orphan := result.ContextOrphans[class]
+		fmt.Printf("  - %s (%s)\n", class, strings.Join(orphan.Selectors, ", "))
+		printLocations(orphan.Locations)
+	}
+}
+
+// maxPages limits how many pages are listed in the per-page text output
+// without verbose.
+const maxPages = 20
+
+// printSite ranks the pages by orphan count and sums them up by directory,
+// if a per-page report was made.
+func printSite(site *validator.SiteReport, verbose bool) {
+	if site == nil || len(site.Pages) == 0 {
+		return
+	}
+	fmt.Println("\nPages (most orphans first):")
+	for i, p := range site.Pages {
+		if i >= maxPages && !verbose {
+			fmt.Printf("  ... and %d more\n", len(site.Pages)-maxPages)
+			break
+		}
+		fmt.Printf("  - %s: %d orphans, %d/%d matched (%.1f%%), %d CSS classes (%.1f%% of CSS)\n",
+			p.File, p.OrphanCount, p.Matched, p.Classes, p.CoveragePercent, len(p.CSSClasses), p.CSSPercent)
+		if p.OrphanCount > 0 {
+			fmt.Printf("      orphans: %s\n", strings.Join(p.Orphans, ", "))
+		}
+	}
+	fmt.Println("\nDirectories:")
+	for _, d := range site.Directories {
+		fmt.Printf("  - %s: %d pages (%d with orphans), %d orphans, %d/%d matched (%.1f%%), %d CSS classes (%.1f%% of CSS)\n",
+			d.Dir, d.Pages, d.OrphanPages, d.OrphanCount, d.Matched, d.Classes, d.CoveragePercent, d.CSSClasses, d.CSSPercent)
+	}
+}
+
+// printRules lists ignore and safelist rules that are expired or matched
+// nothing, so they can be removed, and with verbose what every rule suppressed.
+func printRules(result *validator.Result, verbose bool) {
+	if verbose && len(result.Rules) > 0 {
+		fmt.Println("\nIgnore and safelist rules:")
+		for _, u := range result.Rules {
+			fmt.Printf("  - %s: %d suppressed\n", u, u.Suppressed)
+		}
+	}
+	stale := result.StaleRules()
+	if len(stale) == 0 {
+		return
+	}
+	fmt.Println("\n⚠ Stale rules (remove or update them):")
+	for _, u := range stale {
+		if u.Expired {
+			fmt.Printf("  - %s: expired %s\n", u, u.Expires)
+		} else {
+			fmt.Printf("  - %s: matched nothing\n", u)
+		}
+	}
+}
+
+// printResolvedBaseline lists baseline entries that no longer occur, so the
+// baseline file can be shrunk.
+func printResolvedBaseline(result *validator.Result) {
+	if result.Baseline == nil || len(result.Baseline.Resolved) == 0 {
+		return
+	}
+	fmt.Println("\nResolved baseline entries (no longer orphans, remove from baseline):")
+	for _, class := range result.Baseline.Resolved {
+		fmt.Printf("  - %s\n", class)
 	}
 }
 
 func directCmd(args []string) {
 	fs := flag.NewFlagSet("direct", flag.ExitOnError)
 	htmlDir := fs.String("html", "", "HTML directory to scan")
-	cssDir := fs.String("css", "", "CSS directory or file(s) to parse")
-	jsonOutput := fs.Bool("json", false, "Output JSON")
-	failOnOrphans := fs.Bool("fail", true, "Exit with code 1 if orphans found")
+	cssDir := fs.String("css", "", "CSS, SCSS or LESS directory or file(s) to parse")
+	jsonOutput := fs.Bool("json", false, "Output JSON (same as --format json)")
+	format := addFormatFlag(fs, outputFormats)
+	output := addOutputFlag(fs)
+	failOnOrphans := fs.Bool("fail", true, "Exit with code 1 if error-level findings (by default orphans) remain")
 	verbose := fs.Bool("verbose", false, "Show orphan and unused classes")
 	showUnused := fs.Bool("unused", false, "Also report unused CSS classes")
 	redundancyThreshold := fs.Float64("redundancy-threshold", 80.0, "Coverage threshold for redundancy warnings (%)")
+	baselinePath := fs.String("baseline", "", "Baseline file of accepted orphans (fail only on new ones)")
+	ignore := fs.String("ignore", "", "Class globs that are never orphans (comma-separated, e.g. js-*,wp-block-*)")
+	contextOrphans := fs.Bool("context-orphans", false, "Also report classes used outside the structure their selectors need, e.g. .card-title outside .card for .card > .card-title")
+	perPage := fs.Bool("per-page", false, "Also report orphans, matched classes and CSS classes per HTML page, with a rollup by directory")
+	suggestThreshold := addSuggestFlag(fs)
+	severity := addSeverityFlag(fs)
+	jobs := addJobsFlag(fs)
+	cacheDir := addCacheFlag(fs)
+	changedSince := addChangedSinceFlag(fs)
+	projectPath := addProjectFlag(fs)
 
 	// Source scanning flags
-	var srcPaths srcPathsFlag
-	fs.Var(&srcPaths, "src", "Source directory/file to scan for class tokens (repeatable)")
-	srcExt := fs.String("src-ext", "", "Source file extensions (default: .js,.ts,.jsx,.tsx,.astro,.vue,.svelte,.md,.mdx)")
-	srcExclude := fs.String("src-exclude", "", "Directories to exclude (default: node_modules,dist,.next,build,.git)")
+	src := addSrcFlags(fs)
 
 	fs.Parse(args)
+	proj := applyProject(fs, *projectPath, nil)
 
 	if *htmlDir == "" || *cssDir == "" {
 		fmt.Fprintln(os.Stderr, "Error: --html and --css are required")
 		fs.Usage()
 		os.Exit(1)
 	}
+	checkFormat(*format, outputFormats)
 
-	// Extract HTML classes
-	htmlClasses, err := extractor.ExtractFromDir(*htmlDir)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error extracting HTML classes: %v\n", err)
-		os.Exit(1)
-	}
+	c := openCache(*cacheDir)
+	changes := loadChanges(*changedSince, projectFile(proj))
+	html := loadHTML(*htmlDir, src, changes, *jobs, c)
+	css := loadCSS(*cssDir, *jobs, c)
 
-	// Extract source classes if --src provided
-	var srcClassCount int
-	if len(srcPaths) > 0 {
-		opts := srcscan.Options{
-			Extensions: srcscan.ParseExtensions(*srcExt),
-			Excludes:   srcscan.ParseExcludes(*srcExclude),
-		}
-		scanner := srcscan.New(opts)
-		srcClasses, err := scanner.ScanPaths(srcPaths)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error scanning source files: %v\n", err)
-			os.Exit(1)
-		}
-		srcClassCount = len(srcClasses)
-		// Merge source classes into HTML classes
-		for c := range srcClasses {
-			htmlClasses[c] = struct{}{}
-		}
+	// Validate directly
+	result := newValidator(ruleConfig(proj, *ignore)).ValidateDirectly(html.classes, css.classes)
+	html.annotate(result)
+	html.annotateContexts(result, css.contexts, css.printOnly)
+	if *contextOrphans {
+		result.AddContextOrphans(css.all, loadPages(*htmlDir, changes, *jobs, c))
 	}
-
-	// Parse CSS classes - track per-file for redundancy detection
-	cssPaths := strings.Split(*cssDir, ",")
-	cssClasses := make(map[string]struct{})
-	fileClasses := make(map[string]map[string]struct{}) // file -> classes (for redundancy)
-	var parseErrors []string
-
-	for _, path := range cssPaths {
-		path = strings.TrimSpace(path)
-		info, err := os.Stat(path)
-		if err != nil {
-			parseErrors = append(parseErrors, fmt.Sprintf("%s: %v", path, err))
-			continue
-		}
-
-		var classes map[string]struct{}
-		var parseErr error
-		if info.IsDir() {
-			classes, parseErr = parser.ParseFromDir(path)
-		} else {
-			classList, err := parser.ParseFromFile(path)
-			if err != nil {
-				parseErr = err
-			} else {
-				classes = make(map[string]struct{})
-				for _, c := range classList {
-					classes[c] = struct{}{}
-				}
-			}
-		}
-
-		if parseErr != nil {
-			parseErrors = append(parseErrors, fmt.Sprintf("%s: %v", path, parseErr))
-			continue
-		}
-
-		fileClasses[path] = classes
-		for c := range classes {
-			cssClasses[c] = struct{}{}
-		}
+	result.AddUnusedLocations(css.locations)
+	if *suggestThreshold > 0 {
+		result.Suggest(css.classes, *suggestThreshold)
 	}
-
-	if len(parseErrors) > 0 {
-		fmt.Fprintf(os.Stderr, "Warning: %d CSS path(s) had errors:\n", len(parseErrors))
-		for _, e := range parseErrors {
-			fmt.Fprintf(os.Stderr, "  - %s\n", e)
-		}
+	applyBaseline(result, *baselinePath)
+	outFormat := outputFormat(*format, *jsonOutput)
+	var site *validator.SiteReport
+	if *perPage || outFormat == "html" || outFormat == "junit" {
+		site = result.PerPage(*htmlDir, html.pageClasses(), css.classes)
 	}
+	restrictToChanges(result, changes, html)
 
-	// Validate directly
-	result := validator.ValidateDirectly(htmlClasses, cssClasses)
-
-	// Check for redundancy if multiple CSS files
-	var removableFiles []string
-	if len(fileClasses) >= 2 {
-		removableFiles = detectRedundancy(fileClasses, *redundancyThreshold)
+	// Check for redundancy if multiple CSS files; with unchanged CSS that
+	// is not news
+	var removableFiles []report.Redundancy
+	var pairs []report.FilePair
+	if len(css.fileClasses) >= 2 && changes == nil {
+		removableFiles = detectRedundancy(css.fileClasses, *redundancyThreshold)
+		pairs = filePairs(css.fileClasses)
 	}
 
 	// Output
-	if *jsonOutput {
+	switch outFormat {
+	case "json":
 		type DirectResult struct {
 			*validator.Result
-			Removable []string `json:"removable,omitempty"`
-		}
-		out := DirectResult{Result: result, Removable: removableFiles}
-		enc := json.NewEncoder(os.Stdout)
-		enc.SetIndent("", "  ")
-		enc.Encode(out)
-	} else {
-		if srcClassCount > 0 {
-			fmt.Printf("Source Classes: %d\n", srcClassCount)
+			Removable   []string                    `json:"removable,omitempty"`
+			Pages       []validator.PageReport      `json:"pages,omitempty"`
+			Directories []validator.DirectoryReport `json:"directories,omitempty"`
+		}
+		out := DirectResult{Result: result}
+		if site != nil {
+			out.Pages = site.Pages
+			out.Directories = site.Directories
+		}
+		for _, r := range removableFiles {
+			out.Removable = append(out.Removable, r.String())
+		}
+		writeOutput(*output, func(w io.Writer) error {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(out)
+		})
+	case "sarif":
+		opts := report.Options{ToolVersion: version, Unused: *showUnused, Redundant: removableFiles, Severity: severity.levels}
+		writeOutput(*output, func(w io.Writer) error {
+			return report.WriteSARIF(w, result, opts)
+		})
+	case "html":
+		opts := report.Options{ToolVersion: version, Unused: *showUnused, Redundant: removableFiles, Pairs: pairs, Severity: severity.levels, Site: site}
+		writeOutput(*output, func(w io.Writer) error {
+			return report.WriteHTML(w, result, opts)
+		})
+	case "junit":
+		opts := report.Options{ToolVersion: version, Unused: *showUnused, Redundant: removableFiles, Severity: severity.levels, Site: site}
+		writeOutput(*output, func(w io.Writer) error {
+			return report.WriteJUnit(w, result, opts)
+		})
+	case "github":
+		opts := report.Options{ToolVersion: version, Unused: *showUnused, Redundant: removableFiles, Severity: severity.levels}
+		writeOutput(*output, func(w io.Writer) error {
+			return report.WriteGitHub(w, result, opts)
+		})
+	case "gitlab":
+		opts := report.Options{ToolVersion: version, Unused: *showUnused, Redundant: removableFiles, Severity: severity.levels}
+		writeOutput(*output, func(w io.Writer) error {
+			return report.WriteGitLab(w, result, opts)
+		})
+	case "markdown":
+		opts := report.Options{ToolVersion: version, Unused: *showUnused, Redundant: removableFiles, Severity: severity.levels}
+		opts.LinkBase, opts.LinkRoot = githubLinks()
+		writeMarkdown(*output, func(w io.Writer) error {
+			return report.WriteMarkdown(w, result, opts)
+		})
+	default:
+		if html.srcClassCount > 0 {
+			fmt.Printf("Source Classes: %d\n", html.srcClassCount)
 		}
 		fmt.Print(result.Summary())
 
@@ -399,7 +670,7 @@ func directCmd(args []string) {
 			if result.HasOrphans() {
 				fmt.Println("\nOrphan classes (in HTML, not in CSS):")
 				for _, class := range result.Orphans {
-					fmt.Printf("  - %s\n", class)
+					printOrphan(result, class)
 				}
 			}
 			if *showUnused && result.HasUnused() {
@@ -410,24 +681,78 @@ func directCmd(args []string) {
 						fmt.Printf("  ... and %d more\n", len(result.Unused)-max)
 						break
 					}
-					fmt.Printf("  - %s\n", class)
+					if d, ok := css.definitions[class]; ok {
+						fmt.Printf("  - %s (%s)\n", class, d.Source())
+					} else {
+						fmt.Printf("  - %s\n", class)
+					}
 				}
 			}
 		}
+		printPrintOnly(result, severity.levels)
+		printContextOrphans(result, severity.levels)
+		printSite(site, *verbose)
+		printRules(result, *verbose)
+		printResolvedBaseline(result)
 	}
 
-	if *failOnOrphans && result.HasOrphans() {
+	if *failOnOrphans && failed(result, removableFiles, severity.levels) {
 		os.Exit(1)
 	}
 }
 
+// filePairs compares the classes of every pair of CSS files, in path order.
+func filePairs(fileClasses map[string]map[string]struct{}) []report.FilePair {
+	paths := make([]string, 0, len(fileClasses))
+	for p := range fileClasses {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var pairs []report.FilePair
+	for i := 0; i < len(paths); i++ {
+		for j := i + 1; j < len(paths); j++ {
+			f1, f2 := paths[i], paths[j]
+			c1, c2 := fileClasses[f1], fileClasses[f2]
+
+			overlap := 0
+			for c := range c1 {
+				if _, ok := c2[c]; ok {
+					overlap++
+				}
+			}
+
+			smaller := len(c1)
+			if len(c2) < smaller {
+				smaller = len(c2)
+			}
+
+			coverage := 0.0
+			if smaller > 0 {
+				coverage = float64(overlap) / float64(smaller) * 100
+			}
+
+			pairs = append(pairs, report.FilePair{
+				File1:     f1,
+				File2:     f2,
+				Overlap:   overlap,
+				File1Only: len(c1) - overlap,
+				File2Only: len(c2) - overlap,
+				Coverage:  coverage,
+			})
+		}
+	}
+	return pairs
+}
+
 // detectRedundancy checks if any CSS file is mostly covered by another
-func detectRedundancy(fileClasses map[string]map[string]struct{}, threshold float64) []string {
-	var removable []string
+func detectRedundancy(fileClasses map[string]map[string]struct{}, threshold float64) []report.Redundancy {
+	var removable []report.Redundancy
 	paths := make([]string, 0, len(fileClasses))
 	for p := range fileClasses {
 		paths = append(paths, p)
 	}
+	sort.Strings(paths)
 
 	for i := 0; i < len(paths); i++ {
 		for j := i + 1; j < len(paths); j++ {
@@ -444,8 +769,7 @@ func detectRedundancy(fileClasses map[string]map[string]struct{}, threshold floa
 			if len(c1) > 0 {
 				pct := float64(covered1) / float64(len(c1)) * 100
 				if pct >= threshold {
-					removable = append(removable, fmt.Sprintf("%s (%.1f%% covered by %s)",
-						filepath.Base(f1), pct, filepath.Base(f2)))
+					removable = append(removable, report.Redundancy{File: f1, CoveredBy: f2, Coverage: pct})
 				}
 			}
 
@@ -459,8 +783,7 @@ func detectRedundancy(fileClasses map[string]map[string]struct{}, threshold floa
 			if len(c2) > 0 {
 				pct := float64(covered2) / float64(len(c2)) * 100
 				if pct >= threshold {
-					removable = append(removable, fmt.Sprintf("%s (%.1f%% covered by %s)",
-						filepath.Base(f2), pct, filepath.Base(f1)))
+					removable = append(removable, report.Redundancy{File: f2, CoveredBy: f1, Coverage: pct})
 				}
 			}
 		}
@@ -471,10 +794,18 @@ func detectRedundancy(fileClasses map[string]map[string]struct{}, threshold floa
 func redundancyCmd(args []string) {
 	fs := flag.NewFlagSet("redundancy", flag.ExitOnError)
 	cssFiles := fs.String("css", "", "CSS files to compare (comma-separated)")
-	jsonOutput := fs.Bool("json", false, "Output JSON")
+	jsonOutput := fs.Bool("json", false, "Output JSON (same as --format json)")
+	format := addFormatFlag(fs, redundancyFormats)
+	output := addOutputFlag(fs)
 	verbose := fs.Bool("verbose", false, "Show all redundant classes")
 	threshold := fs.Float64("threshold", 80.0, "Coverage threshold to suggest removal (%)")
+	jobs := addJobsFlag(fs)
+	cacheDir := addCacheFlag(fs)
+	projectPath := addProjectFlag(fs)
 	fs.Parse(args)
+	// The project's output format is for validate and direct, whose formats
+	// this command mostly lacks, so it is not taken over
+	applyProject(fs, *projectPath, map[string]string{"threshold": "redundancy-threshold", "format": ""})
 
 	if *cssFiles == "" {
 		fmt.Fprintln(os.Stderr, "Error: --css is required (comma-separated list of CSS files)")
@@ -487,42 +818,36 @@ func redundancyCmd(args []string) {
 		fmt.Fprintln(os.Stderr, "Error: need at least 2 CSS files to compare")
 		os.Exit(1)
 	}
+	checkFormat(*format, redundancyFormats)
 
-	// Parse each CSS file separately, tracking which classes come from which file
-	fileClasses := make(map[string]map[string]struct{}) // file -> set of classes
-	allClasses := make(map[string][]string)             // class -> list of files
+	// Parse each CSS file separately, tracking which classes come from which
+	// file. Classes are compared per at-rule context: a class defined for
+	// print in one file and for screens in another is not redundant.
+	fileClasses := make(map[string]map[string]struct{})              // file -> set of class keys
+	fileDefinitions := make(map[string]map[string]parser.Definition) // file -> class key -> first definition
+	allClasses := make(map[string][]string)                          // class key -> list of files
+	uniqueClasses := make(map[string]struct{})
 
+	c := openCache(*cacheDir)
 	for _, path := range paths {
 		path = strings.TrimSpace(path)
-		info, err := os.Stat(path)
+		defs, err := parseCSS(path, *jobs, c)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: cannot stat %s: %v\n", path, err)
+			fmt.Fprintf(os.Stderr, "Warning: error parsing %s: %v\n", path, err)
 			continue
 		}
 
-		var classes map[string]struct{}
-		if info.IsDir() {
-			classes, err = parser.ParseFromDir(path)
-		} else {
-			classList, err2 := parser.ParseFromFile(path)
-			if err2 != nil {
-				err = err2
-			} else {
-				classes = make(map[string]struct{})
-				for _, c := range classList {
-					classes[c] = struct{}{}
-				}
+		keys := parser.KeySet(defs)
+		fileClasses[path] = keys
+		fileDefinitions[path] = make(map[string]parser.Definition)
+		for _, d := range defs {
+			if _, ok := fileDefinitions[path][d.Key()]; !ok {
+				fileDefinitions[path][d.Key()] = d
 			}
+			uniqueClasses[d.Class] = struct{}{}
 		}
-
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: error parsing %s: %v\n", path, err)
-			continue
-		}
-
-		fileClasses[path] = classes
-		for c := range classes {
-			allClasses[c] = append(allClasses[c], path)
+		for k := range keys {
+			allClasses[k] = append(allClasses[k], path)
 		}
 	}
 
@@ -535,66 +860,21 @@ func redundancyCmd(args []string) {
 	}
 
 	// Calculate coverage for each file pair
-	type FilePair struct {
-		File1    string  `json:"file1"`
-		File2    string  `json:"file2"`
-		Overlap  int     `json:"overlap"`
-		File1Only int    `json:"file1_only"`
-		File2Only int    `json:"file2_only"`
-		Coverage float64 `json:"coverage_percent"` // % of smaller file covered by larger
-	}
-
-	var pairs []FilePair
-	pathList := make([]string, 0, len(fileClasses))
-	for p := range fileClasses {
-		pathList = append(pathList, p)
-	}
-
-	for i := 0; i < len(pathList); i++ {
-		for j := i + 1; j < len(pathList); j++ {
-			f1, f2 := pathList[i], pathList[j]
-			c1, c2 := fileClasses[f1], fileClasses[f2]
-
-			overlap := 0
-			for c := range c1 {
-				if _, ok := c2[c]; ok {
-					overlap++
-				}
-			}
-
-			smaller := len(c1)
-			if len(c2) < smaller {
-				smaller = len(c2)
-			}
-
-			coverage := 0.0
-			if smaller > 0 {
-				coverage = float64(overlap) / float64(smaller) * 100
-			}
-
-			pairs = append(pairs, FilePair{
-				File1:    f1,
-				File2:    f2,
-				Overlap:  overlap,
-				File1Only: len(c1) - overlap,
-				File2Only: len(c2) - overlap,
-				Coverage: coverage,
-			})
-		}
-	}
+	pairs := filePairs(fileClasses)
 
 	// Output
 	type RedundancyResult struct {
-		TotalFiles      int                 `json:"total_files"`
-		TotalClasses    int                 `json:"total_classes"`
-		RedundantCount  int                 `json:"redundant_count"`
-		Pairs           []FilePair          `json:"pairs"`
-		Redundant       map[string][]string `json:"redundant,omitempty"`
-		Removable       []string            `json:"removable,omitempty"`
+		TotalFiles     int                 `json:"total_files"`
+		TotalClasses   int                 `json:"total_classes"`
+		RedundantCount int                 `json:"redundant_count"`
+		Pairs          []report.FilePair   `json:"pairs"`
+		Redundant      map[string][]string `json:"redundant,omitempty"`
+		Removable      []string            `json:"removable,omitempty"`
 	}
 
 	// Find potentially removable files
 	var removable []string
+	var removableFiles []report.Redundancy
 	for _, pair := range pairs {
 		c1, c2 := fileClasses[pair.File1], fileClasses[pair.File2]
 
@@ -607,6 +887,7 @@ func redundancyCmd(args []string) {
 		}
 		if len(c1) > 0 && float64(covered1)/float64(len(c1))*100 >= *threshold {
 			removable = append(removable, fmt.Sprintf("%s (%.1f%% covered by %s)", pair.File1, float64(covered1)/float64(len(c1))*100, pair.File2))
+			removableFiles = append(removableFiles, report.Redundancy{File: pair.File1, CoveredBy: pair.File2, Coverage: float64(covered1) / float64(len(c1)) * 100})
 		}
 
 		// Check if file2 is fully covered by file1
@@ -618,12 +899,13 @@ func redundancyCmd(args []string) {
 		}
 		if len(c2) > 0 && float64(covered2)/float64(len(c2))*100 >= *threshold {
 			removable = append(removable, fmt.Sprintf("%s (%.1f%% covered by %s)", pair.File2, float64(covered2)/float64(len(c2))*100, pair.File1))
+			removableFiles = append(removableFiles, report.Redundancy{File: pair.File2, CoveredBy: pair.File1, Coverage: float64(covered2) / float64(len(c2)) * 100})
 		}
 	}
 
 	result := RedundancyResult{
 		TotalFiles:     len(fileClasses),
-		TotalClasses:   len(allClasses),
+		TotalClasses:   len(uniqueClasses),
 		RedundantCount: len(redundant),
 		Pairs:          pairs,
 		Removable:      removable,
@@ -633,11 +915,19 @@ func redundancyCmd(args []string) {
 		result.Redundant = redundant
 	}
 
-	if *jsonOutput {
-		enc := json.NewEncoder(os.Stdout)
-		enc.SetIndent("", "  ")
-		enc.Encode(result)
-	} else {
+	switch outputFormat(*format, *jsonOutput) {
+	case "json":
+		writeOutput(*output, func(w io.Writer) error {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(result)
+		})
+	case "junit":
+		opts := report.Options{ToolVersion: version}
+		writeOutput(*output, func(w io.Writer) error {
+			return report.WriteRedundancyJUnit(w, pairs, removableFiles, opts)
+		})
+	default:
 		fmt.Printf("Files analyzed: %d\n", result.TotalFiles)
 		fmt.Printf("Total unique classes: %d\n", result.TotalClasses)
 		fmt.Printf("Redundant classes: %d (defined in 2+ files)\n", result.RedundantCount)
@@ -665,11 +955,11 @@ func redundancyCmd(args []string) {
 					fmt.Printf("  ... and %d more\n", len(redundant)-20)
 					break
 				}
-				fileNames := make([]string, len(files))
+				locations := make([]string, len(files))
 				for i, f := range files {
-					fileNames[i] = filepath.Base(f)
+					locations[i] = fileDefinitions[f][class].Location()
 				}
-				fmt.Printf("  %s: %s\n", class, strings.Join(fileNames, ", "))
+				fmt.Printf("  %s: %s\n", class, strings.Join(locations, ", "))
 				count++
 			}
 		}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/JCorners68/cssguard/pkg/project"
+	"github.com/JCorners68/cssguard/pkg/report"
+	"github.com/JCorners68/cssguard/pkg/validator"
+)
+
+// addProjectFlag registers --project on fs.
+func addProjectFlag(fs *flag.FlagSet) *string {
+	return fs.String("project", "", "Project config file (default: cssguard.yaml found in the current or a parent directory)")
+}
+
+// severityFlag is a repeatable --severity rule=level[,rule=level] flag.
+type severityFlag struct {
+	levels report.Severity
+}
+
+func (s *severityFlag) String() string {
+	if s == nil {
+		return ""
+	}
+	return s.levels.String()
+}
+
+func (s *severityFlag) Set(value string) error {
+	levels, err := report.ParseSeverity(value)
+	if err != nil {
+		return err
+	}
+	if s.levels == nil {
+		s.levels = make(report.Severity)
+	}
+	for rule, level := range levels {
+		s.levels[rule] = level
+	}
+	return nil
+}
+
+// addSeverityFlag registers --severity on fs.
+func addSeverityFlag(fs *flag.FlagSet) *severityFlag {
+	s := &severityFlag{}
+	fs.Var(s, "severity", "Rule levels, e.g. orphan=warning,unused=error (levels: error, warning, note, off)")
+	return s
+}
+
+// projectFlagValues maps flag names to the values a project config gives
+// them. Repeatable flags get one value per entry.
+func projectFlagValues(c *project.Config) map[string][]string {
+	values := make(map[string][]string)
+	set := func(name, value string) {
+		if value != "" {
+			values[name] = []string{value}
+		}
+	}
+	set("html", c.HTML)
+	set("css", strings.Join(c.CSS, ","))
+	values["src"] = c.Src
+	set("src-ext", strings.Join(c.SrcExt, ","))
+	set("src-exclude", strings.Join(c.SrcExclude, ","))
+	set("config", c.Trained)
+	set("baseline", c.Baseline)
+	set("ignore", strings.Join(c.Ignore, ","))
+	if c.RedundancyThreshold > 0 {
+		set("redundancy-threshold", strconv.FormatFloat(c.RedundancyThreshold, 'f', -1, 64))
+	}
+	if c.SuggestThreshold != nil {
+		set("suggest-threshold", strconv.FormatFloat(*c.SuggestThreshold, 'f', -1, 64))
+	}
+	if c.Jobs > 0 {
+		set("jobs", strconv.Itoa(c.Jobs))
+	}
+	set("cache-dir", c.CacheDir)
+	set("format", c.Output.Format)
+	if c.Output.Verbose {
+		set("verbose", "true")
+	}
+	if c.Output.Unused {
+		set("unused", "true")
+	}
+	return values
+}
+
+// applyProject loads the project config (path, or the one discovered from
+// the working directory) and sets every flag of fs that was not given on the
+// command line from it. renames maps a flag of this command to the project
+// flag it takes its value from, when the names differ. Severities are merged
+// per rule, with flags winning. It returns nil if there is no project config
+// and exits on error.
+func applyProject(fs *flag.FlagSet, path string, renames map[string]string) *project.Config {
+	var (
+		c   *project.Config
+		err error
+	)
+	if path != "" {
+		c, err = project.Load(path)
+	} else {
+		c, err = project.Discover()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading project config: %v\n", err)
+		os.Exit(1)
+	}
+	if c == nil {
+		return nil
+	}
+
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	values := projectFlagValues(c)
+	fs.VisitAll(func(f *flag.Flag) {
+		if explicit[f.Name] {
+			return
+		}
+		name := f.Name
+		if renamed, ok := renames[name]; ok {
+			name = renamed
+		}
+		for _, v := range values[name] {
+			if err := fs.Set(f.Name, v); err != nil {
+				fmt.Fprintf(os.Stderr, "Error in project config %s: %s: %v\n", c.Path, name, err)
+				os.Exit(1)
+			}
+		}
+	})
+
+	if f := fs.Lookup("severity"); f != nil {
+		s := f.Value.(*severityFlag)
+		levels, _ := c.SeverityLevels() // validated by Load
+		for rule, level := range levels {
+			if _, ok := s.levels[rule]; !ok {
+				s.Set(rule + "=" + level)
+			}
+		}
+	}
+	return c
+}
+
+// projectFile returns the file c was loaded from, or "" without a project
+// config.
+func projectFile(c *project.Config) string {
+	if c == nil {
+		return ""
+	}
+	return c.Path
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(value string) []string {
+	var list []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// failed reports whether any reported finding is at error level.
+func failed(result *validator.Result, redundant []report.Redundancy, severity report.Severity) bool {
+	isError := func(rule string) bool { return severity.Level(rule) == report.LevelError }
+	return isError(report.RuleOrphan) && result.HasNewOrphans() ||
+		isError(report.RuleUnused) && result.HasUnused() ||
+		isError(report.RuleRedundant) && len(redundant) > 0 ||
+		isError(report.RulePrintOnly) && len(result.PrintOnly) > 0 ||
+		isError(report.RuleContextOrphan) && len(result.ContextOrphans) > 0
+}
+
+func configCmd(args []string) {
+	if len(args) < 1 || args[0] != "print" {
+		fmt.Fprintln(os.Stderr, "Usage: cssguard config print [--project <file>] [flags]")
+		os.Exit(1)
+	}
+
+	fs := flag.NewFlagSet("config print", flag.ExitOnError)
+	htmlDir := fs.String("html", "", "HTML directory to scan")
+	cssDir := fs.String("css", "", "CSS directory or file(s) to parse (comma-separated)")
+	configPath := fs.String("config", "cssguard.json", "Trained config file")
+	baselinePath := fs.String("baseline", "", "Baseline file of accepted orphans")
+	ignore := fs.String("ignore", "", "Class globs that are never orphans (comma-separated, e.g. js-*,wp-block-*)")
+	threshold := fs.Float64("redundancy-threshold", 80.0, "Coverage threshold for redundancy warnings (%)")
+	suggestThreshold := addSuggestFlag(fs)
+	format := addFormatFlag(fs, outputFormats)
+	verbose := fs.Bool("verbose", false, "Verbose output")
+	showUnused := fs.Bool("unused", false, "Also report unused CSS classes")
+	severity := addSeverityFlag(fs)
+	src := addSrcFlags(fs)
+	jobs := addJobsFlag(fs)
+	cacheDir := addCacheFlag(fs)
+	projectPath := addProjectFlag(fs)
+	fs.Parse(args[1:])
+
+	c := applyProject(fs, *projectPath, nil)
+	checkFormat(*format, outputFormats)
+	rules := ruleConfig(c, "")
+
+	effective := &project.Config{
+		HTML:                *htmlDir,
+		CSS:                 splitList(*cssDir),
+		Src:                 src.paths,
+		SrcExt:              splitList(*src.ext),
+		SrcExclude:          splitList(*src.exclude),
+		Trained:             *configPath,
+		Baseline:            *baselinePath,
+		Ignore:              splitList(*ignore),
+		IgnoreRules:         rules.IgnoreRules,
+		Safelist:            rules.Safelist,
+		Severity:            severity.levels.Effective(),
+		RedundancyThreshold: *threshold,
+		SuggestThreshold:    suggestThreshold,
+		Jobs:                *jobs,
+		CacheDir:            *cacheDir,
+		Output: project.Output{
+			Format:  *format,
+			Verbose: *verbose,
+			Unused:  *showUnused,
+		},
+	}
+
+	if c != nil {
+		fmt.Printf("# Project config: %s (flags override file values)\n", c.Path)
+	} else {
+		fmt.Println("# No project config found; showing defaults and flags")
+	}
+	if err := effective.Encode(os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing config: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,506 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/JCorners68/cssguard/pkg/cache"
+	"github.com/JCorners68/cssguard/pkg/extractor"
+	"github.com/JCorners68/cssguard/pkg/parallel"
+	"github.com/JCorners68/cssguard/pkg/parser"
+	"github.com/JCorners68/cssguard/pkg/report"
+	"github.com/JCorners68/cssguard/pkg/srcscan"
+	"github.com/JCorners68/cssguard/pkg/trainer"
+	"github.com/JCorners68/cssguard/pkg/validator"
+	"github.com/JCorners68/cssguard/pkg/watch"
+)
+
+func watchCmd(args []string) {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	htmlDir := fs.String("html", "", "HTML directory to watch")
+	cssDir := fs.String("css", "", "CSS directory or file(s) to watch (comma-separated)")
+	configPath := fs.String("config", "", "Trained config file to validate against instead of the CSS, as validate does")
+	ignore := fs.String("ignore", "", "Class globs that are never orphans (comma-separated, e.g. js-*,wp-block-*)")
+	contextOrphans := fs.Bool("context-orphans", false, "Also report classes used outside the structure their selectors need (without --config)")
+	suggestThreshold := addSuggestFlag(fs)
+	severity := addSeverityFlag(fs)
+	interval := fs.Duration("interval", 500*time.Millisecond, "How often to poll the watched files for changes")
+	debounce := fs.Duration("debounce", 300*time.Millisecond, "Quiet time after the last change before re-validating")
+	jobs := addJobsFlag(fs)
+	projectPath := addProjectFlag(fs)
+
+	// Source scanning flags
+	src := addSrcFlags(fs)
+
+	fs.Parse(args)
+	proj := applyProject(fs, *projectPath, nil)
+
+	if *htmlDir == "" || *cssDir == "" && *configPath == "" {
+		fmt.Fprintln(os.Stderr, "Error: --html and --css or --config are required")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	s := &site{
+		rules:          ruleConfig(proj, *ignore),
+		trained:        *configPath,
+		contextOrphans: *contextOrphans && *configPath == "",
+		suggest:        *suggestThreshold,
+		severity:       severity.levels,
+		jobs:           *jobs,
+		htmlRoots:      []string{*htmlDir},
+		srcRoots:       src.paths,
+		scanner: srcscan.New(srcscan.Options{
+			Extensions: srcscan.ParseExtensions(*src.ext),
+			Excludes:   srcscan.ParseExcludes(*src.exclude),
+		}),
+		kinds:    make(map[string]fileKind),
+		html:     make(map[string][]extractor.Occurrence),
+		pages:    make(map[string]extractor.Page),
+		src:      make(map[string][]srcscan.Occurrence),
+		css:      make(map[string][]parser.Definition),
+		cssFiles: make(map[string][]string),
+	}
+	if s.trained != "" {
+		// Like validate, check against the trained patterns; the CSS
+		// only counts once it is trained again
+		s.validator = loadValidator(s.trained, s.rules)
+	} else {
+		s.validator = newValidator(s.rules)
+		s.cssRoots = splitList(*cssDir)
+	}
+
+	start, err := s.snapshot()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error scanning files: %v\n", err)
+		os.Exit(1)
+	}
+	s.update(watch.Diff(nil, start))
+	roots := append(append([]string{*htmlDir}, s.cssRoots...), s.srcRoots...)
+	if s.trained != "" {
+		roots = append(roots, s.trained)
+	}
+	fmt.Printf("Watching %s (Ctrl+C to stop)\n\n", strings.Join(roots, ", "))
+	s.printInitial(s.validate())
+
+	stop := make(chan struct{})
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		close(stop)
+	}()
+
+	w := &watch.Watcher{Scan: s.snapshot, Start: start, Interval: *interval, Debounce: *debounce}
+	err = w.Run(stop, func(c watch.Changes) {
+		s.update(c)
+		s.printChanges(c, s.validate())
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error watching files: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// fileKind is the kind of input a watched file is.
+type fileKind int
+
+const (
+	htmlFile fileKind = iota
+	cssFile
+	srcFile
+	trainedFile
+)
+
+// site caches what was extracted from each watched file, so a change only
+// re-reads the files that changed. CSS is parsed per --css root with its
+// imports, so a change re-parses the roots that read the changed file. With
+// a trained config the CSS is not read; the classes are checked against the
+// config, which is reloaded when it changes.
+type site struct {
+	validator      *validator.Validator
+	rules          *trainer.Config // Ignore rules and safelist added to a trained config
+	trained        string          // Trained config file, if any
+	contextOrphans bool            // Check uses against the structure of structural selectors
+	suggest        float64
+	severity       report.Severity
+	scanner        *srcscan.Scanner
+	jobs           int // Files to re-read in parallel
+
+	htmlRoots, cssRoots, srcRoots []string
+
+	kinds    map[string]fileKind // Path -> kind, for every file seen
+	html     map[string][]extractor.Occurrence
+	pages    map[string]extractor.Page // Element trees, kept for context orphans only
+	src      map[string][]srcscan.Occurrence
+	css      map[string][]parser.Definition // CSS root -> definitions
+	cssFiles map[string][]string            // CSS root -> absolute paths of the files it reads
+	imported []string                       // Files imported from outside the CSS roots
+
+	orphans, printOnly, contextOrphaned []string // Findings of the last run
+}
+
+// snapshot records the state of every watched file and its kind.
+func (s *site) snapshot() (watch.Snapshot, error) {
+	snap := make(watch.Snapshot)
+	add := func(kind fileKind, roots []string, include watch.IncludeFunc) error {
+		before := make(map[string]bool, len(snap))
+		for path := range snap {
+			before[path] = true
+		}
+		if err := snap.Add(roots, include); err != nil {
+			return err
+		}
+		for path := range snap {
+			if !before[path] {
+				s.kinds[path] = kind
+			}
+		}
+		return nil
+	}
+
+	err := add(htmlFile, s.htmlRoots, func(path string, d fs.DirEntry) bool {
+		return d.IsDir() || strings.HasSuffix(strings.ToLower(path), ".html")
+	})
+	if err == nil {
+		cssRoots := append(append([]string(nil), s.cssRoots...), s.imported...)
+		err = add(cssFile, cssRoots, func(path string, d fs.DirEntry) bool {
+			return d.IsDir() || isRoot(path, cssRoots) || parser.IsStylesheet(path)
+		})
+	}
+	if err == nil {
+		err = add(srcFile, s.srcRoots, func(path string, d fs.DirEntry) bool {
+			return isRoot(path, s.srcRoots) || s.scanner.Includes(path, d.IsDir())
+		})
+	}
+	if err == nil && s.trained != "" {
+		err = add(trainedFile, []string{s.trained}, func(string, fs.DirEntry) bool {
+			return true
+		})
+	}
+	return snap, err
+}
+
+func isRoot(path string, roots []string) bool {
+	for _, root := range roots {
+		if path == root {
+			return true
+		}
+	}
+	return false
+}
+
+// update re-reads changed files and forgets removed ones. Files that cannot
+// be read are reported and skipped.
+func (s *site) update(c watch.Changes) {
+	var cssChanged []string
+	for _, path := range append(append([]string(nil), c.Changed...), c.Removed...) {
+		if s.kinds[path] == cssFile {
+			cssChanged = append(cssChanged, path)
+		}
+	}
+	for _, path := range c.Changed {
+		if s.kinds[path] == trainedFile {
+			s.reloadTrained()
+		}
+	}
+	for _, path := range c.Removed {
+		delete(s.html, path)
+		delete(s.pages, path)
+		delete(s.src, path)
+		delete(s.css, path)
+		delete(s.kinds, path)
+	}
+
+	type extracted struct {
+		html []extractor.Occurrence
+		page extractor.Page
+		src  []srcscan.Occurrence
+		err  error
+	}
+	results, _ := parallel.Map(c.Changed, s.jobs, func(path string) (extracted, error) {
+		var e extracted
+		switch s.kinds[path] {
+		case htmlFile:
+			e.html, e.err = extractor.ExtractOccurrencesFromFile(path)
+			if e.err == nil && s.contextOrphans {
+				e.page, e.err = cache.Load(nil, cache.KindDOM, path, extractor.ExtractPageFromReader)
+			}
+		case srcFile:
+			e.src, e.err = s.scanner.ScanFile(path)
+		}
+		return e, nil
+	})
+	for i, path := range c.Changed {
+		e := results[i]
+		if e.err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", path, e.err)
+		}
+		switch s.kinds[path] {
+		case htmlFile:
+			s.html[path] = e.html
+			if s.contextOrphans {
+				s.pages[path] = e.page
+			}
+		case srcFile:
+			s.src[path] = e.src
+		}
+	}
+	s.updateCSS(cssChanged)
+}
+
+// reloadTrained reloads the trained config. A config that cannot be loaded
+// is reported and the last one is kept.
+func (s *site) reloadTrained() {
+	config, err := trainedConfig(s.trained, s.rules)
+	if err == nil {
+		var v *validator.Validator
+		if v, err = validator.New(config); err == nil {
+			s.validator = v
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", s.trained, err)
+}
+
+// updateCSS re-parses the CSS roots that read or contain the changed
+// paths, following imports as loadCSS does, and collects the imported files
+// outside the roots so they are watched too. A root that cannot be parsed
+// is reported and keeps its last definitions.
+func (s *site) updateCSS(changed []string) {
+	if len(changed) == 0 {
+		return
+	}
+	for _, root := range s.cssRoots {
+		if !s.reads(root, changed) {
+			continue
+		}
+		defs, files, warnings, err := parser.ParseWithImportedFiles(root, s.jobs, nil)
+		for _, w := range warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", root, err)
+			continue
+		}
+		s.css[root] = defs
+		s.cssFiles[root] = files
+	}
+
+	s.imported = nil
+	for _, root := range s.cssRoots {
+		for _, file := range s.cssFiles[root] {
+			if !s.underCSSRoot(file) {
+				s.imported = append(s.imported, file)
+			}
+		}
+	}
+}
+
+// reads reports whether the CSS root read any of paths when it was last
+// parsed, or contains one of them.
+func (s *site) reads(root string, paths []string) bool {
+	for _, path := range paths {
+		abs, _ := filepath.Abs(path)
+		if within(abs, root) {
+			return true
+		}
+		for _, file := range s.cssFiles[root] {
+			if file == abs {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// underCSSRoot reports whether the absolute path is a CSS root or inside
+// one, so a walk of the roots finds it.
+func (s *site) underCSSRoot(abs string) bool {
+	for _, root := range s.cssRoots {
+		if within(abs, root) {
+			return true
+		}
+	}
+	return false
+}
+
+// within reports whether the absolute path abs is root or inside it.
+func within(abs, root string) bool {
+	rootAbs, err := filepath.Abs(root)
+	if err != nil {
+		return false
+	}
+	return abs == rootAbs || strings.HasPrefix(abs, rootAbs+string(filepath.Separator))
+}
+
+// validate checks the cached HTML and source classes as validate does with a
+// trained config, and as direct does against the cached CSS otherwise.
+func (s *site) validate() *validator.Result {
+	html := &htmlInput{classes: make(map[string]struct{})}
+	for _, path := range sortedKeys(s.html) {
+		html.occurrences = append(html.occurrences, s.html[path]...)
+	}
+	for _, path := range sortedKeys(s.src) {
+		html.srcOccurrences = append(html.srcOccurrences, s.src[path]...)
+	}
+	for c := range extractor.ClassSet(html.occurrences) {
+		html.classes[c] = struct{}{}
+	}
+	for c := range srcscan.ClassSet(html.srcOccurrences) {
+		html.classes[c] = struct{}{}
+	}
+
+	if s.trained != "" {
+		result := s.validator.ValidateAgainstPatterns(html.classes)
+		html.annotate(result)
+		contexts, printOnly := s.validator.Contexts()
+		html.annotateContexts(result, contexts, printOnly)
+		if s.suggest > 0 {
+			result.Suggest(s.validator.KnownClasses(), s.suggest)
+		}
+		return result
+	}
+
+	var defs []parser.Definition
+	for _, root := range s.cssRoots {
+		defs = append(defs, s.css[root]...)
+	}
+	cssClasses := parser.ClassSet(defs)
+
+	result := s.validator.ValidateDirectly(html.classes, cssClasses)
+	html.annotate(result)
+	html.annotateContexts(result, parser.ClassContexts(defs), parser.PrintOnlyClasses(defs))
+	if s.contextOrphans {
+		pages := make([]extractor.Page, 0, len(s.pages))
+		for _, path := range sortedKeys(s.pages) {
+			pages = append(pages, s.pages[path])
+		}
+		result.AddContextOrphans(defs, pages)
+	}
+	if s.suggest > 0 {
+		result.Suggest(cssClasses, s.suggest)
+	}
+	return result
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// printInitial prints the summary and orphans of the first run.
+func (s *site) printInitial(result *validator.Result) {
+	fmt.Print(result.Summary())
+	if result.HasOrphans() {
+		fmt.Println("\nOrphan classes:")
+		for _, class := range result.Orphans {
+			printOrphan(result, class)
+		}
+	}
+	printPrintOnly(result, s.severity)
+	printContextOrphans(result, s.severity)
+	printRules(result, false)
+	s.orphans = result.Orphans
+	s.printOnly, s.contextOrphaned = s.warnings(result)
+}
+
+// warnings returns the print-only classes and context orphans of result, or
+// none for a rule that is off.
+func (s *site) warnings(result *validator.Result) (printOnly, contextOrphans []string) {
+	if s.severity.Level(report.RulePrintOnly) != report.LevelOff {
+		printOnly = result.PrintOnlyClasses()
+	}
+	if s.severity.Level(report.RuleContextOrphan) != report.LevelOff {
+		contextOrphans = result.ContextOrphanClasses()
+	}
+	return printOnly, contextOrphans
+}
+
+// printChanges prints which orphans appeared and which were resolved since
+// the last run.
+func (s *site) printChanges(c watch.Changes, result *validator.Result) {
+	added, resolved := diffSorted(s.orphans, result.Orphans)
+	s.orphans = result.Orphans
+	printOnly, contextOrphans := s.warnings(result)
+	printOnlyAdded, printOnlyResolved := diffSorted(s.printOnly, printOnly)
+	contextAdded, contextResolved := diffSorted(s.contextOrphaned, contextOrphans)
+	s.printOnly, s.contextOrphaned = printOnly, contextOrphans
+
+	what := fmt.Sprintf("%d file(s) changed", len(c.Changed))
+	if len(c.Removed) > 0 {
+		what += fmt.Sprintf(", %d removed", len(c.Removed))
+	}
+	fmt.Printf("\n[%s] %s\n", time.Now().Format("15:04:05"), what)
+
+	for _, class := range added {
+		fmt.Print("  + ")
+		printOrphanLine(result, class)
+	}
+	for _, class := range resolved {
+		fmt.Printf("  - %s (resolved)\n", class)
+	}
+	for _, class := range printOnlyAdded {
+		fmt.Printf("  + %s (only defined for print: %s)\n", class, strings.Join(result.Contexts[class], ", "))
+	}
+	for _, class := range printOnlyResolved {
+		fmt.Printf("  - %s (no longer print-only)\n", class)
+	}
+	for _, class := range contextAdded {
+		fmt.Printf("  + %s (context orphan: %s)\n", class, strings.Join(result.ContextOrphans[class].Selectors, ", "))
+	}
+	for _, class := range contextResolved {
+		fmt.Printf("  - %s (no longer a context orphan)\n", class)
+	}
+	if len(added) == 0 && len(resolved) == 0 {
+		fmt.Println("  No change in orphans")
+	}
+	fmt.Printf("Orphans: %d (+%d new, -%d resolved)\n", result.OrphanCount, len(added), len(resolved))
+}
+
+// printOrphanLine prints an orphan on one line with its first location and
+// the best suggestion.
+func printOrphanLine(result *validator.Result, class string) {
+	line := class
+	if locs := result.OrphanLocations[class]; len(locs) > 0 {
+		line += " " + locs[0].String()
+		if len(locs) > 1 {
+			line += fmt.Sprintf(" (+%d more)", len(locs)-1)
+		}
+	}
+	if s := result.Suggestions[class]; len(s) > 0 {
+		line += fmt.Sprintf(" (did you mean %s?)", s[0].Class)
+	}
+	fmt.Println(line)
+}
+
+// diffSorted returns the entries only in cur and those only in prev; both
+// slices must be sorted.
+func diffSorted(prev, cur []string) (added, removed []string) {
+	i, j := 0, 0
+	for i < len(prev) || j < len(cur) {
+		switch {
+		case j == len(cur) || (i < len(prev) && prev[i] < cur[j]):
+			removed = append(removed, prev[i])
+			i++
+		case i == len(prev) || cur[j] < prev[i]:
+			added = append(added, cur[j])
+			j++
+		default:
+			i++
+			j++
+		}
+	}
+	return added, removed
+}
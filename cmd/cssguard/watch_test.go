@@ -0,0 +1,151 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/JCorners68/cssguard/pkg/extractor"
+	"github.com/JCorners68/cssguard/pkg/parser"
+	"github.com/JCorners68/cssguard/pkg/srcscan"
+	"github.com/JCorners68/cssguard/pkg/trainer"
+	"github.com/JCorners68/cssguard/pkg/watch"
+)
+
+func TestSiteUpdate(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("public/index.html", `<div class="flex btn-primray"></div>`)
+	write("public/about.html", `<p class="hidden"></p>`)
+	write("css/site.css", `@import "../vendor/extra.css"; .flex {} .btn-primary {}`)
+	write("vendor/extra.css", `.extra-a {}`)
+
+	s := &site{
+		validator: newValidator(&trainer.Config{}),
+		htmlRoots: []string{filepath.Join(dir, "public")},
+		cssRoots:  []string{filepath.Join(dir, "css")},
+		scanner:   srcscan.New(srcscan.Options{}),
+		kinds:     make(map[string]fileKind),
+		html:      make(map[string][]extractor.Occurrence),
+		src:       make(map[string][]srcscan.Occurrence),
+		css:       make(map[string][]parser.Definition),
+		cssFiles:  make(map[string][]string),
+	}
+	snap, err := s.snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.update(watch.Diff(nil, snap))
+	if got := s.validate().Orphans; !reflect.DeepEqual(got, []string{"btn-primray", "hidden"}) {
+		t.Fatalf("initial orphans = %v", got)
+	}
+
+	// Fix the typo, drop the page using hidden, define a new class and
+	// change the imported file outside the CSS root
+	write("public/index.html", `<div class="flex btn-primary card extra-b"></div>`)
+	write("css/site.css", `@import "../vendor/extra.css"; .flex {} .btn-primary {} .card {}`)
+	write("vendor/extra.css", `.extra-b {}`)
+	if err := os.Remove(filepath.Join(dir, "public/about.html")); err != nil {
+		t.Fatal(err)
+	}
+	cur, err := s.snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.update(watch.Diff(snap, cur))
+	if got := s.validate().Orphans; len(got) != 0 {
+		t.Errorf("orphans after update = %v, want none", got)
+	}
+
+	// A change to the imported file alone re-parses the root importing it
+	write("vendor/extra.css", `.extra-c {}`)
+	next, err := s.snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.update(watch.Diff(cur, next))
+	if got := s.validate().Orphans; !reflect.DeepEqual(got, []string{"extra-b"}) {
+		t.Errorf("orphans after import change = %v, want [extra-b]", got)
+	}
+}
+
+func TestDiffSorted(t *testing.T) {
+	added, removed := diffSorted([]string{"a", "c", "d"}, []string{"b", "c", "e"})
+	if !reflect.DeepEqual(added, []string{"b", "e"}) {
+		t.Errorf("added = %v, want [b e]", added)
+	}
+	if !reflect.DeepEqual(removed, []string{"a", "d"}) {
+		t.Errorf("removed = %v, want [a d]", removed)
+	}
+}
+
+func TestSiteUpdateTrained(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "cssguard.json")
+	train := func(classes ...string) {
+		tr := trainer.New()
+		set := make(map[string]struct{})
+		for _, c := range classes {
+			set[c] = struct{}{}
+		}
+		tr.AddClasses(set)
+		tr.AddContexts(map[string][]string{"receipt": {"@media print"}}, map[string]struct{}{"receipt": {}})
+		tr.Train()
+		if err := tr.SaveConfig(configPath); err != nil {
+			t.Fatal(err)
+		}
+	}
+	train("card", "receipt")
+	if err := os.MkdirAll(filepath.Join(dir, "public"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	page := `<div class="card widget receipt"></div>`
+	if err := os.WriteFile(filepath.Join(dir, "public/index.html"), []byte(page), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &site{
+		rules:     &trainer.Config{},
+		trained:   configPath,
+		validator: loadValidator(configPath, &trainer.Config{}),
+		htmlRoots: []string{filepath.Join(dir, "public")},
+		scanner:   srcscan.New(srcscan.Options{}),
+		kinds:     make(map[string]fileKind),
+		html:      make(map[string][]extractor.Occurrence),
+		src:       make(map[string][]srcscan.Occurrence),
+		css:       make(map[string][]parser.Definition),
+		cssFiles:  make(map[string][]string),
+	}
+	snap, err := s.snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.update(watch.Diff(nil, snap))
+	result := s.validate()
+	if !reflect.DeepEqual(result.Orphans, []string{"widget"}) {
+		t.Fatalf("initial orphans = %v, want [widget]", result.Orphans)
+	}
+	if got := result.PrintOnlyClasses(); !reflect.DeepEqual(got, []string{"receipt"}) {
+		t.Errorf("print-only classes = %v, want [receipt]", got)
+	}
+
+	// Training again picks up the new class
+	train("card", "receipt", "widget")
+	cur, err := s.snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.update(watch.Diff(snap, cur))
+	if got := s.validate().Orphans; len(got) != 0 {
+		t.Errorf("orphans after training = %v, want none", got)
+	}
+}
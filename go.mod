@@ -2,4 +2,7 @@ module github.com/JCorners68/cssguard
 
 go 1.21
 
-require golang.org/x/net v0.19.0
+require (
+	golang.org/x/net v0.19.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,91 @@
+// Package baseline records accepted orphan classes so validation fails only
+// on orphans introduced after the snapshot.
+package baseline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/JCorners68/cssguard/pkg/validator"
+)
+
+// Version is the baseline file format version.
+const Version = "1.0.0"
+
+// Entry is an accepted orphan class and where it was used when the baseline
+// was created. Entries are matched by class; locations are informational.
+type Entry struct {
+	Class     string               `json:"class"`
+	Locations []validator.Location `json:"locations,omitempty"`
+}
+
+// Baseline is a snapshot of accepted orphans.
+type Baseline struct {
+	Version string  `json:"version"`
+	Orphans []Entry `json:"orphans"`
+}
+
+// FromResult snapshots the orphans of a validation result.
+func FromResult(result *validator.Result) *Baseline {
+	b := &Baseline{Version: Version, Orphans: []Entry{}}
+	for _, class := range result.Orphans {
+		b.Orphans = append(b.Orphans, Entry{
+			Class:     class,
+			Locations: result.OrphanLocations[class],
+		})
+	}
+	return b
+}
+
+// Load reads a baseline file.
+func Load(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("parsing baseline %s: %w", path, err)
+	}
+	return &b, nil
+}
+
+// Save writes the baseline to a file.
+func (b *Baseline) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Apply compares the result's orphans with the baseline and records the
+// outcome in result.Baseline: which orphans are new, which are accepted, and
+// which baseline entries no longer occur and can be removed.
+func (b *Baseline) Apply(result *validator.Result) {
+	accepted := make(map[string]struct{}, len(b.Orphans))
+	for _, e := range b.Orphans {
+		accepted[e.Class] = struct{}{}
+	}
+
+	status := &validator.BaselineStatus{}
+	current := make(map[string]struct{}, len(result.Orphans))
+	for _, class := range result.Orphans {
+		current[class] = struct{}{}
+		if _, ok := accepted[class]; ok {
+			status.Known = append(status.Known, class)
+		} else {
+			status.New = append(status.New, class)
+		}
+	}
+	for class := range accepted {
+		if _, ok := current[class]; !ok {
+			status.Resolved = append(status.Resolved, class)
+		}
+	}
+	sort.Strings(status.Resolved)
+
+	result.Baseline = status
+}
@@ -0,0 +1,65 @@
+package baseline
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JCorners68/cssguard/pkg/validator"
+)
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	result := &validator.Result{
+		Orphans: []string{"legacy-a", "legacy-b"},
+		OrphanLocations: map[string][]validator.Location{
+			"legacy-a": {{File: "index.html", Line: 3, Column: 5, Tag: "div"}},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := FromResult(result).Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Version != Version || len(b.Orphans) != 2 {
+		t.Fatalf("loaded %+v", b)
+	}
+	if locs := b.Orphans[0].Locations; len(locs) != 1 || locs[0].String() != "index.html:3:5" {
+		t.Errorf("locations = %v, want [index.html:3:5]", locs)
+	}
+}
+
+func TestApply(t *testing.T) {
+	b := &Baseline{Orphans: []Entry{{Class: "known"}, {Class: "fixed-b"}, {Class: "fixed-a"}}}
+	result := &validator.Result{Orphans: []string{"known", "new-one"}, OrphanCount: 2}
+
+	b.Apply(result)
+
+	status := result.Baseline
+	if strings.Join(status.New, ",") != "new-one" {
+		t.Errorf("New = %v, want [new-one]", status.New)
+	}
+	if strings.Join(status.Known, ",") != "known" {
+		t.Errorf("Known = %v, want [known]", status.Known)
+	}
+	if strings.Join(status.Resolved, ",") != "fixed-a,fixed-b" {
+		t.Errorf("Resolved = %v, want [fixed-a fixed-b]", status.Resolved)
+	}
+	if !result.HasNewOrphans() {
+		t.Error("HasNewOrphans() = false, want true")
+	}
+	if !result.IsKnownOrphan("known") || result.IsKnownOrphan("new-one") {
+		t.Error("IsKnownOrphan() disagrees with Known")
+	}
+
+	// Only accepted orphans left: nothing new to fail on
+	result = &validator.Result{Orphans: []string{"known"}, OrphanCount: 1}
+	b.Apply(result)
+	if result.HasNewOrphans() {
+		t.Error("HasNewOrphans() = true with only baseline orphans")
+	}
+}
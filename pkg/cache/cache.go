@@ -0,0 +1,139 @@
+// Package cache stores what was extracted from each input file on disk,
+// keyed by the file's path and content and the tool version, so unchanged
+// files are not parsed again on the next run.
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+)
+
+// Kinds of cached extraction, one directory each.
+const (
+	KindHTML = "html" // extractor occurrences
+	KindCSS  = "css"  // parser definitions
+	KindSrc  = "src"  // srcscan occurrences
+	KindDOM  = "dom"  // extractor element trees
+)
+
+var kinds = []string{KindHTML, KindCSS, KindSrc, KindDOM}
+
+// format is part of every key. Bump it when the shape of what is cached
+// changes, so entries written by older builds of the same version are missed
+// rather than decoded with fields missing.
+const format = "4"
+
+// Cache is an on-disk cache directory. A nil *Cache caches nothing.
+type Cache struct {
+	dir     string
+	version string
+
+	hits, misses atomic.Int64
+}
+
+// New returns a cache in dir for results of the given tool version. The
+// directory is created when the first entry is stored.
+func New(dir, version string) *Cache {
+	return &Cache{dir: dir, version: version}
+}
+
+// Stats returns how many lookups were served from the cache and how many
+// files had to be parsed.
+func (c *Cache) Stats() (hits, misses int64) {
+	if c == nil {
+		return 0, 0
+	}
+	return c.hits.Load(), c.misses.Load()
+}
+
+// Load returns what parse extracts from the file at path. If the cache holds
+// a result for the same path, content and version it is returned without
+// parsing; otherwise the result is parsed from the file content and stored.
+// Entries that cannot be read or written are ignored.
+func Load[T any](c *Cache, kind, path string, parse func(r io.Reader, file string) (T, error)) (T, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	if c == nil {
+		return parse(bytes.NewReader(data), path)
+	}
+
+	entry := c.entryPath(kind, path, data)
+	if cached, err := os.ReadFile(entry); err == nil {
+		var v T
+		if gob.NewDecoder(bytes.NewReader(cached)).Decode(&v) == nil {
+			c.hits.Add(1)
+			return v, nil
+		}
+	}
+
+	c.misses.Add(1)
+	v, err := parse(bytes.NewReader(data), path)
+	if err != nil {
+		return v, err
+	}
+	var encoded bytes.Buffer
+	if gob.NewEncoder(&encoded).Encode(v) == nil {
+		c.store(entry, encoded.Bytes())
+	}
+	return v, nil
+}
+
+// entryPath returns the file holding the entry for a path and content.
+func (c *Cache) entryPath(kind, path string, content []byte) string {
+	h := sha256.New()
+	for _, s := range []string{format, c.version, kind, path} {
+		io.WriteString(h, s)
+		h.Write([]byte{0})
+	}
+	h.Write(content)
+	key := hex.EncodeToString(h.Sum(nil))
+	return filepath.Join(c.dir, kind, key[:2], key+".gob")
+}
+
+// store writes an entry atomically, so concurrent runs never read a partial
+// entry.
+func (c *Cache) store(entry string, data []byte) {
+	if err := os.MkdirAll(filepath.Dir(entry), 0755); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(entry), ".tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), entry)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// Clean removes every cache entry in dir, and dir itself if nothing else is
+// left in it. Other files in dir are kept.
+func Clean(dir string) error {
+	for _, kind := range kinds {
+		if err := os.RemoveAll(filepath.Join(dir, kind)); err != nil {
+			return err
+		}
+	}
+	if err := os.Remove(dir); err != nil && !os.IsNotExist(err) {
+		if entries, readErr := os.ReadDir(dir); readErr == nil && len(entries) > 0 {
+			return nil // Not empty: leave it
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "index.html")
+	write := func(content string) {
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	parses := 0
+	parse := func(r io.Reader, path string) ([]string, error) {
+		parses++
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		if strings.Contains(string(data), "bad") {
+			return nil, errors.New("cannot parse")
+		}
+		return append(strings.Fields(string(data)), path), nil
+	}
+	load := func(c *Cache) []string {
+		t.Helper()
+		got, err := Load(c, KindHTML, file, parse)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	c := New(filepath.Join(dir, "cache"), "1.0.0")
+	write("flex card")
+	tests := []struct {
+		name       string
+		cache      *Cache
+		content    string // Written first if set
+		wantParses int
+		want       string
+	}{
+		{name: "first run parses", cache: c, wantParses: 1, want: "flex card " + file},
+		{name: "unchanged file is cached", cache: c, wantParses: 1, want: "flex card " + file},
+		{name: "changed content parses", cache: c, content: "flex", wantParses: 2, want: "flex " + file},
+		{name: "back to earlier content is cached", cache: c, content: "flex card", wantParses: 2, want: "flex card " + file},
+		{name: "new version parses", cache: New(filepath.Join(dir, "cache"), "1.1.0"), wantParses: 3, want: "flex card " + file},
+		{name: "nil cache always parses", cache: nil, wantParses: 4, want: "flex card " + file},
+	}
+	for _, tt := range tests {
+		if tt.content != "" {
+			write(tt.content)
+		}
+		got := strings.Join(load(tt.cache), " ")
+		if got != tt.want || parses != tt.wantParses {
+			t.Errorf("%s: got %q after %d parses, want %q after %d", tt.name, got, parses, tt.want, tt.wantParses)
+		}
+	}
+	if hits, misses := c.Stats(); hits != 2 || misses != 2 {
+		t.Errorf("Stats() = %d hits, %d misses, want 2 and 2", hits, misses)
+	}
+
+	// Parse errors are returned and not cached
+	write("bad")
+	for i := 0; i < 2; i++ {
+		if _, err := Load(c, KindHTML, file, parse); err == nil {
+			t.Error("Load() error = nil, want parse error")
+		}
+	}
+	if parses != 6 {
+		t.Errorf("parses = %d, want failed parses to be retried", parses)
+	}
+
+	if _, err := Load(c, KindHTML, filepath.Join(dir, "missing.html"), parse); !os.IsNotExist(err) {
+		t.Errorf("Load(missing) error = %v, want not exist", err)
+	}
+}
+
+func TestClean(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "site.css")
+	if err := os.WriteFile(file, []byte(".flex {}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	parse := func(r io.Reader, path string) (string, error) { return path, nil }
+
+	// A dedicated cache directory is removed entirely
+	cacheDir := filepath.Join(dir, "cache")
+	if _, err := Load(New(cacheDir, "1.0.0"), KindCSS, file, parse); err != nil {
+		t.Fatal(err)
+	}
+	if err := Clean(cacheDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(cacheDir); !os.IsNotExist(err) {
+		t.Errorf("cache dir still exists after Clean: %v", err)
+	}
+
+	// A shared directory keeps everything but the entries
+	if _, err := Load(New(dir, "1.0.0"), KindCSS, file, parse); err != nil {
+		t.Fatal(err)
+	}
+	if err := Clean(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, KindCSS)); !os.IsNotExist(err) {
+		t.Errorf("entries still exist after Clean: %v", err)
+	}
+	if _, err := os.Stat(file); err != nil {
+		t.Errorf("Clean removed an unrelated file: %v", err)
+	}
+
+	if err := Clean(filepath.Join(dir, "missing")); err != nil {
+		t.Errorf("Clean(missing) error = %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package extractor
+
+import (
+	"io"
+
+	"golang.org/x/net/html"
+
+	"github.com/JCorners68/cssguard/pkg/cache"
+	"github.com/JCorners68/cssguard/pkg/parallel"
+)
+
+// Page is the element tree of an HTML file, for matching selectors that
+// relate elements, such as .card > .card-title.
+type Page struct {
+	File     string    `json:"file,omitempty"`
+	Elements []Element `json:"elements"` // In document order
+}
+
+// Element is an element of a Page. Parent and Prev index Page.Elements, so
+// pages can be cached.
+type Element struct {
+	Tag     string   `json:"tag"`
+	Classes []string `json:"classes,omitempty"`
+	Line    int      `json:"line"`
+	Column  int      `json:"column"`
+	Parent  int      `json:"parent"` // Parent element, or -1 at the top
+	Prev    int      `json:"prev"`   // Previous sibling element, or -1
+
+	// Suppressed lists the classes a cssguard-ignore comment silences.
+	Suppressed []string `json:"suppressed,omitempty"`
+}
+
+// voidElements never have content or an end tag.
+var voidElements = map[string]struct{}{
+	"area": {}, "base": {}, "br": {}, "col": {}, "embed": {}, "hr": {}, "img": {},
+	"input": {}, "link": {}, "meta": {}, "source": {}, "track": {}, "wbr": {},
+}
+
+// closedBy maps a start tag to the open elements it implicitly closes, the
+// optional end tags of HTML that matter most for page structure.
+var closedBy = map[string]map[string]struct{}{
+	"li":       {"li": {}},
+	"dt":       {"dt": {}, "dd": {}},
+	"dd":       {"dt": {}, "dd": {}},
+	"option":   {"option": {}},
+	"tr":       {"tr": {}, "td": {}, "th": {}},
+	"td":       {"td": {}, "th": {}},
+	"th":       {"td": {}, "th": {}},
+	"tbody":    {"thead": {}, "tbody": {}, "tr": {}, "td": {}, "th": {}},
+	"tfoot":    {"thead": {}, "tbody": {}, "tr": {}, "td": {}, "th": {}},
+	"p":        {"p": {}},
+	"div":      {"p": {}},
+	"ul":       {"p": {}},
+	"ol":       {"p": {}},
+	"dl":       {"p": {}},
+	"table":    {"p": {}},
+	"section":  {"p": {}},
+	"article":  {"p": {}},
+	"aside":    {"p": {}},
+	"header":   {"p": {}},
+	"footer":   {"p": {}},
+	"nav":      {"p": {}},
+	"form":     {"p": {}},
+	"pre":      {"p": {}},
+	"figure":   {"p": {}},
+	"hr":       {"p": {}},
+	"h1":       {"p": {}},
+	"h2":       {"p": {}},
+	"h3":       {"p": {}},
+	"h4":       {"p": {}},
+	"h5":       {"p": {}},
+	"h6":       {"p": {}},
+	"main":     {"p": {}},
+	"details":  {"p": {}},
+	"fieldset": {"p": {}},
+}
+
+// ExtractPageFromReader builds the element tree of an HTML reader. Elements
+// keep the position of their start tag; file is recorded as given. The tree
+// is built from the tags as written, closing void elements, self-closing
+// tags and the common optional end tags (li, p, td, ...), without the full
+// error recovery of a browser.
+func ExtractPageFromReader(r io.Reader, file string) (Page, error) {
+	page := Page{File: file}
+	open := []int{}        // Open elements, innermost last
+	lastChild := []int{-1} // Last child element of the document, then of each open element
+
+	pop := func() {
+		open = open[:len(open)-1]
+		lastChild = lastChild[:len(lastChild)-1]
+	}
+
+	err := walkTags(r, func(t tag) {
+		if t.Type == html.EndTagToken {
+			for i := len(open) - 1; i >= 0; i-- {
+				if page.Elements[open[i]].Tag == t.Data {
+					for len(open) > i {
+						pop()
+					}
+					break
+				}
+			}
+			return
+		}
+
+		for len(open) > 0 {
+			if _, ok := closedBy[t.Data][page.Elements[open[len(open)-1]].Tag]; !ok {
+				break
+			}
+			pop()
+		}
+
+		e := Element{
+			Tag:     t.Data,
+			Classes: t.classes(),
+			Line:    t.line,
+			Column:  t.column,
+			Parent:  -1,
+			Prev:    lastChild[len(lastChild)-1],
+		}
+		if len(open) > 0 {
+			e.Parent = open[len(open)-1]
+		}
+		for _, class := range e.Classes {
+			if t.suppressed(class) {
+				e.Suppressed = append(e.Suppressed, class)
+			}
+		}
+		i := len(page.Elements)
+		page.Elements = append(page.Elements, e)
+		lastChild[len(lastChild)-1] = i
+
+		if _, void := voidElements[t.Data]; !void && t.Type != html.SelfClosingTagToken {
+			open = append(open, i)
+			lastChild = append(lastChild, -1)
+		}
+	})
+	if err != nil {
+		return Page{}, err
+	}
+	return page, nil
+}
+
+// ExtractPagesFromDirCached builds the element tree of every HTML file under
+// dir, in walk order, reusing the trees c holds for unchanged files; c may be
+// nil.
+func ExtractPagesFromDirCached(dir string, jobs int, c *cache.Cache) ([]Page, error) {
+	paths, err := htmlFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	return ExtractPagesCached(paths, jobs, c)
+}
+
+// ExtractPagesCached builds the element tree of each HTML file in paths,
+// parsing up to jobs files at a time; c may be nil.
+func ExtractPagesCached(paths []string, jobs int, c *cache.Cache) ([]Page, error) {
+	return parallel.Map(paths, jobs, func(path string) (Page, error) {
+		return cache.Load(c, cache.KindDOM, path, ExtractPageFromReader)
+	})
+}
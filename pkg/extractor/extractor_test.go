@@ -1,9 +1,14 @@
 package extractor
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/JCorners68/cssguard/pkg/cache"
 )
 
 func TestExtractFromDir_SingleFile(t *testing.T) {
@@ -144,3 +149,163 @@ func TestExtractEmptyClass(t *testing.T) {
 		t.Errorf("expected 0 classes for empty class attributes, got %d", len(classes))
 	}
 }
+
+func TestExtractOccurrencesFromReader(t *testing.T) {
+	html := "<!DOCTYPE html>\n<html><body>\n  <aside class=\"drawer translate-x-0\">\n    <img class=\"icon\"/>\n  </aside>\n</body></html>"
+
+	occs, err := ExtractOccurrencesFromReader(strings.NewReader(html), "index.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Occurrence{
+		{Class: "drawer", File: "index.html", Line: 3, Column: 3, Tag: "aside"},
+		{Class: "translate-x-0", File: "index.html", Line: 3, Column: 3, Tag: "aside"},
+		{Class: "icon", File: "index.html", Line: 4, Column: 5, Tag: "img"},
+	}
+	if len(occs) != len(expected) {
+		t.Fatalf("got %d occurrences %+v, want %d", len(occs), occs, len(expected))
+	}
+	for i, want := range expected {
+		if occs[i] != want {
+			t.Errorf("occurrence %d = %+v, want %+v", i, occs[i], want)
+		}
+	}
+}
+
+func TestExtractOccurrencesSuppression(t *testing.T) {
+	html := `<!-- cssguard-ignore: js-toggle -->
+<button class="btn js-toggle">Menu</button>
+<div class="js-toggle"></div>
+<!-- cssguard-ignore-next-line -->
+<span class="a"></span><span class="b"></span>
+<p class="c"></p>`
+
+	occs, err := ExtractOccurrencesFromReader(strings.NewReader(html), "index.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]bool{ // class -> suppressed, per occurrence
+		"btn":       {false},
+		"js-toggle": {true, false},
+		"a":         {true},
+		"b":         {true},
+		"c":         {false},
+	}
+	got := make(map[string][]bool)
+	for _, o := range occs {
+		got[o.Class] = append(got[o.Class], o.Suppressed)
+	}
+	for class, flags := range want {
+		if len(got[class]) != len(flags) {
+			t.Errorf("%s: got %v, want %v", class, got[class], flags)
+			continue
+		}
+		for i := range flags {
+			if got[class][i] != flags[i] {
+				t.Errorf("%s occurrence %d suppressed = %v, want %v", class, i, got[class][i], flags[i])
+			}
+		}
+	}
+}
+
+// writePages writes n HTML pages of a typical size into nested directories.
+func writePages(tb testing.TB, dir string, n int) {
+	tb.Helper()
+	var page strings.Builder
+	page.WriteString("<!DOCTYPE html><html><body>\n")
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&page, "<div class=\"flex p-%d md:text-lg card-%d\"><a class=\"btn btn-primary\">Link %d</a></div>\n", i%8, i%50, i)
+	}
+	page.WriteString("</body></html>\n")
+	for i := 0; i < n; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("section-%d", i%10), fmt.Sprintf("page-%d.html", i))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			tb.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(page.String()), 0644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+func TestExtractOccurrencesFromDirN_Deterministic(t *testing.T) {
+	dir := t.TempDir()
+	writePages(t, dir, 40)
+
+	want, err := ExtractOccurrencesFromDirN(dir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, jobs := range []int{0, 4, 16} {
+		got, err := ExtractOccurrencesFromDirN(dir, jobs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("jobs=%d: occurrences differ from a sequential run", jobs)
+		}
+	}
+}
+
+func BenchmarkExtractOccurrencesFromDir(b *testing.B) {
+	dir := b.TempDir()
+	writePages(b, dir, 300)
+
+	for _, jobs := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("jobs=%d", jobs), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := ExtractOccurrencesFromDirN(dir, jobs); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+
+	b.Run("cached", func(b *testing.B) {
+		c := cache.New(b.TempDir(), "bench")
+		if _, err := ExtractOccurrencesFromDirCached(dir, 0, c); err != nil {
+			b.Fatal(err)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := ExtractOccurrencesFromDirCached(dir, 0, c); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestExtractPageFromReader(t *testing.T) {
+	doc := `<ul class="list">
+  <li class="a">one
+  <li class="b"><img class="icon"><span class="x">two</span>
+</ul>
+<!-- cssguard-ignore: card -->
+<p class="card">text<div class="y"></div>
+<svg/><br class="z">`
+
+	page, err := ExtractPageFromReader(strings.NewReader(doc), "index.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Element{
+		{Tag: "ul", Classes: []string{"list"}, Line: 1, Column: 1, Parent: -1, Prev: -1},
+		{Tag: "li", Classes: []string{"a"}, Line: 2, Column: 3, Parent: 0, Prev: -1},
+		{Tag: "li", Classes: []string{"b"}, Line: 3, Column: 3, Parent: 0, Prev: 1},
+		{Tag: "img", Classes: []string{"icon"}, Line: 3, Column: 17, Parent: 2, Prev: -1},
+		{Tag: "span", Classes: []string{"x"}, Line: 3, Column: 35, Parent: 2, Prev: 3},
+		{Tag: "p", Classes: []string{"card"}, Line: 6, Column: 1, Parent: -1, Prev: 0, Suppressed: []string{"card"}},
+		{Tag: "div", Classes: []string{"y"}, Line: 6, Column: 21, Parent: -1, Prev: 5},
+		{Tag: "svg", Line: 7, Column: 1, Parent: -1, Prev: 6},
+		{Tag: "br", Classes: []string{"z"}, Line: 7, Column: 7, Parent: -1, Prev: 7},
+	}
+	if page.File != "index.html" {
+		t.Errorf("File = %q, want index.html", page.File)
+	}
+	if !reflect.DeepEqual(page.Elements, expected) {
+		t.Errorf("got %+v\nwant %+v", page.Elements, expected)
+	}
+}
@@ -7,10 +7,28 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"unicode/utf8"
 
 	"golang.org/x/net/html"
+
+	"github.com/JCorners68/cssguard/pkg/cache"
+	"github.com/JCorners68/cssguard/pkg/parallel"
+	"github.com/JCorners68/cssguard/pkg/suppress"
 )
 
+// Occurrence records one use of a class in an HTML class attribute.
+type Occurrence struct {
+	Class  string `json:"class"`
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Tag    string `json:"tag"` // Element the class attribute belongs to
+
+	// Suppressed is set when a cssguard-ignore comment silences orphan
+	// reports for this occurrence.
+	Suppressed bool `json:"suppressed,omitempty"`
+}
+
 // ExtractFromFile extracts all CSS class names from an HTML file.
 func ExtractFromFile(path string) ([]string, error) {
 	f, err := os.Open(path)
@@ -23,43 +41,199 @@ func ExtractFromFile(path string) ([]string, error) {
 
 // ExtractFromReader extracts all CSS class names from an HTML reader.
 func ExtractFromReader(r io.Reader) ([]string, error) {
-	doc, err := html.Parse(r)
+	occs, err := ExtractOccurrencesFromReader(r, "")
 	if err != nil {
 		return nil, err
 	}
 
-	classes := make(map[string]struct{})
-	var extract func(*html.Node)
-	extract = func(n *html.Node) {
-		if n.Type == html.ElementNode {
-			for _, attr := range n.Attr {
-				if attr.Key == "class" {
-					for _, class := range strings.Fields(attr.Val) {
-						class = strings.TrimSpace(class)
-						if class != "" {
-							classes[class] = struct{}{}
-						}
-					}
+	classes := ClassSet(occs)
+	result := make([]string, 0, len(classes))
+	for class := range classes {
+		result = append(result, class)
+	}
+	return result, nil
+}
+
+// ExtractOccurrencesFromFile extracts every class occurrence from an HTML file.
+func ExtractOccurrencesFromFile(path string) ([]Occurrence, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ExtractOccurrencesFromReader(f, path)
+}
+
+// ExtractOccurrencesFromReader extracts every class occurrence from an HTML
+// reader, in document order. Line and column point at the start of the
+// element's start tag; file is recorded on each occurrence as given.
+//
+// A <!-- cssguard-ignore --> comment marks the classes of the next element
+// as suppressed, and <!-- cssguard-ignore-next-line --> those of elements
+// starting on the following line; either may list the classes to suppress.
+func ExtractOccurrencesFromReader(r io.Reader, file string) ([]Occurrence, error) {
+	var occs []Occurrence
+	err := walkTags(r, func(t tag) {
+		if t.Type == html.EndTagToken {
+			return
+		}
+		for _, class := range t.classes() {
+			occs = append(occs, Occurrence{
+				Class:      class,
+				File:       file,
+				Line:       t.line,
+				Column:     t.column,
+				Tag:        t.Data,
+				Suppressed: t.suppressed(class),
+			})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return occs, nil
+}
+
+// tag is a start, self-closing or end tag met by walkTags.
+type tag struct {
+	html.Token
+	line, column int // Start of the tag
+
+	// suppressed reports whether a cssguard-ignore comment silences a
+	// class of a start tag.
+	suppressed func(class string) bool
+}
+
+// classes returns the classes in the tag's class attribute.
+func (t tag) classes() []string {
+	var classes []string
+	for _, attr := range t.Attr {
+		if attr.Key == "class" {
+			classes = append(classes, strings.Fields(attr.Val)...)
+		}
+	}
+	return classes
+}
+
+// walkTags calls visit for every tag of an HTML reader, in document order,
+// tracking positions and cssguard-ignore comments.
+func walkTags(r io.Reader, visit func(t tag)) error {
+	z := html.NewTokenizer(r)
+	line, col := 1, 1
+
+	var nextElement *suppress.Directive              // Applies to the next start tag
+	nextLines := make(map[int][]*suppress.Directive) // Line -> directives for it
+
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if err := z.Err(); err != io.EOF {
+				return err
+			}
+			return nil
+		}
+
+		startLine, startCol := line, col
+		raw := z.Raw()
+		for len(raw) > 0 {
+			r, size := utf8.DecodeRune(raw)
+			raw = raw[size:]
+			if r == '\n' {
+				line++
+				col = 1
+			} else {
+				col++
+			}
+		}
+
+		if tt == html.CommentToken {
+			if d, ok := suppress.Parse(string(z.Text())); ok {
+				if d.NextLine {
+					nextLines[line+1] = append(nextLines[line+1], d)
+				} else {
+					nextElement = d
 				}
 			}
+			continue
 		}
-		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			extract(c)
+
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			element := nextElement
+			nextElement = nil
+			directives := nextLines[startLine]
+			visit(tag{
+				Token:  z.Token(),
+				line:   startLine,
+				column: startCol,
+				suppressed: func(class string) bool {
+					return element.Covers(class) || covers(directives, class)
+				},
+			})
+		case html.EndTagToken:
+			visit(tag{Token: z.Token(), line: startLine, column: startCol})
 		}
 	}
-	extract(doc)
+}
 
-	result := make([]string, 0, len(classes))
-	for class := range classes {
-		result = append(result, class)
+// covers reports whether any of the directives suppresses class.
+func covers(directives []*suppress.Directive, class string) bool {
+	for _, d := range directives {
+		if d.Covers(class) {
+			return true
+		}
 	}
-	return result, nil
+	return false
 }
 
 // ExtractFromDir recursively extracts classes from all HTML files in a directory.
 func ExtractFromDir(dir string) (map[string]struct{}, error) {
-	classes := make(map[string]struct{})
+	occs, err := ExtractOccurrencesFromDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	return ClassSet(occs), nil
+}
+
+// ExtractOccurrencesFromDir recursively extracts class occurrences from all
+// HTML files in a directory, ordered by file and then by position. Files are
+// parsed in parallel, one per CPU.
+func ExtractOccurrencesFromDir(dir string) ([]Occurrence, error) {
+	return ExtractOccurrencesFromDirN(dir, 0)
+}
+
+// ExtractOccurrencesFromDirN is ExtractOccurrencesFromDir parsing up to jobs
+// files at a time; jobs <= 0 means one per CPU. The result does not depend
+// on jobs.
+func ExtractOccurrencesFromDirN(dir string, jobs int) ([]Occurrence, error) {
+	return ExtractOccurrencesFromDirCached(dir, jobs, nil)
+}
 
+// ExtractOccurrencesFromDirCached is ExtractOccurrencesFromDirN reusing the
+// occurrences c holds for unchanged files; c may be nil.
+func ExtractOccurrencesFromDirCached(dir string, jobs int, c *cache.Cache) ([]Occurrence, error) {
+	paths, err := htmlFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	return ExtractOccurrencesCached(paths, jobs, c)
+}
+
+// ExtractOccurrencesCached extracts the class occurrences of each HTML file
+// in paths, in order, parsing up to jobs files at a time; c may be nil.
+func ExtractOccurrencesCached(paths []string, jobs int, c *cache.Cache) ([]Occurrence, error) {
+	perFile, err := parallel.Map(paths, jobs, func(path string) ([]Occurrence, error) {
+		return cache.Load(c, cache.KindHTML, path, ExtractOccurrencesFromReader)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parallel.Concat(perFile), nil
+}
+
+// htmlFiles lists the HTML files under dir in walk order.
+func htmlFiles(dir string) ([]string, error) {
+	var paths []string
 	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -70,18 +244,19 @@ func ExtractFromDir(dir string) (map[string]struct{}, error) {
 		if !strings.HasSuffix(strings.ToLower(path), ".html") {
 			return nil
 		}
-
-		fileClasses, err := ExtractFromFile(path)
-		if err != nil {
-			return err
-		}
-		for _, class := range fileClasses {
-			classes[class] = struct{}{}
-		}
+		paths = append(paths, path)
 		return nil
 	})
+	return paths, err
+}
 
-	return classes, err
+// ClassSet returns the set of class names used by occs.
+func ClassSet(occs []Occurrence) map[string]struct{} {
+	classes := make(map[string]struct{}, len(occs))
+	for _, o := range occs {
+		classes[o.Class] = struct{}{}
+	}
+	return classes
 }
 
 // ExtractFromGlob extracts classes from files matching a glob pattern.
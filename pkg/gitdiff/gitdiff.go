@@ -0,0 +1,207 @@
+// Package gitdiff lists the files and lines changed since a git revision,
+// using the local git binary.
+package gitdiff
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Diff records the lines changed in each file, by absolute path.
+type Diff struct {
+	files map[string][]lineRange // nil ranges: the whole file is new
+}
+
+// lineRange is a span of changed lines, both ends inclusive.
+type lineRange struct {
+	start, end int
+}
+
+// Since returns the changes in the working tree since the merge base of ref
+// and HEAD, so on a branch only the branch's own changes are included.
+// Untracked files that are not ignored count as changed throughout.
+func Since(ref string) (*Diff, error) {
+	out, err := git("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	root := AbsPath(strings.TrimSpace(out))
+	base, err := git("merge-base", ref, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	out, err = git("-c", "core.quotePath=false", "diff", "--unified=0", "--no-color", "--no-ext-diff", "--no-renames", strings.TrimSpace(base))
+	if err != nil {
+		return nil, err
+	}
+	d, err := Parse(strings.NewReader(out), root)
+	if err != nil {
+		return nil, err
+	}
+
+	untracked, err := git("-c", "core.quotePath=false", "ls-files", "--others", "--exclude-standard", "--full-name", "-z", "--", root)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range strings.Split(untracked, "\x00") {
+		if path != "" {
+			d.files[filepath.Join(root, filepath.FromSlash(path))] = nil
+		}
+	}
+	return d, nil
+}
+
+// git runs git with args and returns its output.
+func git(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+// hunkRegex matches a unified diff hunk header and captures the start and
+// length of the new side.
+var hunkRegex = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// Parse reads the output of git diff, whose paths are relative to root.
+// File headers are only read before the first hunk of each file, so added
+// lines that look like headers are not. Deleted files and pure deletions are
+// left out, since they add no lines.
+func Parse(r io.Reader, root string) (*Diff, error) {
+	d := &Diff{files: make(map[string][]lineRange)}
+	var current string
+	header := false // Between "diff --git" and the first hunk of a file
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			current = ""
+			header = true
+		case header && strings.HasPrefix(line, "+++ "):
+			// git ends paths containing spaces with a tab
+			path := strings.TrimSuffix(strings.TrimPrefix(line, "+++ "), "\t")
+			if strings.HasPrefix(path, `"`) {
+				if unquoted, err := strconv.Unquote(path); err == nil {
+					path = unquoted
+				}
+			}
+			if path == "/dev/null" || !strings.HasPrefix(path, "b/") {
+				current = ""
+				continue
+			}
+			current = filepath.Join(root, filepath.FromSlash(strings.TrimPrefix(path, "b/")))
+			if _, ok := d.files[current]; !ok {
+				d.files[current] = []lineRange{}
+			}
+		case strings.HasPrefix(line, "@@"):
+			header = false
+			if current == "" {
+				continue
+			}
+			m := hunkRegex.FindStringSubmatch(line)
+			if m == nil {
+				return nil, fmt.Errorf("invalid hunk header %q", line)
+			}
+			start, _ := strconv.Atoi(m[1])
+			count := 1
+			if m[2] != "" {
+				count, _ = strconv.Atoi(m[2])
+			}
+			if count > 0 {
+				d.files[current] = append(d.files[current], lineRange{start, start + count - 1})
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Files with only deletions add no lines
+	for path, ranges := range d.files {
+		if ranges != nil && len(ranges) == 0 {
+			delete(d.files, path)
+		}
+	}
+	return d, nil
+}
+
+// Files returns the absolute paths of the changed files, sorted.
+func (d *Diff) Files() []string {
+	files := make([]string, 0, len(d.files))
+	for path := range d.files {
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Under returns the changed files inside dir, or dir itself if it is a
+// changed file, as paths joined onto dir as given so they read like the
+// paths of a directory walk.
+func (d *Diff) Under(dir string) []string {
+	root := AbsPath(dir)
+	var files []string
+	for _, path := range d.Files() {
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		files = append(files, filepath.Join(dir, rel))
+	}
+	return files
+}
+
+// Changed reports whether a file has changed lines.
+func (d *Diff) Changed(path string) bool {
+	_, ok := d.files[AbsPath(path)]
+	return ok
+}
+
+// Touches reports whether a line of a file (counted from 1) was added or
+// changed.
+func (d *Diff) Touches(path string, line int) bool {
+	ranges, ok := d.files[AbsPath(path)]
+	if !ok {
+		return false
+	}
+	if ranges == nil {
+		return true
+	}
+	for _, r := range ranges {
+		if line >= r.start && line <= r.end {
+			return true
+		}
+	}
+	return false
+}
+
+// AbsPath makes path absolute and resolves symlinks, so paths match the
+// ones git reports.
+func AbsPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved
+	}
+	return abs
+}
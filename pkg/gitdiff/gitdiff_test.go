@@ -0,0 +1,163 @@
+package gitdiff
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sampleDiff = `diff --git a/public/index.html b/public/index.html
+index 1111111..2222222 100644
+--- a/public/index.html
++++ b/public/index.html
+@@ -3 +3 @@
+-<div class="old">
++<div class="new">
+@@ -10,0 +11,3 @@
++<p class="a">
++<p class="b">
++<p class="c">
+@@ -20,2 +23,0 @@
+-<p class="gone">
+-<p class="gone">
+diff --git a/docs/my page.html b/docs/my page.html
+--- a/docs/my page.html	
++++ b/docs/my page.html	
+@@ -1,0 +2,2 @@
++++ b/other.html
++<i>
+@@ -8 +9 @@
+-<b>
++<b class="y">
+diff --git a/src/old.tsx b/src/old.tsx
+deleted file mode 100644
+--- a/src/old.tsx
++++ /dev/null
+@@ -1,2 +0,0 @@
+-a
+-b
+diff --git a/css/only-deletions.css b/css/only-deletions.css
+--- a/css/only-deletions.css
++++ b/css/only-deletions.css
+@@ -5 +4,0 @@
+-.x {}
+diff --git "a/pages/caf\303\251 \"menu\".html" "b/pages/caf\303\251 \"menu\".html"
+--- "a/pages/caf\303\251 \"menu\".html"
++++ "b/pages/caf\303\251 \"menu\".html"
+@@ -1 +1 @@
+-<b>
++<b class="x">
+`
+
+func TestParse(t *testing.T) {
+	root := t.TempDir()
+	d, err := Parse(strings.NewReader(sampleDiff), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index := filepath.Join(root, "public", "index.html")
+	quoted := filepath.Join(root, "pages", `café "menu".html`)
+	spaced := filepath.Join(root, "docs", "my page.html")
+	if got := d.Files(); len(got) != 3 || got[0] != spaced || got[1] != quoted || got[2] != index {
+		t.Errorf("Files() = %v, want my page.html, the quoted page and index.html", got)
+	}
+	for _, line := range []int{2, 3, 9} {
+		if !d.Touches(spaced, line) {
+			t.Errorf("Touches(my page.html, %d) = false, want true", line)
+		}
+	}
+	if d.Changed(filepath.Join(root, "src", "old.tsx")) {
+		t.Error("deleted file reported as changed")
+	}
+
+	tests := []struct {
+		line int
+		want bool
+	}{
+		{2, false}, {3, true}, {4, false}, {10, false}, {11, true}, {13, true}, {14, false}, {23, false},
+	}
+	for _, tt := range tests {
+		if got := d.Touches(index, tt.line); got != tt.want {
+			t.Errorf("Touches(index.html, %d) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+	if !d.Touches(quoted, 1) {
+		t.Error("quoted path: line 1 not touched")
+	}
+
+	for dir, want := range map[string]string{
+		filepath.Join(root, "public"): "[" + index + "]",
+		index:                         "[" + index + "]",
+		filepath.Join(root, "pub"):    "[]",
+		filepath.Join(root, "src"):    "[]",
+	} {
+		if got := d.Under(dir); fmt.Sprint(got) != want {
+			t.Errorf("Under(%s) = %v, want %s", dir, got, want)
+		}
+	}
+}
+
+func TestSince(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run("init", "-q", "-b", "main")
+	write("index.html", "<html>\n<div class=\"a\"></div>\n</html>\n")
+	write(".gitignore", "ignored.html\n")
+	run("add", ".")
+	run("commit", "-q", "-m", "initial")
+	run("checkout", "-q", "-b", "feature")
+	write("index.html", "<html>\n<div class=\"a\"></div>\n<div class=\"b\"></div>\n</html>\n")
+	run("commit", "-q", "-am", "add b")
+	write("new.html", "<p class=\"c\"></p>\n")     // Untracked
+	write("ignored.html", "<p class=\"d\"></p>\n") // Ignored
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	d, err := Since("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Touches("index.html", 3) || d.Touches("index.html", 2) {
+		t.Error("index.html: want only line 3 touched")
+	}
+	if !d.Touches("new.html", 1) {
+		t.Error("untracked new.html not reported")
+	}
+	if d.Changed("ignored.html") {
+		t.Error("ignored file reported as changed")
+	}
+
+	if _, err := Since("no-such-ref"); err == nil {
+		t.Error("Since(no-such-ref) error = nil")
+	}
+}
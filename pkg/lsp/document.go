@@ -0,0 +1,176 @@
+package lsp
+
+import (
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// document is the text of an open document, split into lines.
+type document struct {
+	uri   string
+	path  string // File path the URI refers to, used in occurrences
+	text  string
+	lines []string
+}
+
+func newDocument(uri, text string) *document {
+	path := uri
+	if u, err := url.Parse(uri); err == nil && u.Scheme == "file" {
+		path = filepath.FromSlash(u.Path)
+	}
+	return &document{uri: uri, path: path, text: text, lines: strings.Split(text, "\n")}
+}
+
+// isHTML reports whether the document is parsed as HTML rather than scanned
+// as source code.
+func (d *document) isHTML() bool {
+	ext := strings.ToLower(filepath.Ext(d.path))
+	return ext == ".html" || ext == ".htm"
+}
+
+// line returns the text of a zero-based line, without its line ending.
+func (d *document) line(n int) string {
+	if n < 0 || n >= len(d.lines) {
+		return ""
+	}
+	return strings.TrimSuffix(d.lines[n], "\r")
+}
+
+// position converts a one-based line and rune column to a protocol position.
+func (d *document) position(line, column int) Position {
+	text := d.line(line - 1)
+	char, runes := 0, 0
+	for _, r := range text {
+		if runes >= column-1 {
+			break
+		}
+		char += utf16Len(r)
+		runes++
+	}
+	return Position{Line: line - 1, Character: char}
+}
+
+// byteOffset converts a protocol position to a byte offset in its line.
+func (d *document) byteOffset(pos Position) int {
+	text := d.line(pos.Line)
+	char := 0
+	for i, r := range text {
+		if char >= pos.Character {
+			return i
+		}
+		char += utf16Len(r)
+	}
+	return len(text)
+}
+
+// tokenRange returns the range of a class token starting at a one-based
+// line and rune column.
+func (d *document) tokenRange(line, column int, class string) Range {
+	start := d.position(line, column)
+	end := start
+	for _, r := range class {
+		end.Character += utf16Len(r)
+	}
+	return Range{Start: start, End: end}
+}
+
+// classAttrRegex matches a class attribute and captures its value, quoted or
+// not.
+var classAttrRegex = regexp.MustCompile(`(?is)\sclass\s*=\s*("[^"]*"|'[^']*'|[^\s>]+)`)
+
+// htmlClassRange finds class in the class attribute of the start tag at a
+// one-based line and rune column. If it cannot be found, the range covers
+// the start of the tag.
+func (d *document) htmlClassRange(line, column int, class string) Range {
+	fallback := d.tokenRange(line, column, "<")
+	offset := 0
+	for i := 0; i < line-1 && i < len(d.lines); i++ {
+		offset += len(d.lines[i]) + 1
+	}
+	lineText := d.line(line - 1)
+	offset += len(lineText) - len(trimRunes(lineText, column-1))
+	if offset >= len(d.text) {
+		return fallback
+	}
+
+	tag := d.text[offset:]
+	if end := strings.IndexByte(tag, '>'); end >= 0 {
+		tag = tag[:end]
+	}
+	m := classAttrRegex.FindStringSubmatchIndex(tag)
+	if m == nil {
+		return fallback
+	}
+	value := tag[m[2]:m[3]]
+	for i := 0; i < len(value); {
+		j := i
+		for j < len(value) && !isClassSeparator(value[j]) {
+			j++
+		}
+		if value[i:j] == class {
+			return d.rangeAt(offset+m[2]+i, class)
+		}
+		i = j + 1
+	}
+	return fallback
+}
+
+// rangeAt returns the range of class starting at a byte offset in the text.
+func (d *document) rangeAt(offset int, class string) Range {
+	line := strings.Count(d.text[:offset], "\n")
+	lineStart := strings.LastIndexByte(d.text[:offset], '\n') + 1
+	return d.tokenRange(line+1, utf8.RuneCountInString(d.text[lineStart:offset])+1, class)
+}
+
+// wordAt returns the class token around a position and its range. The token
+// ends at the position when toCursor is set, as when completing.
+func (d *document) wordAt(pos Position, toCursor bool) (string, Range) {
+	text := d.line(pos.Line)
+	at := d.byteOffset(pos)
+	start, end := at, at
+	for start > 0 && !isClassSeparator(text[start-1]) {
+		start--
+	}
+	if !toCursor {
+		for end < len(text) && !isClassSeparator(text[end]) {
+			end++
+		}
+	}
+	word := text[start:end]
+	r := Range{
+		Start: d.position(pos.Line+1, utf8.RuneCountInString(text[:start])+1),
+		End:   d.position(pos.Line+1, utf8.RuneCountInString(text[:end])+1),
+	}
+	return word, r
+}
+
+// isClassSeparator reports whether b ends a class token in markup or code.
+func isClassSeparator(b byte) bool {
+	switch b {
+	case ' ', '\t', '\r', '\n', '"', '\'', '`', '<', '=', '{', '}':
+		return true
+	}
+	return false
+}
+
+// trimRunes drops the first n runes of s.
+func trimRunes(s string, n int) string {
+	for i := range s {
+		if n == 0 {
+			return s[i:]
+		}
+		n--
+	}
+	return ""
+}
+
+// utf16Len returns the number of UTF-16 code units encoding r.
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,400 @@
+// Package lsp implements a Language Server Protocol server that reports
+// orphan classes in open documents as diagnostics while they are edited, and
+// offers completion and hover for the classes the CSS defines.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/JCorners68/cssguard/pkg/extractor"
+	"github.com/JCorners68/cssguard/pkg/parser"
+	"github.com/JCorners68/cssguard/pkg/report"
+	"github.com/JCorners68/cssguard/pkg/srcscan"
+	"github.com/JCorners68/cssguard/pkg/validator"
+)
+
+// maxCompletions limits how many classes one completion request returns.
+const maxCompletions = 200
+
+// maxHoverDefinitions limits how many definitions a hover lists.
+const maxHoverDefinitions = 5
+
+// Options configures a server.
+type Options struct {
+	Version   string               // Reported to the client
+	Validator *validator.Validator // Applies ignore rules; with Trained, the trained config
+	Trained   bool                 // Check classes against the trained config rather than CSS
+
+	// Definitions are the CSS class definitions. Without Trained classes are
+	// checked against them; they also back completion and hover.
+	Definitions []parser.Definition
+
+	Scanner          *srcscan.Scanner // Scans the documents that are not HTML and have its extensions
+	SuggestThreshold float64          // 0 disables "did you mean" suggestions
+	Severity         report.Severity  // Level of orphan diagnostics
+}
+
+// Server is a Language Server Protocol server. It handles one client.
+type Server struct {
+	opts        Options
+	css         map[string]struct{}            // Classes defined in the CSS
+	definitions map[string][]parser.Definition // Class -> definitions
+	known       map[string]struct{}            // Classes offered for completion and suggestions
+	docs        map[string]*document           // URI -> open document
+
+	out      io.Writer
+	shutdown bool
+}
+
+// NewServer creates a server.
+func NewServer(opts Options) *Server {
+	if opts.Scanner == nil {
+		opts.Scanner = srcscan.New(srcscan.DefaultOptions())
+	}
+	s := &Server{
+		opts:        opts,
+		css:         parser.ClassSet(opts.Definitions),
+		definitions: make(map[string][]parser.Definition),
+		known:       make(map[string]struct{}),
+		docs:        make(map[string]*document),
+	}
+	for _, d := range opts.Definitions {
+		s.definitions[d.Class] = append(s.definitions[d.Class], d)
+	}
+	for class := range s.css {
+		s.known[class] = struct{}{}
+	}
+	if opts.Trained {
+		for class := range opts.Validator.KnownClasses() {
+			s.known[class] = struct{}{}
+		}
+	}
+	return s
+}
+
+// Serve reads requests from r and writes responses and notifications to w
+// until the client sends exit or closes r. It returns an error if the client
+// exits without shutting the server down first.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.out = w
+	in := bufio.NewReader(r)
+	for {
+		body, err := readMessage(in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var msg message
+		if err := json.Unmarshal(body, &msg); err != nil {
+			if err := s.replyError(nil, codeParseError, err.Error()); err != nil {
+				return err
+			}
+			continue
+		}
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return fmt.Errorf("exit without shutdown")
+			}
+			return nil
+		}
+
+		result, err := s.handle(msg)
+		if msg.ID == nil {
+			if err != nil {
+				return err // Failed to write a notification
+			}
+			continue
+		}
+		if rpcErr, ok := err.(*rpcError); ok {
+			err = s.replyError(msg.ID, rpcErr.Code, rpcErr.Message)
+		} else if err == nil {
+			err = writeMessage(s.out, response{JSONRPC: "2.0", ID: msg.ID, Result: result})
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) replyError(id *json.RawMessage, code int, msg string) error {
+	return writeMessage(s.out, errorResponse{JSONRPC: "2.0", ID: id, Error: rpcError{Code: code, Message: msg}})
+}
+
+// handle dispatches a message. Errors of type *rpcError are sent to the
+// client; others end the session.
+func (s *Server) handle(msg message) (interface{}, error) {
+	decode := func(v interface{}) error {
+		if err := json.Unmarshal(msg.Params, v); err != nil {
+			return &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		return nil
+	}
+
+	switch msg.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":   1, // Full document on every change
+				"completionProvider": map[string]interface{}{"triggerCharacters": []string{`"`, "'", " ", ":"}},
+				"hoverProvider":      true,
+			},
+			"serverInfo": map[string]string{"name": "cssguard", "version": s.opts.Version},
+		}, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var p didOpenParams
+		if err := decode(&p); err != nil {
+			return nil, nil // Notifications get no error response
+		}
+		doc := newDocument(p.TextDocument.URI, p.TextDocument.Text)
+		s.docs[doc.uri] = doc
+		return nil, s.publish(doc)
+	case "textDocument/didChange":
+		var p didChangeParams
+		if err := decode(&p); err != nil || len(p.ContentChanges) == 0 {
+			return nil, nil
+		}
+		doc := newDocument(p.TextDocument.URI, p.ContentChanges[len(p.ContentChanges)-1].Text)
+		s.docs[doc.uri] = doc
+		return nil, s.publish(doc)
+	case "textDocument/didClose":
+		var p didCloseParams
+		if err := decode(&p); err != nil {
+			return nil, nil
+		}
+		delete(s.docs, p.TextDocument.URI)
+		return nil, s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+			URI:         p.TextDocument.URI,
+			Diagnostics: []Diagnostic{},
+		})
+
+	case "textDocument/completion":
+		var p positionParams
+		if err := decode(&p); err != nil {
+			return nil, err
+		}
+		return s.complete(p), nil
+	case "textDocument/hover":
+		var p positionParams
+		if err := decode(&p); err != nil {
+			return nil, err
+		}
+		if h := s.hover(p); h != nil {
+			return h, nil
+		}
+		return nil, nil
+	}
+
+	if msg.ID != nil {
+		return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method}
+	}
+	return nil, nil // Ignore other notifications
+}
+
+func (s *Server) notify(method string, params interface{}) error {
+	return writeMessage(s.out, notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// publish sends the diagnostics of a document.
+func (s *Server) publish(doc *document) error {
+	return s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         doc.uri,
+		Diagnostics: s.Diagnostics(doc.uri, doc.text),
+	})
+}
+
+// Diagnostics returns a diagnostic for every use of an orphan class in a
+// document, except uses silenced by inline cssguard-ignore comments.
+func (s *Server) Diagnostics(uri, text string) []Diagnostic {
+	diags := []Diagnostic{}
+	severity := lspSeverity(s.opts.Severity.Level(report.RuleOrphan))
+	if severity == 0 {
+		return diags
+	}
+
+	doc := newDocument(uri, text)
+	type use struct {
+		class        string
+		line, column int
+		suppressed   bool
+	}
+	var uses []use
+	if doc.isHTML() {
+		occs, err := extractor.ExtractOccurrencesFromReader(strings.NewReader(text), doc.path)
+		if err != nil {
+			return diags
+		}
+		for _, o := range occs {
+			uses = append(uses, use{o.Class, o.Line, o.Column, o.Suppressed})
+		}
+	} else if s.opts.Scanner.Includes(doc.path, false) {
+		occs, err := s.opts.Scanner.ScanReader(strings.NewReader(text), doc.path)
+		if err != nil {
+			return diags
+		}
+		for _, o := range occs {
+			uses = append(uses, use{o.Class, o.Line, o.Column, o.Suppressed})
+		}
+	}
+
+	classes := make(map[string]struct{})
+	for _, u := range uses {
+		if !u.suppressed {
+			classes[u.class] = struct{}{}
+		}
+	}
+	result := s.check(classes)
+	orphans := make(map[string]struct{}, len(result.Orphans))
+	for _, class := range result.Orphans {
+		orphans[class] = struct{}{}
+	}
+
+	for _, u := range uses {
+		if _, ok := orphans[u.class]; !ok || u.suppressed {
+			continue
+		}
+		r := doc.tokenRange(u.line, u.column, u.class)
+		if doc.isHTML() {
+			r = doc.htmlClassRange(u.line, u.column, u.class)
+		}
+		diags = append(diags, Diagnostic{
+			Range:    r,
+			Severity: severity,
+			Code:     report.RuleOrphan,
+			Source:   "cssguard",
+			Message:  orphanMessage(result, u.class),
+		})
+	}
+	return diags
+}
+
+// check validates classes against the trained config or the CSS.
+func (s *Server) check(classes map[string]struct{}) *validator.Result {
+	var result *validator.Result
+	if s.opts.Trained {
+		result = s.opts.Validator.ValidateAgainstPatterns(classes)
+	} else {
+		result = s.opts.Validator.ValidateDirectly(classes, s.css)
+	}
+	if s.opts.SuggestThreshold > 0 {
+		result.Suggest(s.known, s.opts.SuggestThreshold)
+	}
+	return result
+}
+
+func orphanMessage(result *validator.Result, class string) string {
+	msg := fmt.Sprintf("Class %s is not defined in CSS", class)
+	if issue, ok := result.VariantIssues[class]; ok {
+		msg += fmt.Sprintf(" (%s)", issue.Reason())
+	}
+	if s := result.Suggestions[class]; len(s) > 0 {
+		msg += ". " + report.DidYouMean(s)
+	}
+	return msg
+}
+
+// lspSeverity maps a report level to a diagnostic severity, or 0 for off.
+func lspSeverity(level string) int {
+	switch level {
+	case report.LevelError:
+		return SeverityError
+	case report.LevelWarning:
+		return SeverityWarning
+	case report.LevelNote:
+		return SeverityInformation
+	}
+	return 0
+}
+
+// complete offers the known classes starting with the partial class before
+// the cursor, when the cursor is inside a class attribute or helper string.
+func (s *Server) complete(p positionParams) completionList {
+	list := completionList{Items: []completionItem{}}
+	doc, ok := s.docs[p.TextDocument.URI]
+	if !ok {
+		return list
+	}
+	line := doc.line(p.Position.Line)
+	if !srcscan.InClassString(line[:doc.byteOffset(p.Position)]) {
+		return list
+	}
+	prefix, r := doc.wordAt(p.Position, true)
+
+	var matches []string
+	for class := range s.known {
+		if strings.HasPrefix(class, prefix) {
+			matches = append(matches, class)
+		}
+	}
+	sort.Strings(matches)
+	if len(matches) > maxCompletions {
+		matches = matches[:maxCompletions]
+		list.IsIncomplete = true
+	}
+	for _, class := range matches {
+		item := completionItem{
+			Label:    class,
+			Kind:     completionItemKindClass,
+			TextEdit: &textEdit{Range: r, NewText: class},
+		}
+		if defs := s.definitions[class]; len(defs) > 0 {
+			item.Detail = defs[0].Selector
+		}
+		list.Items = append(list.Items, item)
+	}
+	return list
+}
+
+// hover describes the CSS rules defining the class under the cursor, or
+// returns nil if there is no class there.
+func (s *Server) hover(p positionParams) *hover {
+	doc, ok := s.docs[p.TextDocument.URI]
+	if !ok {
+		return nil
+	}
+	class, r := doc.wordAt(p.Position, false)
+	if class == "" {
+		return nil
+	}
+
+	var b strings.Builder
+	defs := s.definitions[class]
+	switch {
+	case len(defs) > 0:
+		b.WriteString("```css\n")
+		for i, d := range defs {
+			if i == maxHoverDefinitions {
+				break
+			}
+			fmt.Fprintf(&b, "%s { … }\n", d.Selector)
+		}
+		b.WriteString("```\n\nDefined in:\n")
+		for i, d := range defs {
+			if i == maxHoverDefinitions {
+				fmt.Fprintf(&b, "- ... and %d more\n", len(defs)-maxHoverDefinitions)
+				break
+			}
+			fmt.Fprintf(&b, "- `%s`", d.Location())
+			if d.Entry != "" {
+				fmt.Fprintf(&b, " (imported by `%s`)", d.Entry)
+			}
+			b.WriteString("\n")
+		}
+	case s.opts.Trained && len(s.check(map[string]struct{}{class: {}}).Orphans) == 0:
+		fmt.Fprintf(&b, "`%s` matches the trained config", class)
+	default:
+		return nil
+	}
+	return &hover{Contents: markupContent{Kind: "markdown", Value: b.String()}, Range: &r}
+}
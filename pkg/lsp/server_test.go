@@ -0,0 +1,207 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/JCorners68/cssguard/pkg/parser"
+	"github.com/JCorners68/cssguard/pkg/report"
+	"github.com/JCorners68/cssguard/pkg/trainer"
+	"github.com/JCorners68/cssguard/pkg/validator"
+)
+
+const testCSS = `.flex { display: flex }
+.btn-primary { color: blue }
+.card > .btn-primary { margin: 0 }
+.text-gray-500 { color: gray }
+`
+
+func newTestServer(t *testing.T, severity report.Severity) *Server {
+	t.Helper()
+	defs, err := parser.ParseDefinitionsFromReader(strings.NewReader(testCSS), "site.css")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := validator.New(&trainer.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewServer(Options{
+		Validator:        v,
+		Definitions:      defs,
+		SuggestThreshold: validator.DefaultSuggestThreshold,
+		Severity:         severity,
+	})
+}
+
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		name string
+		uri  string
+		text string
+		want []string // class@line:start-end
+	}{
+		{
+			name: "html class attribute",
+			uri:  "file:///site/index.html",
+			text: "<body>\n  <div id=\"flex\" class=\"flex btn-primray\">x</div>\n</body>",
+			want: []string{"btn-primray@1:29-40"},
+		},
+		{
+			name: "html attribute on a later line",
+			uri:  "file:///site/index.html",
+			text: "<div\n  class='text-grey-500'></div>",
+			want: []string{"text-grey-500@1:9-22"},
+		},
+		{
+			name: "html suppressed",
+			uri:  "file:///site/index.html",
+			text: "<!-- cssguard-ignore -->\n<div class=\"js-toggle\"></div>",
+		},
+		{
+			name: "source with wide characters",
+			uri:  "file:///site/App.tsx",
+			text: "const s = \"😀\"; <b className=\"flex nope\" />",
+			want: []string{"nope@0:35-39"},
+		},
+		{
+			name: "source extension not scanned",
+			uri:  "file:///site/main.go",
+			text: `x := "<b class=\"nope\">"`,
+		},
+	}
+
+	s := newTestServer(t, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, d := range s.Diagnostics(tt.uri, tt.text) {
+				class := strings.Fields(d.Message)[1]
+				got = append(got, fmt.Sprintf("%s@%d:%d-%d", class, d.Range.Start.Line, d.Range.Start.Character, d.Range.End.Character))
+				if d.Severity != SeverityError || d.Source != "cssguard" {
+					t.Errorf("diagnostic = %+v, want a cssguard error", d)
+				}
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Diagnostics() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiagnosticsSeverity(t *testing.T) {
+	text := `<div class="nope"></div>`
+
+	s := newTestServer(t, report.Severity{report.RuleOrphan: report.LevelWarning})
+	diags := s.Diagnostics("file:///index.html", text)
+	if len(diags) != 1 || diags[0].Severity != SeverityWarning {
+		t.Errorf("Diagnostics() = %+v, want one warning", diags)
+	}
+
+	s = newTestServer(t, report.Severity{report.RuleOrphan: report.LevelOff})
+	if diags := s.Diagnostics("file:///index.html", text); len(diags) != 0 {
+		t.Errorf("Diagnostics() = %+v, want none with orphans off", diags)
+	}
+}
+
+// session drives a server through a scripted exchange with a client.
+type session struct {
+	in bytes.Buffer
+}
+
+func (s *session) send(id int, method string, params interface{}) {
+	msg := map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params}
+	if id > 0 {
+		msg["id"] = id
+	}
+	if err := writeMessage(&s.in, msg); err != nil {
+		panic(err)
+	}
+}
+
+func TestServe(t *testing.T) {
+	uri := "file:///site/index.html"
+	var c session
+	c.send(1, "initialize", map[string]interface{}{})
+	c.send(0, "initialized", map[string]interface{}{})
+	c.send(0, "textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri, "languageId": "html", "version": 1, "text": `<a class="btn-primray"></a>`},
+	})
+	c.send(0, "textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": uri, "version": 2},
+		"contentChanges": []map[string]string{{"text": `<a class="btn-primary text-"></a>`}},
+	})
+	c.send(2, "textDocument/completion", map[string]interface{}{
+		"textDocument": map[string]string{"uri": uri},
+		"position":     Position{Line: 0, Character: 27},
+	})
+	c.send(3, "textDocument/hover", map[string]interface{}{
+		"textDocument": map[string]string{"uri": uri},
+		"position":     Position{Line: 0, Character: 14},
+	})
+	c.send(4, "workspace/symbol", map[string]interface{}{})
+	c.send(5, "shutdown", nil)
+	c.send(0, "exit", nil)
+
+	var out bytes.Buffer
+	if err := newTestServer(t, nil).Serve(&c.in, &out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	r := bufio.NewReader(&out)
+	var msgs []map[string]json.RawMessage
+	for {
+		body, err := readMessage(r)
+		if err != nil {
+			break
+		}
+		var m map[string]json.RawMessage
+		if err := json.Unmarshal(body, &m); err != nil {
+			t.Fatal(err)
+		}
+		msgs = append(msgs, m)
+	}
+	if len(msgs) != 7 {
+		t.Fatalf("got %d messages, want 7", len(msgs))
+	}
+
+	if !strings.Contains(string(msgs[0]["result"]), `"hoverProvider":true`) {
+		t.Errorf("initialize result = %s", msgs[0]["result"])
+	}
+
+	var diags publishDiagnosticsParams
+	json.Unmarshal(msgs[1]["params"], &diags)
+	if len(diags.Diagnostics) != 1 || !strings.Contains(diags.Diagnostics[0].Message, "Did you mean btn-primary?") {
+		t.Errorf("diagnostics after open = %+v, want btn-primray with a suggestion", diags.Diagnostics)
+	}
+	json.Unmarshal(msgs[2]["params"], &diags)
+	if len(diags.Diagnostics) != 1 || !strings.Contains(diags.Diagnostics[0].Message, "text-") {
+		t.Errorf("diagnostics after change = %+v, want only the unfinished text-", diags.Diagnostics)
+	}
+
+	var completions completionList
+	json.Unmarshal(msgs[3]["result"], &completions)
+	if len(completions.Items) != 1 || completions.Items[0].Label != "text-gray-500" {
+		t.Fatalf("completion = %+v, want text-gray-500", completions.Items)
+	}
+	if e := completions.Items[0].TextEdit; e == nil || e.Range.Start.Character != 22 || e.Range.End.Character != 27 {
+		t.Errorf("completion edit = %+v, want it to replace text-", e)
+	}
+
+	var h hover
+	json.Unmarshal(msgs[4]["result"], &h)
+	if !strings.Contains(h.Contents.Value, ".card > .btn-primary") || !strings.Contains(h.Contents.Value, "site.css:2") {
+		t.Errorf("hover = %q, want both rules defining btn-primary", h.Contents.Value)
+	}
+
+	if !strings.Contains(string(msgs[5]["error"]), fmt.Sprint(codeMethodNotFound)) {
+		t.Errorf("unknown method response = %v, want method not found", msgs[5])
+	}
+	if string(msgs[6]["result"]) != "null" {
+		t.Errorf("shutdown response = %v, want null result", msgs[6])
+	}
+}
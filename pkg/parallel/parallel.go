@@ -0,0 +1,76 @@
+// Package parallel runs per-file work on a bounded pool of goroutines while
+// keeping results in input order, so output does not depend on scheduling.
+package parallel
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Jobs returns the number of workers to use for jobs: jobs itself if
+// positive, otherwise GOMAXPROCS.
+func Jobs(jobs int) int {
+	if jobs > 0 {
+		return jobs
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// Map calls fn on every item using up to Jobs(jobs) goroutines and returns
+// the results in item order. Items are started in order, and after an error
+// no further items are started; the error returned is that of the first
+// failing item, as if the items had been processed one after another.
+func Map[T, R any](items []T, jobs int, fn func(T) (R, error)) ([]R, error) {
+	results := make([]R, len(items))
+	errs := make([]error, len(items))
+
+	workers := Jobs(jobs)
+	if workers > len(items) {
+		workers = len(items)
+	}
+	var (
+		next   atomic.Int64
+		failed atomic.Bool
+		wg     sync.WaitGroup
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !failed.Load() {
+				i := int(next.Add(1) - 1)
+				if i >= len(items) {
+					return
+				}
+				if results[i], errs[i] = fn(items[i]); errs[i] != nil {
+					failed.Store(true)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// Concat joins the per-item result slices returned by Map into one.
+func Concat[T any](parts [][]T) []T {
+	n := 0
+	for _, p := range parts {
+		n += len(p)
+	}
+	if n == 0 {
+		return nil
+	}
+	all := make([]T, 0, n)
+	for _, p := range parts {
+		all = append(all, p...)
+	}
+	return all
+}
@@ -0,0 +1,56 @@
+package parallel
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMap(t *testing.T) {
+	items := make([]int, 100)
+	for i := range items {
+		items[i] = i
+	}
+
+	for _, jobs := range []int{0, 1, 4, 200} {
+		t.Run(fmt.Sprintf("jobs=%d", jobs), func(t *testing.T) {
+			got, err := Map(items, jobs, func(i int) (int, error) {
+				time.Sleep(time.Duration(i%3) * time.Millisecond) // Finish out of order
+				return i * i, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, v := range got {
+				if v != i*i {
+					t.Fatalf("Map()[%d] = %d, want %d", i, v, i*i)
+				}
+			}
+		})
+	}
+}
+
+func TestMapFirstError(t *testing.T) {
+	items := []int{0, 1, 2, 3, 4, 5, 6, 7}
+	for _, jobs := range []int{1, 3, 8} {
+		t.Run(fmt.Sprintf("jobs=%d", jobs), func(t *testing.T) {
+			_, err := Map(items, jobs, func(i int) (int, error) {
+				if i == 2 {
+					time.Sleep(5 * time.Millisecond) // Fail after a later item
+				}
+				if i == 2 || i == 5 {
+					return 0, fmt.Errorf("item %d", i)
+				}
+				return i, nil
+			})
+			if err == nil || err.Error() != "item 2" {
+				t.Errorf("Map() error = %v, want item 2", err)
+			}
+		})
+	}
+
+	if got, err := Map(nil, 4, func(i int) (int, error) { return 0, errors.New("called") }); err != nil || len(got) != 0 {
+		t.Errorf("Map(nil) = %v, %v, want no results", got, err)
+	}
+}
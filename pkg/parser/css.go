@@ -2,63 +2,135 @@
 package parser
 
 import (
-	"bufio"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
-	"regexp"
+	"sort"
 	"strings"
+
+	"github.com/JCorners68/cssguard/pkg/cache"
+	"github.com/JCorners68/cssguard/pkg/parallel"
 )
 
-// classRegex matches CSS class selectors (e.g., .foo, .bar-baz, .text-gray-500)
-// Handles escaped characters like \/ \: \[ \] in Tailwind classes
-var classRegex = regexp.MustCompile(`\.(-?[_a-zA-Z][_a-zA-Z0-9-]*(?:\\[/:.\[\]()%][_a-zA-Z0-9-]*)*)`)
+// Definition records one occurrence of a class in a style rule selector.
+type Definition struct {
+	Class    string `json:"class"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Selector string `json:"selector"` // The complex selector containing the class
+
+	// Context lists the grouping at-rules enclosing the rule, outermost
+	// first, e.g. ["@layer components", "@media (min-width: 768px)"].
+	// Conditions of the @import rules that pulled the file in come first.
+	Context []string `json:"context,omitempty"`
+
+	// Entry is the stylesheet that imported File, directly or through
+	// other imports; empty when File was parsed as given.
+	Entry string `json:"entry,omitempty"`
+}
+
+// Location returns the definition's position as file:line.
+func (d Definition) Location() string {
+	return fmt.Sprintf("%s:%d", d.File, d.Line)
+}
+
+// Source returns the definition's position and, for an imported file, the
+// stylesheet that imported it, as "vendor/flowbite.css:12 via main.css".
+func (d Definition) Source() string {
+	if d.Entry == "" {
+		return d.Location()
+	}
+	return fmt.Sprintf("%s via %s", d.Location(), d.Entry)
+}
+
+// ContextString returns the definition's context as one string, e.g.
+// "@media print @supports (display: grid)", or "" outside any at-rule.
+func (d Definition) ContextString() string {
+	return strings.Join(d.Context, " ")
+}
+
+// Key identifies the class together with its context, so the same class
+// defined for print and for screens counts as two definitions.
+func (d Definition) Key() string {
+	if len(d.Context) == 0 {
+		return d.Class
+	}
+	return d.Class + " " + d.ContextString()
+}
+
+// PrintOnly reports whether the definition applies only to print (or other
+// media that are not screens): it is inside an @media rule none of whose
+// queries can match a screen.
+func (d Definition) PrintOnly() bool {
+	for _, rule := range d.Context {
+		if query, ok := strings.CutPrefix(rule, "@media "); ok && !matchesScreen(query) {
+			return true
+		}
+	}
+	return false
+}
+
+// screenMediaTypes are the media types a screen matches.
+var screenMediaTypes = map[string]struct{}{
+	"all":    {},
+	"screen": {},
+}
 
-// pseudoCleanRegex removes pseudo-classes/elements from selectors
-// Matches :pseudo or ::pseudo at start of string or after non-backslash character
-var pseudoCleanRegex = regexp.MustCompile(`(^|[^\\])::?[a-zA-Z-]+(\([^)]*\))?`)
+// matchesScreen reports whether any query of a media query list can match
+// a screen. Media features are ignored, so "screen and (min-width: 9999px)"
+// counts as matching; only the media types decide.
+func matchesScreen(queryList string) bool {
+	for _, query := range strings.Split(queryList, ",") {
+		words := strings.Fields(strings.ToLower(query))
+		negated := false
+		if len(words) > 0 && (words[0] == "not" || words[0] == "only") {
+			negated = words[0] == "not"
+			words = words[1:]
+		}
+		if len(words) == 0 || strings.HasPrefix(words[0], "(") {
+			return true // No media type: all media
+		}
+		_, screen := screenMediaTypes[words[0]]
+		if negated {
+			// "not screen" and "not all" exclude screens; "not screen and
+			// (color)" still matches monochrome ones
+			screen = !screen || len(words) > 1
+		}
+		if screen {
+			return true
+		}
+	}
+	return false
+}
 
-// ParseFromFile extracts all CSS class selectors from a CSS file.
+// ParseFromFile extracts all CSS class selectors from a CSS file and the
+// local stylesheets it imports. Imports that cannot be followed are skipped.
 func ParseFromFile(path string) ([]string, error) {
-	f, err := os.Open(path)
+	defs, _, err := ParseWithImports(path, 0, nil)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
-	return ParseFromReader(f)
+
+	classes := ClassSet(defs)
+	result := make([]string, 0, len(classes))
+	for class := range classes {
+		result = append(result, class)
+	}
+	return result, nil
 }
 
 // ParseFromReader extracts all CSS class selectors from a CSS reader.
+// Only selector preludes are inspected, so text inside comments, strings,
+// url() values and declaration blocks never produces classes.
 func ParseFromReader(r io.Reader) ([]string, error) {
-	classes := make(map[string]struct{})
-
-	scanner := bufio.NewScanner(r)
-	// Increase buffer size for minified CSS
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 10*1024*1024) // 10MB max
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		// Remove pseudo-classes/elements to get clean class names
-		// $1 preserves the character before the colon
-		cleaned := pseudoCleanRegex.ReplaceAllString(line, "$1")
-		matches := classRegex.FindAllStringSubmatch(cleaned, -1)
-		for _, match := range matches {
-			if len(match) > 1 {
-				className := match[1]
-				// Skip Tailwind's escaped characters (e.g., \:, \/)
-				className = unescapeClassName(className)
-				if className != "" && (!strings.HasPrefix(className, "-") || isValidNegativeClass(className)) {
-					classes[className] = struct{}{}
-				}
-			}
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
+	defs, err := ParseDefinitionsFromReader(r, "")
+	if err != nil {
 		return nil, err
 	}
 
+	classes := ClassSet(defs)
 	result := make([]string, 0, len(classes))
 	for class := range classes {
 		result = append(result, class)
@@ -66,48 +138,76 @@ func ParseFromReader(r io.Reader) ([]string, error) {
 	return result, nil
 }
 
-// unescapeClassName handles Tailwind's escaped class names
-func unescapeClassName(name string) string {
-	// Handle common escapes: \: -> :, \/ -> /, \. -> .
-	replacer := strings.NewReplacer(
-		`\:`, `:`,
-		`\/`, `/`,
-		`\.`, `.`,
-		`\[`, `[`,
-		`\]`, `]`,
-		`\(`, `(`,
-		`\)`, `)`,
-		`\,`, `,`,
-		`\%`, `%`,
-	)
-	return replacer.Replace(name)
-}
-
-// isValidNegativeClass checks if a negative class is valid (e.g., -translate-x-full)
-func isValidNegativeClass(name string) bool {
-	if !strings.HasPrefix(name, "-") {
-		return true
-	}
-	// Valid negative Tailwind utilities
-	validPrefixes := []string{
-		"-translate", "-rotate", "-skew", "-scale",
-		"-m-", "-mx-", "-my-", "-mt-", "-mr-", "-mb-", "-ml-",
-		"-p-", "-px-", "-py-", "-pt-", "-pr-", "-pb-", "-pl-",
-		"-inset", "-top-", "-right-", "-bottom-", "-left-",
-		"-z-", "-order-", "-tracking-", "-indent-",
-	}
-	for _, prefix := range validPrefixes {
-		if strings.HasPrefix(name, prefix) {
-			return true
-		}
+// ParseDefinitionsFromFile extracts every class definition from a CSS file.
+func ParseDefinitionsFromFile(path string) ([]Definition, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
 	}
-	return false
+	defer f.Close()
+	return ParseDefinitionsFromReader(f, path)
 }
 
-// ParseFromDir extracts classes from all CSS files in a directory.
-func ParseFromDir(dir string) (map[string]struct{}, error) {
-	classes := make(map[string]struct{})
+// ParseDefinitionsFromReader extracts every class definition from a CSS
+// reader, in source order. File is recorded on each definition as given.
+func ParseDefinitionsFromReader(r io.Reader, file string) ([]Definition, error) {
+	sheet, err := parseStylesheet(r, file)
+	return sheet.Definitions, err
+}
+
+// stylesheet is what parsing one CSS file yields, and what the cache holds
+// for it.
+type stylesheet struct {
+	Definitions []Definition
+	Imports     []Import
+}
+
+func parseStylesheet(r io.Reader, file string) (stylesheet, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return stylesheet{}, err
+	}
+
+	p := newRuleParser(newTokenizer(string(data)), file)
+	p.parseRuleList(true)
+	return stylesheet{Definitions: p.defs, Imports: p.imports}, nil
+}
+
+// loadStylesheet parses the CSS file at path, or takes it from c.
+func loadStylesheet(c *cache.Cache, path string) (stylesheet, error) {
+	return cache.Load(c, cache.KindCSS, path, parseStylesheet)
+}
 
+// ParseDefinitionsFromDir extracts class definitions from all stylesheets in a
+// directory, ordered by file and then by position. Files are parsed in
+// parallel, one per CPU.
+func ParseDefinitionsFromDir(dir string) ([]Definition, error) {
+	return ParseDefinitionsFromDirN(dir, 0)
+}
+
+// ParseDefinitionsFromDirN is ParseDefinitionsFromDir parsing up to jobs
+// files at a time; jobs <= 0 means one per CPU. The result does not depend
+// on jobs.
+func ParseDefinitionsFromDirN(dir string, jobs int) ([]Definition, error) {
+	return parseDir(dir, jobs, nil)
+}
+
+func parseDir(dir string, jobs int, c *cache.Cache) ([]Definition, error) {
+	_, sheets, err := parseDirStylesheets(dir, jobs, c)
+	if err != nil {
+		return nil, err
+	}
+	perFile := make([][]Definition, len(sheets))
+	for i, sheet := range sheets {
+		perFile[i] = sheet.Definitions
+	}
+	return parallel.Concat(perFile), nil
+}
+
+// parseDirStylesheets parses the CSS, SCSS and LESS files in a directory, returning their
+// paths in walk order and what each yields.
+func parseDirStylesheets(dir string, jobs int, c *cache.Cache) ([]string, []stylesheet, error) {
+	var paths []string
 	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -115,21 +215,137 @@ func ParseFromDir(dir string) (map[string]struct{}, error) {
 		if info.IsDir() {
 			return nil
 		}
-		if !strings.HasSuffix(strings.ToLower(path), ".css") {
+		if !IsStylesheet(path) {
 			return nil
 		}
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
 
-		fileClasses, err := ParseFromFile(path)
-		if err != nil {
-			return err
+	sheets, err := parallel.Map(paths, jobs, func(path string) (stylesheet, error) {
+		return loadStylesheet(c, path)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return paths, sheets, nil
+}
+
+// ParseDefinitions extracts class definitions from a CSS file, or from all
+// stylesheets (CSS, SCSS, LESS) under path if it is a directory.
+func ParseDefinitions(path string) ([]Definition, error) {
+	return ParseDefinitionsN(path, 0)
+}
+
+// ParseDefinitionsN is ParseDefinitions parsing up to jobs files of a
+// directory at a time; jobs <= 0 means one per CPU.
+func ParseDefinitionsN(path string, jobs int) ([]Definition, error) {
+	return ParseDefinitionsCached(path, jobs, nil)
+}
+
+// ParseDefinitionsCached is ParseDefinitionsN reusing the definitions c
+// holds for unchanged files; c may be nil.
+func ParseDefinitionsCached(path string, jobs int, c *cache.Cache) ([]Definition, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return parseDir(path, jobs, c)
+	}
+	sheet, err := loadStylesheet(c, path)
+	return sheet.Definitions, err
+}
+
+// ClassSet returns the set of class names defined by defs.
+func ClassSet(defs []Definition) map[string]struct{} {
+	classes := make(map[string]struct{}, len(defs))
+	for _, d := range defs {
+		classes[d.Class] = struct{}{}
+	}
+	return classes
+}
+
+// FirstDefinitions maps each class to its first definition in defs.
+func FirstDefinitions(defs []Definition) map[string]Definition {
+	first := make(map[string]Definition, len(defs))
+	for _, d := range defs {
+		if _, ok := first[d.Class]; !ok {
+			first[d.Class] = d
 		}
-		for _, class := range fileClasses {
-			classes[class] = struct{}{}
+	}
+	return first
+}
+
+// KeySet returns the keys of defs (see Definition.Key): the classes they
+// define, each paired with its at-rule context.
+func KeySet(defs []Definition) map[string]struct{} {
+	keys := make(map[string]struct{}, len(defs))
+	for _, d := range defs {
+		keys[d.Key()] = struct{}{}
+	}
+	return keys
+}
+
+// ClassContexts maps each class that defs define only inside at-rules to
+// the distinct contexts of its definitions, sorted. Classes with any
+// definition outside at-rules are left out.
+func ClassContexts(defs []Definition) map[string][]string {
+	contexts := make(map[string][]string)
+	unconditional := make(map[string]struct{})
+	for _, d := range defs {
+		if len(d.Context) == 0 {
+			unconditional[d.Class] = struct{}{}
+			continue
 		}
-		return nil
-	})
+		ctx := d.ContextString()
+		if !contains(contexts[d.Class], ctx) {
+			contexts[d.Class] = append(contexts[d.Class], ctx)
+		}
+	}
+	for class := range unconditional {
+		delete(contexts, class)
+	}
+	for _, list := range contexts {
+		sort.Strings(list)
+	}
+	return contexts
+}
+
+// PrintOnlyClasses returns the classes every definition of which in defs
+// applies only to print (see Definition.PrintOnly).
+func PrintOnlyClasses(defs []Definition) map[string]struct{} {
+	printOnly := make(map[string]struct{})
+	screen := make(map[string]struct{})
+	for _, d := range defs {
+		if d.PrintOnly() {
+			printOnly[d.Class] = struct{}{}
+		} else {
+			screen[d.Class] = struct{}{}
+		}
+	}
+	for class := range screen {
+		delete(printOnly, class)
+	}
+	return printOnly
+}
 
-	return classes, err
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseFromDir extracts classes from all CSS files in a directory.
+func ParseFromDir(dir string) (map[string]struct{}, error) {
+	defs, err := ParseDefinitionsFromDir(dir)
+	return ClassSet(defs), err
 }
 
 // ParseFromFiles extracts classes from multiple CSS files.
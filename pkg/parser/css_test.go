@@ -1,8 +1,12 @@
 package parser
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
 )
 
@@ -37,6 +41,13 @@ func TestParseFromFile(t *testing.T) {
 			css:      `.md\:flex { display: flex; } .hover\:bg-blue-500:hover { background: blue; }`,
 			expected: []string{"md:flex", "hover:bg-blue-500"}, // Parser unescapes colons
 		},
+		{
+			name: "tailwind arbitrary values and important modifier",
+			css: `.\!mt-0 { margin-top: 0 !important; } .bg-\[\#1da1f2\] { background: #1da1f2; } ` +
+				`.w-\[calc\(100\%-2rem\)\] { width: calc(100% - 2rem); } .\[mask-type\:luminance\] { mask-type: luminance; } ` +
+				`.\32 xl\:flex { display: flex; } .text-\[\23 fff\] { color: #fff; }`,
+			expected: []string{"!mt-0", "bg-[#1da1f2]", "w-[calc(100%-2rem)]", "[mask-type:luminance]", "2xl:flex", "text-[#fff]"},
+		},
 		{
 			name:     "negative classes",
 			css:      ".-mt-4 { margin-top: -1rem; }",
@@ -76,6 +87,81 @@ func TestParseFromFile(t *testing.T) {
 	}
 }
 
+func TestParseFromReaderIgnoresNonSelectors(t *testing.T) {
+	tests := []struct {
+		name     string
+		css      string
+		expected []string
+	}{
+		{
+			name:     "comments",
+			css:      "/* .commented { } */ .real { color: red; }",
+			expected: []string{"real"},
+		},
+		{
+			name:     "string values",
+			css:      `.quote::before { content: ".foo"; }`,
+			expected: []string{"quote"},
+		},
+		{
+			name:     "url paths",
+			css:      ".hero { background: url(img/bg.large.png); } .icon { mask: url('a.b.svg'); }",
+			expected: []string{"hero", "icon"},
+		},
+		{
+			name:     "numeric values",
+			css:      ".gap { margin: .5rem 0.25em; line-height: 1.5; }",
+			expected: []string{"gap"},
+		},
+		{
+			name:     "selector split across lines",
+			css:      ".card,\n.panel\n  > .title\n{ padding: 0; }",
+			expected: []string{"card", "panel", "title"},
+		},
+		{
+			name:     "at-rule blocks",
+			css:      "@media (min-width: 768px) { .md\\:flex { display: flex; } } @font-face { src: url(x.woff2); } @keyframes spin { from { opacity: .5; } }",
+			expected: []string{"md:flex"},
+		},
+		{
+			name:     "negated and relational pseudo-classes",
+			css:      ".btn:not(.disabled):is(.primary, .secondary):has(.icon) { color: red; }",
+			expected: []string{"btn", "disabled", "primary", "secondary", "icon"},
+		},
+		{
+			name:     "negative classes",
+			css:      ".-mt-4 { margin-top: -1rem; } .-translate-x-full { transform: none; } .-foo { color: red; }",
+			expected: []string{"-mt-4", "-translate-x-full"},
+		},
+		{
+			name:     "custom property holding braces",
+			css:      ".a { --x: { .b { color: red } }; --y: [.c] { .d {} } } .e { --z: {}; }",
+			expected: []string{"a", "e"},
+		},
+		{
+			name:     "unterminated rule",
+			css:      ".ok { color: red; } .broken",
+			expected: []string{"ok"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			classes, err := ParseFromReader(strings.NewReader(tt.css))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sort.Strings(classes)
+			expected := append([]string(nil), tt.expected...)
+			sort.Strings(expected)
+			if strings.Join(classes, ",") != strings.Join(expected, ",") {
+				t.Errorf("got %v, want %v", classes, expected)
+			}
+		})
+	}
+}
+
 func TestParseFromDir(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -139,3 +225,139 @@ func TestParseMinified(t *testing.T) {
 		}
 	}
 }
+
+func TestParseDefinitionsFromReader(t *testing.T) {
+	css := "/* header */\n.card,\n.panel > .title:hover { padding: 0; }\n@media print {\n  .btn\\:x { color: red; }\n}\n"
+
+	defs, err := ParseDefinitionsFromReader(strings.NewReader(css), "site.css")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Definition{
+		{Class: "card", File: "site.css", Line: 2, Column: 1, Selector: ".card"},
+		{Class: "panel", File: "site.css", Line: 3, Column: 1, Selector: ".panel > .title:hover"},
+		{Class: "title", File: "site.css", Line: 3, Column: 10, Selector: ".panel > .title:hover"},
+		{Class: "btn:x", File: "site.css", Line: 5, Column: 3, Selector: `.btn\:x`, Context: []string{"@media print"}},
+	}
+	if len(defs) != len(expected) {
+		t.Fatalf("got %d definitions %+v, want %d", len(defs), defs, len(expected))
+	}
+	for i, want := range expected {
+		if !reflect.DeepEqual(defs[i], want) {
+			t.Errorf("definition %d = %+v, want %+v", i, defs[i], want)
+		}
+	}
+
+	if loc := defs[1].Location(); loc != "site.css:3" {
+		t.Errorf("Location() = %q, want %q", loc, "site.css:3")
+	}
+}
+
+func TestDefinitionContext(t *testing.T) {
+	css := `.base { color: red }
+@layer components {
+  @media screen and (min-width: 768px) {
+    @supports (display:   grid) { .grid { display: grid } }
+  }
+  .card { padding: 0 }
+}
+@media print { .base, .print-only { color: black } }
+@media NOT Screen { .not-screen { color: black } }
+@media print, (min-width: 640px) { .print-or-wide { color: black } }
+@media not print and (color) { .color-screen { color: black } }
+@layer { .anonymous { color: black } }
+@font-face { .never { x: y } }
+`
+	defs, err := ParseDefinitionsFromReader(strings.NewReader(css), "site.css")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key       string
+		printOnly bool
+	}{
+		{"base", false},
+		{"grid @layer components @media screen and (min-width: 768px) @supports (display: grid)", false},
+		{"card @layer components", false},
+		{"base @media print", true},
+		{"print-only @media print", true},
+		{"not-screen @media NOT Screen", true},
+		{"print-or-wide @media print, (min-width: 640px)", false},
+		{"color-screen @media not print and (color)", false},
+		{"anonymous @layer", false},
+	}
+	if len(defs) != len(tests) {
+		t.Fatalf("got %d definitions %+v, want %d", len(defs), defs, len(tests))
+	}
+	for i, tt := range tests {
+		if got := defs[i].Key(); got != tt.key {
+			t.Errorf("definition %d key = %q, want %q", i, got, tt.key)
+		}
+		if got := defs[i].PrintOnly(); got != tt.printOnly {
+			t.Errorf("%s: PrintOnly() = %v, want %v", tt.key, got, tt.printOnly)
+		}
+	}
+
+	contexts := ClassContexts(defs)
+	if _, ok := contexts["base"]; ok {
+		t.Error("ClassContexts() includes base, which is also defined outside at-rules")
+	}
+	if got := contexts["print-only"]; len(got) != 1 || got[0] != "@media print" {
+		t.Errorf("ClassContexts()[print-only] = %v, want [@media print]", got)
+	}
+	printOnly := PrintOnlyClasses(defs)
+	if len(printOnly) != 2 {
+		t.Errorf("PrintOnlyClasses() = %v, want print-only and not-screen", printOnly)
+	}
+}
+
+// writeStylesheets writes n stylesheets of utility rules.
+func writeStylesheets(tb testing.TB, dir string, n int) {
+	tb.Helper()
+	var css strings.Builder
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&css, ".p-%d, .hover\\:p-%d:hover { padding: %dpx }\n", i, i, i)
+		fmt.Fprintf(&css, "@media (min-width: 768px) { .md\\:m-%d { margin: %dpx } }\n", i, i)
+	}
+	for i := 0; i < n; i++ {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("sheet-%d.css", i)), []byte(css.String()), 0644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+func TestParseDefinitionsFromDirN_Deterministic(t *testing.T) {
+	dir := t.TempDir()
+	writeStylesheets(t, dir, 12)
+
+	want, err := ParseDefinitionsFromDirN(dir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, jobs := range []int{0, 3, 16} {
+		got, err := ParseDefinitionsFromDirN(dir, jobs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("jobs=%d: definitions differ from a sequential run", jobs)
+		}
+	}
+}
+
+func BenchmarkParseDefinitionsFromDir(b *testing.B) {
+	dir := b.TempDir()
+	writeStylesheets(b, dir, 16)
+
+	for _, jobs := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("jobs=%d", jobs), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := ParseDefinitionsFromDirN(dir, jobs); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
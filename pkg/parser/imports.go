@@ -0,0 +1,216 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/JCorners68/cssguard/pkg/cache"
+)
+
+// Import is an @import rule of a stylesheet.
+type Import struct {
+	URL  string `json:"url"`
+	File string `json:"file,omitempty"` // Stylesheet containing the rule
+	Line int    `json:"line"`
+
+	// Context lists the conditions of the import as at-rules, e.g.
+	// ["@layer vendor", "@media print"] for
+	// @import "vendor.css" layer(vendor) print.
+	Context []string `json:"context,omitempty"`
+}
+
+// ImportWarning is an @import rule that was not followed.
+type ImportWarning struct {
+	Import
+	Reason string `json:"reason"`
+}
+
+// String formats the warning as "main.css:3: @import "x.css" skipped: reason".
+func (w ImportWarning) String() string {
+	return fmt.Sprintf("%s:%d: @import %q skipped: %s", w.File, w.Line, w.URL, w.Reason)
+}
+
+// ParseWithImports is ParseDefinitionsCached following the @import rules of
+// the parsed stylesheets. Relative local imports are parsed recursively and
+// their definitions come before those of the importing file, as in the
+// cascade. Imported definitions record the conditions of the import chain
+// ahead of their own context, and in Entry the file given (or found in the
+// directory given) that pulled them in. Every file is parsed once, so a file
+// imported twice is not counted twice; a file both found in the directory
+// and imported is counted as imported, with the conditions of the import.
+// Remote, root-relative, missing and circular imports are skipped and
+// returned as warnings.
+func ParseWithImports(path string, jobs int, c *cache.Cache) ([]Definition, []ImportWarning, error) {
+	defs, _, warnings, err := ParseWithImportedFiles(path, jobs, c)
+	return defs, warnings, err
+}
+
+// ParseWithImportedFiles is ParseWithImports also returning the absolute
+// paths of the files it read or tried to read, sorted, such as to watch
+// them for changes.
+func ParseWithImportedFiles(path string, jobs int, c *cache.Cache) ([]Definition, []string, []ImportWarning, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var paths []string
+	var sheets []stylesheet
+	if info.IsDir() {
+		if paths, sheets, err = parseDirStylesheets(path, jobs, c); err != nil {
+			return nil, nil, nil, err
+		}
+	} else {
+		sheet, err := loadStylesheet(c, path)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		paths, sheets = []string{path}, []stylesheet{sheet}
+	}
+
+	r := &importResolver{
+		cache:  c,
+		seen:   make(map[string]struct{}),
+		sheets: make(map[string]stylesheet, len(paths)),
+	}
+	for i, p := range paths {
+		r.sheets[absPath(p)] = sheets[i]
+	}
+	// Files imported by another file of the directory are parsed through
+	// the import; the rest are entries
+	imported := make(map[string]struct{})
+	for i, p := range paths {
+		for _, imp := range sheets[i].Imports {
+			if target, reason := importPath(imp); reason == "" && absPath(target) != absPath(p) {
+				imported[absPath(target)] = struct{}{}
+			}
+		}
+	}
+
+	var defs []Definition
+	entry := func(i int) {
+		abs := absPath(paths[i])
+		if _, ok := r.seen[abs]; ok {
+			return
+		}
+		r.seen[abs] = struct{}{}
+		defs = append(defs, r.follow(sheets[i], paths[i], nil, []string{paths[i]})...)
+		defs = append(defs, sheets[i].Definitions...)
+	}
+	for i, p := range paths {
+		if _, ok := imported[absPath(p)]; !ok {
+			entry(i)
+		}
+	}
+	// Imported files not reached from an entry, such as files importing
+	// each other
+	for i := range paths {
+		entry(i)
+	}
+
+	files := make([]string, 0, len(r.seen))
+	for abs := range r.seen {
+		files = append(files, abs)
+	}
+	sort.Strings(files)
+	return defs, files, r.warnings, nil
+}
+
+// importResolver follows @import rules.
+type importResolver struct {
+	cache    *cache.Cache
+	seen     map[string]struct{}   // Absolute paths of the files parsed so far
+	sheets   map[string]stylesheet // Stylesheets of the directory, by absolute path
+	warnings []ImportWarning
+}
+
+// follow returns the definitions of the files sheet imports, recursively.
+// Entry is the file the chain started from, context the conditions of the
+// imports leading to sheet and chain the files on the way, entry first.
+func (r *importResolver) follow(sheet stylesheet, entry string, context []string, chain []string) []Definition {
+	var defs []Definition
+	for _, imp := range sheet.Imports {
+		target, reason := importPath(imp)
+		if reason != "" {
+			r.warnings = append(r.warnings, ImportWarning{Import: imp, Reason: reason})
+			continue
+		}
+		abs := absPath(target)
+		if i := indexOfFile(chain, abs); i >= 0 {
+			cycle := append(append([]string(nil), chain[i:]...), target)
+			r.warnings = append(r.warnings, ImportWarning{Import: imp, Reason: "import cycle " + strings.Join(cycle, " -> ")})
+			continue
+		}
+		if _, ok := r.seen[abs]; ok {
+			continue
+		}
+		r.seen[abs] = struct{}{}
+
+		imported, ok := r.sheets[abs]
+		if !ok {
+			var err error
+			if imported, err = loadStylesheet(r.cache, target); err != nil {
+				r.warnings = append(r.warnings, ImportWarning{Import: imp, Reason: err.Error()})
+				continue
+			}
+		}
+		ctx := append(append([]string(nil), context...), imp.Context...)
+		defs = append(defs, r.follow(imported, entry, ctx, append(chain, target))...)
+		for _, d := range imported.Definitions {
+			if len(ctx) > 0 {
+				d.Context = append(append([]string(nil), ctx...), d.Context...)
+			}
+			d.Entry = entry
+			defs = append(defs, d)
+		}
+	}
+	return defs
+}
+
+// importPath resolves the URL of an import against the importing file, or
+// returns why it cannot be followed. SCSS and LESS imports find partials as
+// their compilers do.
+func importPath(imp Import) (path, reason string) {
+	url := imp.URL
+	if i := strings.IndexAny(url, "?#"); i >= 0 {
+		url = url[:i]
+	}
+	lower := strings.ToLower(url)
+	switch {
+	case strings.HasPrefix(lower, "http://"), strings.HasPrefix(lower, "https://"), strings.HasPrefix(url, "//"):
+		return "", "remote URL"
+	case strings.Contains(lower, ":"):
+		return "", "not a local file"
+	case strings.HasPrefix(url, "/"):
+		return "", "root-relative URL"
+	case url == "":
+		return "", "empty URL"
+	}
+	path = filepath.Join(filepath.Dir(imp.File), filepath.FromSlash(url))
+	if isSourceSyntax(imp.File) {
+		path = sourceImportPath(path, strings.ToLower(filepath.Ext(imp.File)))
+	}
+	return path, ""
+}
+
+// indexOfFile returns the index of the path in paths that names the file at
+// the absolute path abs, or -1.
+func indexOfFile(paths []string, abs string) int {
+	for i, p := range paths {
+		if absPath(p) == abs {
+			return i
+		}
+	}
+	return -1
+}
+
+// absPath makes path absolute, so different spellings of a file compare
+// equal.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
@@ -0,0 +1,137 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestParseWithImports(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.css": `@charset "utf-8";
+@layer base, components;
+@import "components.css";
+@import url("vendor/flowbite.css") layer(vendor);
+@import url(print.css?v=2) print;
+@import "https://fonts.googleapis.com/css2?family=Inter";
+@import "missing.css";
+.main { color: red }
+@import "late.css";
+`,
+		"components.css":      `@import "buttons.css" supports(display: grid) screen and (min-width: 640px); .card {}`,
+		"buttons.css":         `@import "./components.css"; .btn {}`,
+		"vendor/flowbite.css": `@import "../components.css"; .fb {}`,
+		"print.css":           `.receipt {}`,
+		"late.css":            `.late {}`,
+	})
+	main := filepath.Join(dir, "main.css")
+
+	defs, warnings, err := ParseWithImports(main, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, d := range defs {
+		got = append(got, d.Key()+" <- "+filepath.Base(d.Entry))
+	}
+	want := []string{
+		"btn @supports (display: grid) @media screen and (min-width: 640px) <- main.css",
+		"card <- main.css",
+		"fb @layer vendor <- main.css",
+		"receipt @media print <- main.css",
+		"main <- .",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("definitions:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	wantWarnings := map[string]string{ // URL -> start of reason
+		"./components.css": "import cycle " + filepath.Join(dir, "components.css") + " -> ",
+		"https://fonts.googleapis.com/css2?family=Inter": "remote URL",
+		"missing.css": "open ",
+	}
+	if len(warnings) != len(wantWarnings) {
+		t.Errorf("warnings = %v, want %d", warnings, len(wantWarnings))
+	}
+	for _, w := range warnings {
+		if want, ok := wantWarnings[w.URL]; !ok || !strings.HasPrefix(w.Reason, want) {
+			t.Errorf("warning %s, want reason starting %q", w, want)
+		}
+	}
+
+	_, files, _, err := ParseWithImportedFiles(main, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range files {
+		rel, _ := filepath.Rel(dir, f)
+		names = append(names, filepath.ToSlash(rel))
+	}
+	if want := "buttons.css,components.css,main.css,missing.css,print.css,vendor/flowbite.css"; strings.Join(names, ",") != want {
+		t.Errorf("files = %v, want %s", names, want)
+	}
+
+	classes, err := ParseFromFile(main)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(classes) != 5 {
+		t.Errorf("ParseFromFile() = %v, want the classes of main.css and its imports", classes)
+	}
+}
+
+func TestParseWithImportsDir(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.css":          `@import "b.css" layer(vendor) print; @import "../outside.css"; .a {}`,
+		"b.css":          `.b {}`,
+		"base.css":       `.base {}`,
+		"theme.css":      `@import "base.css" print; .theme {}`,
+		"x.css":          `@import "y.css"; .x {}`,
+		"y.css":          `@import "x.css"; .y {}`,
+		"../outside.css": `.outside {}`,
+	})
+
+	defs, warnings, err := ParseWithImports(dir, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 || !strings.HasPrefix(warnings[0].Reason, "import cycle ") {
+		t.Errorf("warnings = %v, want the x.css cycle", warnings)
+	}
+	var got []string
+	for _, d := range defs {
+		got = append(got, d.Key()+" <- "+filepath.Base(d.Entry))
+	}
+	// Files in the directory that are also imported take the conditions of
+	// the import, even if they come first in the directory
+	want := []string{
+		"b @layer vendor @media print <- a.css",
+		"outside <- a.css",
+		"a <- .",
+		"base @media print <- theme.css",
+		"theme <- .",
+		"y <- x.css",
+		"x <- .",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("definitions:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,572 @@
+package parser
+
+import (
+	"sort"
+	"strings"
+)
+
+// groupingAtRules are at-rules whose blocks contain further style rules.
+// Blocks of any other at-rule (@font-face, @keyframes, @page, ...) are skipped.
+var groupingAtRules = map[string]struct{}{
+	"media":          {},
+	"supports":       {},
+	"layer":          {},
+	"container":      {},
+	"document":       {},
+	"-moz-document":  {},
+	"scope":          {},
+	"starting-style": {},
+}
+
+// ruleParser consumes a token stream as a list of rules (CSS Syntax Level 3,
+// §5) and records a Definition for each class named in a style rule selector.
+// Style rules nested in others (CSS Nesting) have their selectors resolved
+// against their parents'.
+type ruleParser struct {
+	tz      *tokenizer
+	file    string
+	tokens  []token
+	pos     int
+	defs    []Definition
+	imports []Import
+	context []string // Enclosing grouping at-rules, outermost first
+
+	// started is set by the first rule after which @import is invalid:
+	// anything but @charset, @import and @layer statements.
+	started bool
+
+	// source is set for SCSS and LESS, which allow @import anywhere at the
+	// top level and several files per @import.
+	source bool
+}
+
+func newRuleParser(tz *tokenizer, file string) *ruleParser {
+	p := &ruleParser{
+		tz:     tz,
+		file:   file,
+		source: isSourceSyntax(file),
+	}
+	if p.source {
+		src := tz.src
+		tz.src = maskSourceSyntax(src)
+		p.tokens = tz.tokenize()
+		tz.src = src // Selector and context text as written
+	} else {
+		p.tokens = tz.tokenize()
+	}
+	return p
+}
+
+func (p *ruleParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.typ != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *ruleParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// parseRuleList consumes rules until EOF, or until the closing brace of the
+// enclosing block when topLevel is false.
+func (p *ruleParser) parseRuleList(topLevel bool) {
+	for {
+		switch tok := p.peek(); tok.typ {
+		case tokEOF:
+			return
+		case tokWhitespace, tokSemicolon:
+			p.next()
+		case tokCDO, tokCDC:
+			if topLevel {
+				p.next()
+				continue
+			}
+			p.parseQualifiedRule(nil)
+		case tokRightBrace:
+			p.next()
+			if !topLevel {
+				return
+			}
+		case tokAtKeyword:
+			p.parseAtRule(nil)
+		default:
+			p.parseQualifiedRule(nil)
+		}
+	}
+}
+
+// parseStyleBlock consumes the contents of a style rule's block through its
+// closing brace: declarations, which are skipped, and nested rules, whose
+// selectors are relative to parents.
+func (p *ruleParser) parseStyleBlock(parents []resolvedSelector) {
+	for {
+		switch tok := p.peek(); tok.typ {
+		case tokEOF:
+			return
+		case tokWhitespace, tokSemicolon:
+			p.next()
+		case tokRightBrace:
+			p.next()
+			return
+		case tokAtKeyword:
+			p.parseAtRule(parents)
+		default:
+			if tok.typ == tokIdent && strings.HasPrefix(tok.value, "--") {
+				// A custom property's value may hold braces, as in
+				// --x: { .b {} }, so it is never read as a rule
+				p.skipDeclaration()
+				continue
+			}
+			// A declaration is a prelude ending in ';' or '}' rather than
+			// a block, so it is consumed as a rule that never starts
+			p.parseQualifiedRule(parents)
+		}
+	}
+}
+
+// skipDeclaration consumes a declaration up to its terminating ';', or up to
+// the '}' closing the enclosing block, skipping any blocks in its value.
+func (p *ruleParser) skipDeclaration() {
+	for {
+		switch p.peek().typ {
+		case tokEOF, tokRightBrace:
+			return
+		case tokSemicolon:
+			p.next()
+			return
+		case tokLeftBrace:
+			p.next()
+			p.skipBlock(tokRightBrace)
+		case tokLeftBracket:
+			p.next()
+			p.skipBlock(tokRightBracket)
+		case tokLeftParen, tokFunction:
+			p.next()
+			p.skipBlock(tokRightParen)
+		default:
+			p.next()
+		}
+	}
+}
+
+// parseAtRule consumes an at-rule, descending into grouping rule blocks.
+// The definitions inside a grouping rule record it in their context. Inside
+// a style rule, parents holds its selectors, which rules in a nested
+// grouping rule are relative to; it is nil at the top level.
+func (p *ruleParser) parseAtRule(parents []resolvedSelector) {
+	at := p.next()
+	name := strings.ToLower(at.value)
+	prelude := trimWhitespace(p.consumePrelude())
+	if p.peek().typ != tokLeftBrace {
+		// Statement at-rule ending in ';', EOF or the enclosing block
+		if p.peek().typ != tokRightBrace {
+			p.next()
+		}
+		switch {
+		case name == "import" && (!p.started || p.source) && len(p.context) == 0 && parents == nil:
+			p.recordImports(at, prelude)
+		case name != "charset" && name != "import" && name != "layer":
+			p.started = true
+		}
+		return
+	}
+	p.next()
+	p.started = true
+	if _, ok := groupingAtRules[name]; ok {
+		rule := "@" + name
+		if len(prelude) > 0 {
+			rule += " " + normalizeSelector(p.tz.text(prelude[0].pos, prelude[len(prelude)-1].end))
+		}
+		p.context = append(p.context, rule)
+		if parents == nil {
+			p.parseRuleList(false)
+		} else {
+			p.parseStyleBlock(parents)
+		}
+		p.context = p.context[:len(p.context)-1]
+		return
+	}
+	p.skipBlock(tokRightBrace)
+}
+
+// parseQualifiedRule consumes a style rule, recording the classes in its
+// selector and those of the rules nested in its block. Parents holds the
+// selectors of the enclosing style rule, or is nil at the top level.
+func (p *ruleParser) parseQualifiedRule(parents []resolvedSelector) {
+	p.started = true
+	prelude := p.consumePrelude()
+	switch p.peek().typ {
+	case tokLeftBrace:
+		p.next()
+	case tokRightBrace:
+		return // End of the enclosing block: a declaration
+	default:
+		p.next()
+		return // ';' or EOF before the block: not a rule
+	}
+	if p.source && isMixinDefinition(prelude) {
+		p.skipBlock(tokRightBrace)
+		return
+	}
+	selectors := p.resolveSelectors(prelude, parents)
+	p.recordSelectors(selectors)
+	p.parseStyleBlock(selectors)
+}
+
+// consumePrelude returns the tokens up to (not including) the next top-level
+// '{', ';' or '}'. Nested (), [] and function blocks are included whole.
+func (p *ruleParser) consumePrelude() []token {
+	var prelude []token
+	for {
+		tok := p.peek()
+		switch tok.typ {
+		case tokEOF, tokLeftBrace, tokSemicolon, tokRightBrace:
+			return prelude
+		case tokLeftParen, tokFunction:
+			start := p.pos
+			p.next()
+			p.skipBlock(tokRightParen)
+			prelude = append(prelude, p.tokens[start:p.pos]...)
+		case tokLeftBracket:
+			start := p.pos
+			p.next()
+			p.skipBlock(tokRightBracket)
+			prelude = append(prelude, p.tokens[start:p.pos]...)
+		default:
+			prelude = append(prelude, p.next())
+		}
+	}
+}
+
+// resolvedSelector is a complex selector with its nesting resolved.
+type resolvedSelector struct {
+	text string     // Full selector, & replaced by the parent selector
+	refs []classRef // Classes named in the rule's own selector, or formed by & suffixes
+	last string     // Class the selector ends with, which an & suffix extends; "" if none
+}
+
+// resolveSelectors resolves each complex selector of a selector list prelude
+// against each of parents, the selectors of the enclosing style rule (nil at
+// the top level):
+//
+//   - & stands for the parent selector, as in &:hover or .theme-dark &
+//   - & directly followed by a name extends the class the parent ends with,
+//     as in SCSS and LESS: &__title in .card is .card__title
+//   - a selector without & is a descendant of the parent
+//
+// The result is never nil, so rules nested in it know they are nested.
+func (p *ruleParser) resolveSelectors(prelude []token, parents []resolvedSelector) []resolvedSelector {
+	resolved := []resolvedSelector{}
+	for _, selector := range splitSelectorList(prelude) {
+		if parents == nil {
+			resolved = append(resolved, p.resolveSelector(selector, resolvedSelector{}, false))
+			continue
+		}
+		for _, parent := range parents {
+			resolved = append(resolved, p.resolveSelector(selector, parent, true))
+		}
+	}
+	return resolved
+}
+
+func (p *ruleParser) resolveSelector(selector []token, parent resolvedSelector, nested bool) resolvedSelector {
+	r := resolvedSelector{refs: selectorClasses(selector)}
+	var b strings.Builder
+	hasNesting := false
+	for i := 0; i < len(selector); i++ {
+		tok := selector[i]
+		if tok.typ != tokDelim || tok.value != "&" {
+			b.WriteString(p.tz.text(tok.pos, tok.end))
+			r.last = ""
+			if tok.typ == tokIdent && i > 0 && selector[i-1].typ == tokDelim && selector[i-1].value == "." {
+				r.last = tok.value
+			}
+			continue
+		}
+
+		hasNesting = true
+		b.WriteString(parent.text)
+		r.last = parent.last
+		if i+1 >= len(selector) || !isNameSuffix(selector[i+1]) || selector[i+1].pos != tok.end {
+			continue
+		}
+		suffix := selector[i+1]
+		i++
+		b.WriteString(p.tz.text(suffix.pos, suffix.end))
+		r.last = ""
+		if parent.last == "" {
+			continue // No class to extend, e.g. div { &-x {} }
+		}
+		name := parent.last + suffix.value
+		if suffix.typ != tokIdent {
+			name = parent.last + p.tz.text(suffix.pos, suffix.end)
+		}
+		if !strings.ContainsRune(name, interpolationMarker) {
+			r.refs = append(r.refs, classRef{name: name, pos: tok.pos})
+			r.last = name
+		}
+	}
+
+	r.text = normalizeSelector(b.String())
+	if nested && !hasNesting {
+		r.text = normalizeSelector(parent.text + " " + r.text)
+	}
+	sort.SliceStable(r.refs, func(i, j int) bool { return r.refs[i].pos < r.refs[j].pos })
+	return r
+}
+
+// isNameSuffix reports whether a token directly after & continues a class
+// name: &__title, &--active and &-2 in SCSS.
+func isNameSuffix(tok token) bool {
+	switch tok.typ {
+	case tokIdent, tokNumber, tokDimension:
+		return true
+	}
+	return false
+}
+
+// recordSelectors adds a Definition for every class in each resolved
+// selector of a rule. A class resolved against several parent selectors is
+// recorded once, with the first.
+func (p *ruleParser) recordSelectors(selectors []resolvedSelector) {
+	var context []string
+	if len(p.context) > 0 {
+		context = append(context, p.context...)
+	}
+	recorded := make(map[classRef]struct{})
+	for _, selector := range selectors {
+		for _, ref := range selector.refs {
+			if _, ok := recorded[ref]; ok {
+				continue
+			}
+			recorded[ref] = struct{}{}
+			line, col := p.tz.position(ref.pos)
+			p.defs = append(p.defs, Definition{
+				Class:    ref.name,
+				File:     p.file,
+				Line:     line,
+				Column:   col,
+				Selector: selector.text,
+				Context:  context,
+			})
+		}
+	}
+}
+
+// recordImports adds the Imports of an @import prelude. SCSS and LESS
+// import several files at once, as in @import "base", "buttons".
+func (p *ruleParser) recordImports(at token, prelude []token) {
+	if p.source {
+		urls := splitSelectorList(prelude)
+		single := len(urls) == 1
+		for _, url := range urls {
+			single = single || len(url) != 1 || url[0].typ != tokString
+		}
+		if !single {
+			for _, url := range urls {
+				p.recordImport(at, url)
+			}
+			return
+		}
+	}
+	p.recordImport(at, prelude)
+}
+
+// recordImport adds an Import for an @import prelude: a URL followed by
+// optional layer, supports() and media query conditions. Preludes without a
+// URL are ignored.
+func (p *ruleParser) recordImport(at token, prelude []token) {
+	if len(prelude) == 0 {
+		return
+	}
+	var url string
+	rest := prelude[1:]
+	switch tok := prelude[0]; {
+	case tok.typ == tokString || tok.typ == tokURL:
+		url = tok.value
+	case tok.typ == tokFunction && strings.EqualFold(tok.value, "url"):
+		args := trimWhitespace(prelude[1:closingParen(prelude, 0)])
+		if len(args) != 1 || args[0].typ != tokString {
+			return
+		}
+		url = args[0].value
+		rest = prelude[closingParen(prelude, 0)+1:]
+	default:
+		return
+	}
+
+	var context []string
+	rest = trimWhitespace(rest)
+	for len(rest) > 0 {
+		tok := rest[0]
+		switch {
+		case tok.typ == tokIdent && strings.EqualFold(tok.value, "layer"):
+			context = append(context, "@layer")
+			rest = trimWhitespace(rest[1:])
+			continue
+		case tok.typ == tokFunction && (strings.EqualFold(tok.value, "layer") || strings.EqualFold(tok.value, "supports")):
+			end := closingParen(rest, 0)
+			inner := ""
+			if end > 1 {
+				inner = normalizeSelector(p.tz.text(rest[1].pos, rest[end-1].end))
+			}
+			if strings.EqualFold(tok.value, "layer") {
+				context = append(context, "@layer "+inner)
+			} else {
+				context = append(context, "@supports "+supportsCondition(inner))
+			}
+			rest = trimWhitespace(rest[min(end+1, len(rest)):])
+			continue
+		}
+		break
+	}
+	if len(rest) > 0 {
+		context = append(context, "@media "+normalizeSelector(p.tz.text(rest[0].pos, rest[len(rest)-1].end)))
+	}
+
+	line, _ := p.tz.position(at.pos)
+	p.imports = append(p.imports, Import{URL: url, File: p.file, Line: line, Context: context})
+}
+
+// closingParen returns the index of the ')' closing the function or '(' at
+// tokens[open], or the last index if it is unclosed.
+func closingParen(tokens []token, open int) int {
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		switch tokens[i].typ {
+		case tokFunction, tokLeftParen:
+			depth++
+		case tokRightParen:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(tokens) - 1
+}
+
+// supportsCondition turns the argument of an @import supports() into an
+// @supports condition: a bare declaration gets parentheses.
+func supportsCondition(arg string) string {
+	lower := strings.ToLower(arg)
+	if strings.HasPrefix(arg, "(") || strings.HasPrefix(lower, "not ") || strings.HasPrefix(lower, "selector(") {
+		return arg
+	}
+	return "(" + arg + ")"
+}
+
+// skipBlock consumes tokens through the matching closer of an already opened
+// block, honouring nested blocks of every kind.
+func (p *ruleParser) skipBlock(closer tokenType) {
+	for {
+		switch p.next().typ {
+		case tokEOF, closer:
+			return
+		case tokLeftBrace:
+			p.skipBlock(tokRightBrace)
+		case tokLeftBracket:
+			p.skipBlock(tokRightBracket)
+		case tokLeftParen, tokFunction:
+			p.skipBlock(tokRightParen)
+		}
+	}
+}
+
+// classRef is a class name and the offset of the '.' that introduces it.
+type classRef struct {
+	name string
+	pos  int
+}
+
+// splitSelectorList splits a prelude at its top-level commas, dropping
+// surrounding whitespace from each complex selector.
+func splitSelectorList(prelude []token) [][]token {
+	var selectors [][]token
+	depth := 0
+	start := 0
+	for i := 0; i <= len(prelude); i++ {
+		if i < len(prelude) {
+			switch prelude[i].typ {
+			case tokFunction, tokLeftParen, tokLeftBracket:
+				depth++
+				continue
+			case tokRightParen, tokRightBracket:
+				depth--
+				continue
+			case tokComma:
+				if depth > 0 {
+					continue
+				}
+			default:
+				continue
+			}
+		}
+		if selector := trimWhitespace(prelude[start:i]); len(selector) > 0 {
+			selectors = append(selectors, selector)
+		}
+		start = i + 1
+	}
+	return selectors
+}
+
+func trimWhitespace(tokens []token) []token {
+	for len(tokens) > 0 && tokens[0].typ == tokWhitespace {
+		tokens = tokens[1:]
+	}
+	for len(tokens) > 0 && tokens[len(tokens)-1].typ == tokWhitespace {
+		tokens = tokens[:len(tokens)-1]
+	}
+	return tokens
+}
+
+// normalizeSelector collapses runs of whitespace in selector source text.
+func normalizeSelector(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// selectorClasses returns the class references in a selector: every '.'
+// delim immediately followed by an ident, including those in functional
+// pseudo-classes such as :not() and :has(), since those classes are styled
+// or matched too. Names built by SCSS or LESS interpolation are left out, as
+// are negative names that are not Tailwind utilities.
+func selectorClasses(selector []token) []classRef {
+	var refs []classRef
+	for i, tok := range selector {
+		if tok.typ != tokDelim || tok.value != "." || i+1 >= len(selector) {
+			continue
+		}
+		next := selector[i+1]
+		if next.typ != tokIdent || next.value == "" || strings.ContainsRune(next.value, interpolationMarker) {
+			continue
+		}
+		if strings.HasPrefix(next.value, "-") && !isValidNegativeClass(next.value) {
+			continue
+		}
+		refs = append(refs, classRef{name: next.value, pos: tok.pos})
+	}
+	return refs
+}
+
+// isValidNegativeClass checks if a negative class is valid (e.g., -translate-x-full)
+func isValidNegativeClass(name string) bool {
+	if !strings.HasPrefix(name, "-") {
+		return true
+	}
+	// Valid negative Tailwind utilities
+	validPrefixes := []string{
+		"-translate", "-rotate", "-skew", "-scale",
+		"-m-", "-mx-", "-my-", "-mt-", "-mr-", "-mb-", "-ml-",
+		"-p-", "-px-", "-py-", "-pt-", "-pr-", "-pb-", "-pl-",
+		"-inset", "-top-", "-right-", "-bottom-", "-left-",
+		"-z-", "-order-", "-tracking-", "-indent-",
+	}
+	for _, prefix := range validPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IsStylesheet reports whether path names a stylesheet the parser reads:
+// CSS, or SCSS and LESS source.
+func IsStylesheet(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".css", ".scss", ".less":
+		return true
+	}
+	return false
+}
+
+// isSourceSyntax reports whether path names an SCSS or LESS stylesheet.
+//
+// Those are read by a best-effort front end: variables, mixins, functions
+// and control directives are ignored, while selector nesting, & suffixes and
+// @import of partials are resolved like a compiler would. Rules generated by
+// @each or @include are not seen.
+func isSourceSyntax(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".scss", ".less":
+		return true
+	}
+	return false
+}
+
+// interpolationMarker replaces the characters of SCSS #{...} and LESS @{...}
+// interpolations before tokenizing, so .btn-#{$size} reads as one ident.
+// Class names containing it are not known until compile time and are
+// dropped.
+const interpolationMarker = '\uE000'
+
+// maskSourceSyntax returns a copy of SCSS or LESS source the CSS tokenizer
+// can read: // line comments become spaces and interpolations become
+// interpolationMarker. Newlines are kept, so offsets into the copy are
+// offsets into src.
+func maskSourceSyntax(src []rune) []rune {
+	out := append([]rune(nil), src...)
+	for i := 0; i < len(out); i++ {
+		c := out[i]
+		switch {
+		case c == '"' || c == '\'':
+			for i++; i < len(out) && out[i] != c && out[i] != '\n'; i++ {
+				if out[i] == '\\' {
+					i++
+				}
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '*':
+			for i += 2; i+1 < len(out) && (out[i] != '*' || out[i+1] != '/'); i++ {
+			}
+			i++
+		case c == '/' && i+1 < len(out) && out[i+1] == '/':
+			if i > 0 && (out[i-1] == ':' || out[i-1] == '(') {
+				continue // http:// or url(//host/...)
+			}
+			for ; i < len(out) && out[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+		case (c == '#' || c == '@') && i+1 < len(out) && out[i+1] == '{':
+			depth := 0
+			for ; i < len(out); i++ {
+				switch out[i] {
+				case '{':
+					depth++
+				case '}':
+					depth--
+				}
+				closed := out[i] == '}' && depth == 0
+				if out[i] != '\n' {
+					out[i] = interpolationMarker
+				}
+				if closed {
+					break
+				}
+			}
+		}
+	}
+	return out
+}
+
+// isMixinDefinition reports whether a rule prelude defines or calls a LESS
+// mixin with arguments, e.g. .rounded(@radius: 4px) or .m() when (@a), which
+// compiles to no rule of its own.
+func isMixinDefinition(prelude []token) bool {
+	for i := 0; i+1 < len(prelude); i++ {
+		if prelude[i].typ == tokDelim && prelude[i].value == "." && prelude[i+1].typ == tokFunction {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceImportPath finds the file an SCSS or LESS import of path names.
+// Imports leave out the extension; SCSS ones also the leading underscore of
+// partials, and may name a directory holding an _index file. The first
+// candidate is returned if none exists, to be reported missing.
+func sourceImportPath(path, ext string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".css", ".scss", ".less", ".sass":
+		return path
+	}
+	candidates := []string{path + ext}
+	if ext == ".scss" {
+		dir, base := filepath.Split(path)
+		candidates = append(candidates,
+			filepath.Join(dir, "_"+base+ext),
+			filepath.Join(path, "_index"+ext),
+			filepath.Join(path, "index"+ext))
+	}
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+	}
+	return candidates[0]
+}
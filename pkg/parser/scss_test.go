@@ -0,0 +1,168 @@
+package parser
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// selectorsByClass formats definitions as "class=selector" for comparison.
+func selectorsByClass(defs []Definition) []string {
+	var got []string
+	for _, d := range defs {
+		got = append(got, d.Class+"="+d.Selector)
+	}
+	return got
+}
+
+func TestParseNesting(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		css      string
+		expected []string
+	}{
+		{
+			name:     "descendant rule",
+			file:     "site.css",
+			css:      ".card { padding: 0; .title { margin: 0 } }",
+			expected: []string{"card=.card", "title=.card .title"},
+		},
+		{
+			name:     "parent selector",
+			file:     "site.css",
+			css:      ".btn { color: red; &:hover { color: blue; } .theme-dark & { color: white } &.active > .icon {} }",
+			expected: []string{"btn=.btn", "theme-dark=.theme-dark .btn", "active=.btn.active > .icon", "icon=.btn.active > .icon"},
+		},
+		{
+			name:     "relative selector",
+			file:     "site.css",
+			css:      ".list { > .item { } }",
+			expected: []string{"list=.list", "item=.list > .item"},
+		},
+		{
+			name:     "suffixes",
+			file:     "site.scss",
+			css:      ".card { &__title { &--large { } } &-2 {} &-2xl {} }",
+			expected: []string{"card=.card", "card__title=.card__title", "card__title--large=.card__title--large", "card-2=.card-2", "card-2xl=.card-2xl"},
+		},
+		{
+			name:     "suffix of each parent",
+			file:     "site.scss",
+			css:      ".a, .b { &-x { } .c { } }",
+			expected: []string{"a=.a", "b=.b", "a-x=.a-x", "b-x=.b-x", "c=.a .c"},
+		},
+		{
+			name:     "suffix without a class",
+			file:     "site.scss",
+			css:      "div { &-x { } } .a:hover { &-y { } }",
+			expected: []string{"a=.a:hover"},
+		},
+		{
+			name:     "nested at-rule",
+			file:     "site.css",
+			css:      ".nav { @media (min-width: 768px) { display: flex; &__link { } } @apply flex }",
+			expected: []string{"nav=.nav", "nav__link=.nav__link"},
+		},
+		{
+			name:     "declarations",
+			file:     "site.css",
+			css:      ".hero { background: url(a.b.png); content: \".x\"; margin: .5rem }",
+			expected: []string{"hero=.hero"},
+		},
+		{
+			name: "scss",
+			file: "site.scss",
+			css: `@use "sass:math";
+$gap: 4px; // .not-a-class { }
+@mixin card($p) { .mixin-only { padding: $p; } }
+.btn-#{$size} { }
+.panel {
+  @include card(2px);
+  // &__commented { }
+  &__body { margin: math.div($gap, 2); }
+  @each $k in a, b { .each-#{$k} { } }
+  @extend %base;
+  font: { family: serif; }
+}`,
+			expected: []string{"panel=.panel", "panel__body=.panel__body"},
+		},
+		{
+			name: "less",
+			file: "site.less",
+			css: `@gap: 4px;
+.rounded(@r: 2px) { border-radius: @r; }
+.@{prefix}-x { }
+.box {
+  .rounded(4px);
+  .bordered;
+  &-inner { margin: @gap; }
+}`,
+			expected: []string{"box=.box", "box-inner=.box-inner"},
+		},
+		{
+			name:     "url comment in less",
+			file:     "site.less",
+			css:      ".a { background: url(http://x.test/a.png); } // .b {}\n.c { background: url(//cdn.test/c.png) }",
+			expected: []string{"a=.a", "c=.c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defs, err := ParseDefinitionsFromReader(strings.NewReader(tt.css), tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := selectorsByClass(defs); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseNestingPositions(t *testing.T) {
+	scss := ".card {\n  &__title {\n    @media print { &--x { } }\n  }\n}\n"
+
+	defs, err := ParseDefinitionsFromReader(strings.NewReader(scss), "card.scss")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Definition{
+		{Class: "card", File: "card.scss", Line: 1, Column: 1, Selector: ".card"},
+		{Class: "card__title", File: "card.scss", Line: 2, Column: 3, Selector: ".card__title"},
+		{Class: "card__title--x", File: "card.scss", Line: 3, Column: 20, Selector: ".card__title--x", Context: []string{"@media print"}},
+	}
+	if !reflect.DeepEqual(defs, expected) {
+		t.Errorf("got %+v, want %+v", defs, expected)
+	}
+}
+
+func TestParseWithImportsPartials(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.scss":                "$x: 1;\n.main { }\n@import 'base', 'components/buttons';\n@import 'theme';\n@import 'missing';",
+		"_base.scss":               ".base { }",
+		"components/_buttons.scss": ".btn { &--primary { } }",
+		"theme/_index.scss":        ".theme { }",
+	})
+
+	defs, warnings, err := ParseWithImports(filepath.Join(dir, "main.scss"), 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var classes []string
+	for _, d := range defs {
+		classes = append(classes, d.Class)
+	}
+	expected := []string{"base", "btn", "btn--primary", "theme", "main"}
+	if !reflect.DeepEqual(classes, expected) {
+		t.Errorf("classes = %q, want %q", classes, expected)
+	}
+	if len(warnings) != 1 || warnings[0].URL != "missing" {
+		t.Errorf("warnings = %v, want the missing import", warnings)
+	}
+}
@@ -0,0 +1,95 @@
+package parser
+
+import "strings"
+
+// Compound is a compound selector reduced to the classes an element needs
+// to match it, e.g. .card.active in .list > .card.active:hover.
+type Compound struct {
+	// Combinator relates the compound to the one before it: " ", ">", "+"
+	// or "~"; it is empty for the first compound.
+	Combinator string   `json:"combinator,omitempty"`
+	Classes    []string `json:"classes,omitempty"`
+}
+
+// Compounds splits the definition's selector into its compound selectors,
+// leftmost first, so the last is the subject: the element the rule styles.
+// Classes inside functional pseudo-classes such as :is() and :not() are left
+// out, as are tags, IDs and attributes, so a compound without classes
+// matches any element. It returns nil for a selector it cannot split, such
+// as one using the column combinator.
+func (d Definition) Compounds() []Compound {
+	return selectorCompounds(d.Selector)
+}
+
+// Structural reports whether the rule needs more than the class on the
+// element it styles: other classes on it (.btn.active) or on the elements
+// around it (.card > .title). It is false for a class that is not in the
+// subject, such as card in .card > .title, since the rule never styles
+// elements by it.
+func (d Definition) Structural() bool {
+	compounds := d.Compounds()
+	if len(compounds) == 0 || !contains(compounds[len(compounds)-1].Classes, d.Class) {
+		return false
+	}
+	for i, c := range compounds {
+		if i < len(compounds)-1 && len(c.Classes) > 0 {
+			return true
+		}
+	}
+	for _, class := range compounds[len(compounds)-1].Classes {
+		if class != d.Class {
+			return true
+		}
+	}
+	return false
+}
+
+func selectorCompounds(selector string) []Compound {
+	tz := newTokenizer(selector)
+	tz.src = maskSourceSyntax(tz.src)
+	tokens := tz.tokenize()
+
+	compounds := []Compound{{}}
+	combinator := "" // Pending combinator before the next compound
+	depth := 0       // Nesting of (), [] and functions
+	for i, tok := range tokens {
+		if depth == 0 {
+			switch {
+			case tok.typ == tokWhitespace:
+				if combinator == "" {
+					combinator = " "
+				}
+				continue
+			case tok.typ == tokDelim && (tok.value == ">" || tok.value == "+" || tok.value == "~"):
+				combinator = tok.value
+				continue
+			case tok.typ == tokDelim && tok.value == "|",
+				tok.typ == tokComma, tok.typ == tokLeftBrace, tok.typ == tokRightBrace:
+				return nil
+			case tok.typ == tokEOF:
+				return compounds
+			}
+			if combinator != "" {
+				compounds = append(compounds, Compound{Combinator: combinator})
+				combinator = ""
+			}
+		}
+
+		switch tok.typ {
+		case tokFunction, tokLeftParen, tokLeftBracket:
+			depth++
+		case tokRightParen, tokRightBracket:
+			depth--
+		case tokDelim:
+			if depth > 0 || tok.value != "." {
+				continue
+			}
+			next := tokens[i+1]
+			if next.typ == tokIdent && next.value != "" && !strings.ContainsRune(next.value, interpolationMarker) {
+				last := &compounds[len(compounds)-1]
+				last.Classes = append(last.Classes, next.value)
+			}
+		}
+	}
+	return compounds
+}
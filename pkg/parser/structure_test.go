@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDefinitionCompounds(t *testing.T) {
+	tests := []struct {
+		selector   string
+		class      string
+		expected   []Compound
+		structural bool
+	}{
+		{
+			selector: ".btn",
+			class:    "btn",
+			expected: []Compound{{Classes: []string{"btn"}}},
+		},
+		{
+			selector: `.hover\:flex:hover::before`,
+			class:    "hover:flex",
+			expected: []Compound{{Classes: []string{"hover:flex"}}},
+		},
+		{
+			selector: "ul.nav > li .active",
+			class:    "active",
+			expected: []Compound{
+				{Classes: []string{"nav"}},
+				{Combinator: ">"},
+				{Combinator: " ", Classes: []string{"active"}},
+			},
+			structural: true,
+		},
+		{
+			selector: ".card>.card-title",
+			class:    "card",
+			expected: []Compound{
+				{Classes: []string{"card"}},
+				{Combinator: ">", Classes: []string{"card-title"}},
+			},
+		},
+		{
+			selector: ".peer:checked ~ .peer-checked\\:block + [data-x=\".y\"]",
+			class:    "peer-checked:block",
+			expected: []Compound{
+				{Classes: []string{"peer"}},
+				{Combinator: "~", Classes: []string{"peer-checked:block"}},
+				{Combinator: "+"},
+			},
+		},
+		{
+			selector:   ".btn.active:not(.disabled)",
+			class:      "active",
+			expected:   []Compound{{Classes: []string{"btn", "active"}}},
+			structural: true,
+		},
+		{
+			selector: ":is(.dark .x) .y",
+			class:    "y",
+			expected: []Compound{
+				{},
+				{Combinator: " ", Classes: []string{"y"}},
+			},
+		},
+		{
+			selector: "col.selected || td",
+			class:    "selected",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			d := Definition{Class: tt.class, Selector: tt.selector}
+			if got := d.Compounds(); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Compounds() = %+v, want %+v", got, tt.expected)
+			}
+			if got := d.Structural(); got != tt.structural {
+				t.Errorf("Structural() = %v, want %v", got, tt.structural)
+			}
+		})
+	}
+}
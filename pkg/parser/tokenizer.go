@@ -0,0 +1,531 @@
+package parser
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// tokenType identifies the kind of a CSS token (CSS Syntax Level 3, §4).
+type tokenType int
+
+const (
+	tokEOF tokenType = iota
+	tokIdent
+	tokFunction
+	tokAtKeyword
+	tokHash
+	tokString
+	tokBadString
+	tokURL
+	tokBadURL
+	tokDelim
+	tokNumber
+	tokPercentage
+	tokDimension
+	tokWhitespace
+	tokCDO
+	tokCDC
+	tokColon
+	tokSemicolon
+	tokComma
+	tokLeftBracket
+	tokRightBracket
+	tokLeftParen
+	tokRightParen
+	tokLeftBrace
+	tokRightBrace
+)
+
+// token is a single CSS token. Value holds the unescaped name for idents,
+// functions, at-keywords and hashes, the contents of strings and urls, and the
+// code point for delims. Pos and end are the rune offsets of the token's first
+// character and of the character following it.
+type token struct {
+	typ   tokenType
+	value string
+	pos   int
+	end   int
+}
+
+// tokenizer converts CSS source into tokens following the CSS Syntax Level 3
+// tokenization algorithm. Comments are consumed and never produce tokens.
+type tokenizer struct {
+	src        []rune
+	pos        int
+	lineStarts []int
+}
+
+// newTokenizer preprocesses the input stream (§3.3) and returns a tokenizer.
+func newTokenizer(input string) *tokenizer {
+	input = strings.ReplaceAll(input, "\r\n", "\n")
+	input = strings.NewReplacer("\r", "\n", "\f", "\n", "\x00", "�").Replace(input)
+
+	src := make([]rune, 0, utf8.RuneCountInString(input))
+	lineStarts := []int{0}
+	for _, r := range input {
+		src = append(src, r)
+		if r == '\n' {
+			lineStarts = append(lineStarts, len(src))
+		}
+	}
+	return &tokenizer{src: src, lineStarts: lineStarts}
+}
+
+// tokenize consumes the whole input and returns its tokens, ending with EOF.
+func (t *tokenizer) tokenize() []token {
+	var tokens []token
+	for {
+		tok := t.consumeToken()
+		tok.end = t.pos
+		tokens = append(tokens, tok)
+		if tok.typ == tokEOF {
+			return tokens
+		}
+	}
+}
+
+// UnescapeIdent resolves the CSS escapes in an identifier as written in a
+// selector, e.g. `bg-\[\#1da1f2\]` -> "bg-[#1da1f2]" and `\32 xl\:flex` ->
+// "2xl:flex". Hex escapes follow the same rules as the tokenizer: up to six
+// hex digits, an optional trailing whitespace, and U+FFFD for invalid code
+// points. Other characters are kept as they are.
+func UnescapeIdent(s string) string {
+	t := newTokenizer(s)
+	var b strings.Builder
+	for c := t.peek(0); c != -1; c = t.peek(0) {
+		if isValidEscape(c, t.peek(1)) {
+			t.pos++
+			b.WriteRune(t.consumeEscape())
+			continue
+		}
+		t.pos++
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// text returns the source between two rune offsets.
+func (t *tokenizer) text(start, end int) string {
+	return string(t.src[start:end])
+}
+
+// position converts a rune offset to a 1-based line and column.
+func (t *tokenizer) position(offset int) (line, col int) {
+	i := sort.Search(len(t.lineStarts), func(i int) bool {
+		return t.lineStarts[i] > offset
+	}) - 1
+	return i + 1, offset - t.lineStarts[i] + 1
+}
+
+// peek returns the code point n positions ahead without consuming it,
+// or -1 past the end of input.
+func (t *tokenizer) peek(n int) rune {
+	if t.pos+n >= len(t.src) {
+		return -1
+	}
+	return t.src[t.pos+n]
+}
+
+func (t *tokenizer) consumeToken() token {
+	t.consumeComments()
+
+	start := t.pos
+	c := t.peek(0)
+	tok := token{pos: start}
+
+	switch {
+	case c == -1:
+		tok.typ = tokEOF
+		return tok
+	case isWhitespace(c):
+		for isWhitespace(t.peek(0)) {
+			t.pos++
+		}
+		tok.typ = tokWhitespace
+		return tok
+	case c == '"' || c == '\'':
+		t.pos++
+		return t.consumeString(c, start)
+	case c == '#':
+		t.pos++
+		if isIdentCodePoint(t.peek(0)) || isValidEscape(t.peek(0), t.peek(1)) {
+			tok.typ = tokHash
+			tok.value = t.consumeIdentSequence()
+			return tok
+		}
+		tok.typ, tok.value = tokDelim, "#"
+		return tok
+	case c == '(':
+		t.pos++
+		tok.typ = tokLeftParen
+		return tok
+	case c == ')':
+		t.pos++
+		tok.typ = tokRightParen
+		return tok
+	case c == '[':
+		t.pos++
+		tok.typ = tokLeftBracket
+		return tok
+	case c == ']':
+		t.pos++
+		tok.typ = tokRightBracket
+		return tok
+	case c == '{':
+		t.pos++
+		tok.typ = tokLeftBrace
+		return tok
+	case c == '}':
+		t.pos++
+		tok.typ = tokRightBrace
+		return tok
+	case c == ',':
+		t.pos++
+		tok.typ = tokComma
+		return tok
+	case c == ':':
+		t.pos++
+		tok.typ = tokColon
+		return tok
+	case c == ';':
+		t.pos++
+		tok.typ = tokSemicolon
+		return tok
+	case c == '+' || c == '.':
+		if startsNumber(c, t.peek(1), t.peek(2)) {
+			return t.consumeNumeric(start)
+		}
+		t.pos++
+		tok.typ, tok.value = tokDelim, string(c)
+		return tok
+	case c == '-':
+		if startsNumber(c, t.peek(1), t.peek(2)) {
+			return t.consumeNumeric(start)
+		}
+		if t.peek(1) == '-' && t.peek(2) == '>' {
+			t.pos += 3
+			tok.typ = tokCDC
+			return tok
+		}
+		if startsIdent(c, t.peek(1), t.peek(2)) {
+			return t.consumeIdentLike(start)
+		}
+		t.pos++
+		tok.typ, tok.value = tokDelim, "-"
+		return tok
+	case c == '<':
+		if t.peek(1) == '!' && t.peek(2) == '-' && t.peek(3) == '-' {
+			t.pos += 4
+			tok.typ = tokCDO
+			return tok
+		}
+		t.pos++
+		tok.typ, tok.value = tokDelim, "<"
+		return tok
+	case c == '@':
+		t.pos++
+		if startsIdent(t.peek(0), t.peek(1), t.peek(2)) {
+			tok.typ = tokAtKeyword
+			tok.value = t.consumeIdentSequence()
+			return tok
+		}
+		tok.typ, tok.value = tokDelim, "@"
+		return tok
+	case c == '\\':
+		if isValidEscape(c, t.peek(1)) {
+			return t.consumeIdentLike(start)
+		}
+		t.pos++
+		tok.typ, tok.value = tokDelim, "\\"
+		return tok
+	case isDigit(c):
+		return t.consumeNumeric(start)
+	case isIdentStart(c):
+		return t.consumeIdentLike(start)
+	default:
+		t.pos++
+		tok.typ, tok.value = tokDelim, string(c)
+		return tok
+	}
+}
+
+// consumeComments skips any number of /* ... */ comments.
+func (t *tokenizer) consumeComments() {
+	for t.peek(0) == '/' && t.peek(1) == '*' {
+		t.pos += 2
+		for {
+			if t.peek(0) == -1 {
+				return
+			}
+			if t.peek(0) == '*' && t.peek(1) == '/' {
+				t.pos += 2
+				break
+			}
+			t.pos++
+		}
+	}
+}
+
+// consumeString consumes a string token; the opening quote is already consumed.
+func (t *tokenizer) consumeString(quote rune, start int) token {
+	var b strings.Builder
+	for {
+		c := t.peek(0)
+		switch {
+		case c == -1:
+			return token{typ: tokString, value: b.String(), pos: start}
+		case c == quote:
+			t.pos++
+			return token{typ: tokString, value: b.String(), pos: start}
+		case c == '\n':
+			// Leave the newline to be reconsumed as whitespace.
+			return token{typ: tokBadString, pos: start}
+		case c == '\\':
+			next := t.peek(1)
+			switch {
+			case next == -1:
+				t.pos++
+			case next == '\n':
+				t.pos += 2
+			default:
+				t.pos++
+				b.WriteRune(t.consumeEscape())
+			}
+		default:
+			t.pos++
+			b.WriteRune(c)
+		}
+	}
+}
+
+// consumeNumeric consumes a number, percentage or dimension token.
+func (t *tokenizer) consumeNumeric(start int) token {
+	t.consumeNumber()
+	if startsIdent(t.peek(0), t.peek(1), t.peek(2)) {
+		unit := t.consumeIdentSequence()
+		return token{typ: tokDimension, value: unit, pos: start}
+	}
+	if t.peek(0) == '%' {
+		t.pos++
+		return token{typ: tokPercentage, pos: start}
+	}
+	return token{typ: tokNumber, pos: start}
+}
+
+// consumeNumber consumes the characters of a number. Only the extent of the
+// number matters to this parser, so its value is not computed.
+func (t *tokenizer) consumeNumber() {
+	if c := t.peek(0); c == '+' || c == '-' {
+		t.pos++
+	}
+	for isDigit(t.peek(0)) {
+		t.pos++
+	}
+	if t.peek(0) == '.' && isDigit(t.peek(1)) {
+		t.pos += 2
+		for isDigit(t.peek(0)) {
+			t.pos++
+		}
+	}
+	if c := t.peek(0); c == 'e' || c == 'E' {
+		next := t.peek(1)
+		if isDigit(next) {
+			t.pos += 2
+		} else if (next == '+' || next == '-') && isDigit(t.peek(2)) {
+			t.pos += 3
+		} else {
+			return
+		}
+		for isDigit(t.peek(0)) {
+			t.pos++
+		}
+	}
+}
+
+// consumeIdentLike consumes an ident, function or url token.
+func (t *tokenizer) consumeIdentLike(start int) token {
+	name := t.consumeIdentSequence()
+	if t.peek(0) != '(' {
+		return token{typ: tokIdent, value: name, pos: start}
+	}
+	t.pos++
+	if strings.EqualFold(name, "url") {
+		for isWhitespace(t.peek(0)) && isWhitespace(t.peek(1)) {
+			t.pos++
+		}
+		next := t.peek(0)
+		if isWhitespace(next) {
+			next = t.peek(1)
+		}
+		if next != '"' && next != '\'' {
+			return t.consumeURL(start)
+		}
+	}
+	return token{typ: tokFunction, value: name, pos: start}
+}
+
+// consumeURL consumes an unquoted url token; "url(" is already consumed.
+func (t *tokenizer) consumeURL(start int) token {
+	var b strings.Builder
+	for isWhitespace(t.peek(0)) {
+		t.pos++
+	}
+	for {
+		c := t.peek(0)
+		switch {
+		case c == ')':
+			t.pos++
+			return token{typ: tokURL, value: b.String(), pos: start}
+		case c == -1:
+			return token{typ: tokURL, value: b.String(), pos: start}
+		case isWhitespace(c):
+			for isWhitespace(t.peek(0)) {
+				t.pos++
+			}
+			if t.peek(0) == ')' || t.peek(0) == -1 {
+				continue
+			}
+			t.consumeBadURLRemnants()
+			return token{typ: tokBadURL, pos: start}
+		case c == '"' || c == '\'' || c == '(' || isNonPrintable(c):
+			t.consumeBadURLRemnants()
+			return token{typ: tokBadURL, pos: start}
+		case c == '\\':
+			if !isValidEscape(c, t.peek(1)) {
+				t.consumeBadURLRemnants()
+				return token{typ: tokBadURL, pos: start}
+			}
+			t.pos++
+			b.WriteRune(t.consumeEscape())
+		default:
+			t.pos++
+			b.WriteRune(c)
+		}
+	}
+}
+
+// consumeBadURLRemnants skips the rest of a malformed url up to its ')'.
+func (t *tokenizer) consumeBadURLRemnants() {
+	for {
+		c := t.peek(0)
+		switch {
+		case c == -1:
+			return
+		case c == ')':
+			t.pos++
+			return
+		case isValidEscape(c, t.peek(1)):
+			t.pos++
+			t.consumeEscape()
+		default:
+			t.pos++
+		}
+	}
+}
+
+// consumeIdentSequence consumes an ident sequence, resolving escapes.
+func (t *tokenizer) consumeIdentSequence() string {
+	var b strings.Builder
+	for {
+		c := t.peek(0)
+		switch {
+		case isIdentCodePoint(c):
+			t.pos++
+			b.WriteRune(c)
+		case isValidEscape(c, t.peek(1)):
+			t.pos++
+			b.WriteRune(t.consumeEscape())
+		default:
+			return b.String()
+		}
+	}
+}
+
+// consumeEscape consumes an escaped code point; the backslash is already consumed.
+func (t *tokenizer) consumeEscape() rune {
+	c := t.peek(0)
+	if c == -1 {
+		return utf8.RuneError
+	}
+	t.pos++
+	if !isHexDigit(c) {
+		return c
+	}
+	value := hexValue(c)
+	for i := 1; i < 6 && isHexDigit(t.peek(0)); i++ {
+		value = value*16 + hexValue(t.peek(0))
+		t.pos++
+	}
+	if isWhitespace(t.peek(0)) {
+		t.pos++
+	}
+	if value == 0 || (value >= 0xD800 && value <= 0xDFFF) || value > utf8.MaxRune {
+		return utf8.RuneError
+	}
+	return value
+}
+
+func isWhitespace(c rune) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
+
+func isDigit(c rune) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c rune) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func hexValue(c rune) rune {
+	switch {
+	case c >= 'a':
+		return c - 'a' + 10
+	case c >= 'A':
+		return c - 'A' + 10
+	default:
+		return c - '0'
+	}
+}
+
+func isIdentStart(c rune) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' || c >= 0x80
+}
+
+func isIdentCodePoint(c rune) bool {
+	return isIdentStart(c) || isDigit(c) || c == '-'
+}
+
+func isNonPrintable(c rune) bool {
+	return (c >= 0 && c <= 0x08) || c == 0x0B || (c >= 0x0E && c <= 0x1F) || c == 0x7F
+}
+
+// isValidEscape reports whether two code points start a valid escape.
+func isValidEscape(first, second rune) bool {
+	return first == '\\' && second != '\n' && second != -1
+}
+
+// startsIdent reports whether three code points would start an ident sequence.
+func startsIdent(first, second, third rune) bool {
+	switch {
+	case first == '-':
+		return isIdentStart(second) || second == '-' || isValidEscape(second, third)
+	case first == '\\':
+		return isValidEscape(first, second)
+	default:
+		return isIdentStart(first)
+	}
+}
+
+// startsNumber reports whether three code points would start a number.
+func startsNumber(first, second, third rune) bool {
+	switch {
+	case first == '+' || first == '-':
+		return isDigit(second) || (second == '.' && isDigit(third))
+	case first == '.':
+		return isDigit(second)
+	default:
+		return isDigit(first)
+	}
+}
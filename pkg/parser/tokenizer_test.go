@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestTokenizerIdentEscapes(t *testing.T) {
+	tests := []struct {
+		name     string
+		css      string
+		expected string
+	}{
+		{name: "plain", css: "text-gray-500", expected: "text-gray-500"},
+		{name: "escaped colon", css: `md\:flex`, expected: "md:flex"},
+		{name: "hex escape with space", css: `\31 0`, expected: "10"},
+		{name: "hex escape max length", css: `\000023x`, expected: "#x"},
+		{name: "invalid code point", css: `a\0`, expected: "a�"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tok := newTokenizer(tt.css).consumeToken()
+			if tok.typ != tokIdent {
+				t.Fatalf("token type = %d, want ident", tok.typ)
+			}
+			if tok.value != tt.expected {
+				t.Errorf("value = %q, want %q", tok.value, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTokenizerTokenTypes(t *testing.T) {
+	tokens := newTokenizer(`@media .5 10% 2px url(a.png) "s" #id rgb( /* c */ }`).tokenize()
+
+	var types []tokenType
+	for _, tok := range tokens {
+		if tok.typ != tokWhitespace {
+			types = append(types, tok.typ)
+		}
+	}
+	expected := []tokenType{
+		tokAtKeyword, tokNumber, tokPercentage, tokDimension, tokURL,
+		tokString, tokHash, tokFunction, tokRightBrace, tokEOF,
+	}
+	if len(types) != len(expected) {
+		t.Fatalf("got %d tokens %v, want %d", len(types), types, len(expected))
+	}
+	for i := range expected {
+		if types[i] != expected[i] {
+			t.Errorf("token %d type = %d, want %d", i, types[i], expected[i])
+		}
+	}
+}
+
+func TestTokenizerPosition(t *testing.T) {
+	tz := newTokenizer("a {}\r\n  .b {}")
+	tokens := tz.tokenize()
+
+	for _, tok := range tokens {
+		if tok.typ == tokIdent && tok.value == "b" {
+			line, col := tz.position(tok.pos)
+			if line != 2 || col != 4 {
+				t.Errorf("position = %d:%d, want 2:4", line, col)
+			}
+			return
+		}
+	}
+	t.Fatal("ident b not found")
+}
+
+func TestUnescapeIdent(t *testing.T) {
+	tests := []struct {
+		escaped  string
+		expected string
+	}{
+		{escaped: `md\:flex`, expected: "md:flex"},
+		{escaped: `\!mt-0`, expected: "!mt-0"},
+		{escaped: `bg-\[\#1da1f2\]`, expected: "bg-[#1da1f2]"},
+		{escaped: `bg-\[\23 1da1f2\]`, expected: "bg-[#1da1f2]"},
+		{escaped: `w-\[calc\(100\%-2rem\)\]`, expected: "w-[calc(100%-2rem)]"},
+		{escaped: `\[mask-type\:luminance\]`, expected: "[mask-type:luminance]"},
+		{escaped: `\32 xl\:grid-cols-3`, expected: "2xl:grid-cols-3"},
+		{escaped: `space-x-\+`, expected: "space-x-+"},
+		{escaped: `\31 0`, expected: "10"},
+		{escaped: `a\110000`, expected: "a\uFFFD"},
+		{escaped: `plain`, expected: "plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.escaped, func(t *testing.T) {
+			if got := UnescapeIdent(tt.escaped); got != tt.expected {
+				t.Errorf("UnescapeIdent(%q) = %q, want %q", tt.escaped, got, tt.expected)
+			}
+		})
+	}
+}
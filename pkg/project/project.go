@@ -0,0 +1,155 @@
+// Package project loads the cssguard.yaml project config, which supplies
+// inputs and settings shared by every command so CI jobs need not repeat
+// them as flags.
+package project
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/JCorners68/cssguard/pkg/report"
+	"github.com/JCorners68/cssguard/pkg/trainer"
+)
+
+// FileNames are the project config names looked for, in order.
+var FileNames = []string{"cssguard.yaml", "cssguard.yml"}
+
+// Config is a project config. Paths are relative to the directory of the
+// file; Load rewrites them relative to the working directory.
+type Config struct {
+	HTML                string            `yaml:"html,omitempty"`                 // HTML directory to scan
+	CSS                 []string          `yaml:"css,omitempty"`                  // CSS files and directories
+	Src                 []string          `yaml:"src,omitempty"`                  // Source paths to scan for class tokens
+	SrcExt              []string          `yaml:"src_ext,omitempty"`              // Source file extensions
+	SrcExclude          []string          `yaml:"src_exclude,omitempty"`          // Directories to skip when scanning sources
+	Trained             string            `yaml:"trained,omitempty"`              // Trained config written by 'cssguard train'
+	Baseline            string            `yaml:"baseline,omitempty"`             // Baseline of accepted orphans
+	Ignore              []string          `yaml:"ignore,omitempty"`               // Class globs that are never orphans
+	IgnoreRules         []trainer.Rule    `yaml:"ignore_rules,omitempty"`         // Ignore rules with a reason or expiry
+	Safelist            []trainer.Rule    `yaml:"safelist,omitempty"`             // CSS classes that may be unused
+	Severity            map[string]string `yaml:"severity,omitempty"`             // Rule -> error, warning, note or off
+	RedundancyThreshold float64           `yaml:"redundancy_threshold,omitempty"` // Coverage (%) at which a CSS file is redundant
+	SuggestThreshold    *float64          `yaml:"suggest_threshold,omitempty"`    // Similarity for "did you mean" suggestions; 0 disables
+	Jobs                int               `yaml:"jobs,omitempty"`                 // Files to parse in parallel
+	CacheDir            string            `yaml:"cache_dir,omitempty"`            // Directory caching extracted classes between runs
+	Output              Output            `yaml:"output,omitempty"`
+
+	Path string `yaml:"-"` // File the config was loaded from
+}
+
+// Output holds the output settings.
+type Output struct {
+	Format  string `yaml:"format,omitempty"` // text, json, sarif, html, junit, github, gitlab or markdown
+	Verbose bool   `yaml:"verbose,omitempty"`
+	Unused  bool   `yaml:"unused,omitempty"` // Report unused CSS classes
+}
+
+// Find looks for a project config in dir and its parents and returns its
+// path, or "" if there is none.
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		for _, name := range FileNames {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, nil
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Discover finds and loads the project config for the working directory.
+// It returns nil and no error if there is none.
+func Discover() (*Config, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	path, err := Find(cwd)
+	if err != nil || path == "" {
+		return nil, err
+	}
+	if rel, err := filepath.Rel(cwd, path); err == nil {
+		path = rel
+	}
+	return Load(path)
+}
+
+// Load reads a project config. Unknown keys and invalid severities are
+// errors, and relative paths are resolved against the file's directory.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Config
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if _, err := c.SeverityLevels(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	c.Path = path
+	c.resolvePaths(filepath.Dir(path))
+	return &c, nil
+}
+
+// SeverityLevels validates and returns the configured rule severities.
+func (c *Config) SeverityLevels() (report.Severity, error) {
+	s := make(report.Severity, len(c.Severity))
+	for rule, level := range c.Severity {
+		if err := s.Set(rule, level); err != nil {
+			return nil, fmt.Errorf("severity: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// resolvePaths makes relative paths relative to the working directory
+// instead of dir.
+func (c *Config) resolvePaths(dir string) {
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+	c.HTML = resolve(c.HTML)
+	c.Trained = resolve(c.Trained)
+	c.Baseline = resolve(c.Baseline)
+	c.CacheDir = resolve(c.CacheDir)
+	for i, p := range c.CSS {
+		c.CSS[i] = resolve(p)
+	}
+	for i, p := range c.Src {
+		c.Src[i] = resolve(p)
+	}
+}
+
+// Encode writes the config as YAML.
+func (c *Config) Encode(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return err
+	}
+	return enc.Close()
+}
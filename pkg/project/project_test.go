@@ -0,0 +1,124 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "site", "pages")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := Find(nested)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "" {
+		t.Errorf("Find() = %q with no project config, want \"\"", path)
+	}
+
+	want := filepath.Join(root, "cssguard.yml")
+	if err := os.WriteFile(want, []byte("html: public\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path, err = Find(nested)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != want {
+		t.Errorf("Find() = %q, want %q", path, want)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cssguard.yaml")
+	yaml := `html: public
+css:
+  - public/css/main.css
+  - /abs/vendor.css
+src: [src]
+src_ext: [.tsx, .vue]
+src_exclude: [node_modules]
+trained: cssguard.json
+ignore: [js-toggle]
+severity:
+  orphan: warning
+  unused: off
+redundancy_threshold: 90
+output:
+  format: sarif
+  verbose: true
+`
+	if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.HTML != filepath.Join(dir, "public") {
+		t.Errorf("HTML = %q, want it resolved against the config directory", c.HTML)
+	}
+	if len(c.CSS) != 2 || c.CSS[0] != filepath.Join(dir, "public/css/main.css") || c.CSS[1] != "/abs/vendor.css" {
+		t.Errorf("CSS = %v", c.CSS)
+	}
+	if c.Trained != filepath.Join(dir, "cssguard.json") {
+		t.Errorf("Trained = %q", c.Trained)
+	}
+	if len(c.SrcExclude) != 1 || c.SrcExclude[0] != "node_modules" {
+		t.Errorf("SrcExclude = %v, want names left as is", c.SrcExclude)
+	}
+	if c.RedundancyThreshold != 90 || c.Output.Format != "sarif" || !c.Output.Verbose {
+		t.Errorf("config = %+v", c)
+	}
+	levels, err := c.SeverityLevels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if levels.Level("orphan") != "warning" || levels.Level("unused") != "off" || levels.Level("redundant") != "warning" {
+		t.Errorf("SeverityLevels() = %v", levels)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{name: "unknown key", yaml: "htm: public\n", want: "htm"},
+		{name: "unknown rule", yaml: "severity:\n  orphans: error\n", want: "unknown rule"},
+		{name: "unknown level", yaml: "severity:\n  orphan: fatal\n", want: "unknown level"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cssguard.yaml")
+			if err := os.WriteFile(path, []byte(tt.yaml), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := Load(path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cssguard.yaml")
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err != nil {
+		t.Errorf("Load() of an empty file: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/JCorners68/cssguard/pkg/validator"
+)
+
+// annotation is a finding at one place, for formats that annotate lines.
+type annotation struct {
+	Rule     string
+	Level    string
+	Class    string // Class the finding is about; empty for stylesheets
+	Message  string
+	Location validator.Location // File is empty if the finding has no location
+}
+
+// annotations lists the findings of result, one per location: orphans,
+// print-only classes and context orphans where they are used and, as
+// selected by opts, unused classes where they are defined and redundant
+// stylesheets. Orphans accepted by a baseline are notes. Rules set to
+// LevelOff and orphans silenced by inline comments are left out.
+func annotations(result *validator.Result, opts Options) []annotation {
+	var list []annotation
+	add := func(rule, level, class, msg string, locs []validator.Location) {
+		if len(locs) == 0 {
+			locs = []validator.Location{{}}
+		}
+		for _, loc := range locs {
+			list = append(list, annotation{Rule: rule, Level: level, Class: class, Message: msg, Location: loc})
+		}
+	}
+	enabled := func(rule string) bool {
+		return opts.Severity.Level(rule) != LevelOff
+	}
+
+	if enabled(RuleOrphan) {
+		for _, class := range result.Orphans {
+			level := opts.Severity.Level(RuleOrphan)
+			msg := "Orphan class " + class
+			if issue, ok := result.VariantIssues[class]; ok {
+				msg += fmt.Sprintf(" (%s)", issue.Reason())
+			}
+			if result.IsKnownOrphan(class) {
+				level = LevelNote
+				msg += " [baseline]"
+			}
+			if s := result.Suggestions[class]; len(s) > 0 {
+				msg += ". " + DidYouMean(s)
+			}
+			add(RuleOrphan, level, class, msg, result.OrphanLocations[class])
+		}
+	}
+	if enabled(RulePrintOnly) {
+		for _, class := range result.PrintOnlyClasses() {
+			msg := fmt.Sprintf("Class %s is only defined for print (%s)", class, strings.Join(result.Contexts[class], ", "))
+			add(RulePrintOnly, opts.Severity.Level(RulePrintOnly), class, msg, result.PrintOnly[class])
+		}
+	}
+	if enabled(RuleContextOrphan) {
+		for _, class := range result.ContextOrphanClasses() {
+			orphan := result.ContextOrphans[class]
+			msg := fmt.Sprintf("Class %s is used where no rule defining it matches (%s)", class, strings.Join(orphan.Selectors, ", "))
+			add(RuleContextOrphan, opts.Severity.Level(RuleContextOrphan), class, msg, orphan.Locations)
+		}
+	}
+	if opts.Unused && enabled(RuleUnused) {
+		for _, class := range result.Unused {
+			add(RuleUnused, opts.Severity.Level(RuleUnused), class, "Unused class "+class, result.UnusedLocations[class])
+		}
+	}
+	if enabled(RuleRedundant) {
+		for _, r := range opts.Redundant {
+			msg := fmt.Sprintf("Stylesheet %s is %.1f%% covered by %s", r.File, r.Coverage, r.CoveredBy)
+			add(RuleRedundant, opts.Severity.Level(RuleRedundant), "", msg, []validator.Location{{File: r.File}})
+		}
+	}
+	return list
+}
@@ -0,0 +1,57 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/JCorners68/cssguard/pkg/validator"
+)
+
+// WriteGitHub writes result as GitHub Actions workflow commands, such as
+// "::error file=public/index.html,line=12,col=3,title=cssguard orphan::Orphan class translate-x-0",
+// one per place a finding occurs, so the findings show up inline on the pull
+// request diff. Error findings become ::error, warnings ::warning and notes
+// ::notice; see annotations for what is included.
+func WriteGitHub(w io.Writer, result *validator.Result, opts Options) error {
+	for _, a := range annotations(result, opts) {
+		props := []string{}
+		if a.Location.File != "" {
+			props = append(props, "file="+githubProperty(filepath.ToSlash(a.Location.File)))
+			if a.Location.Line > 0 {
+				props = append(props, fmt.Sprintf("line=%d", a.Location.Line))
+				if a.Location.Column > 0 {
+					props = append(props, fmt.Sprintf("col=%d", a.Location.Column))
+				}
+			}
+		}
+		props = append(props, "title="+githubProperty("cssguard "+a.Rule))
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n", githubCommand(a.Level), strings.Join(props, ","), githubData(a.Message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// githubCommand maps a severity level to a workflow command.
+func githubCommand(level string) string {
+	switch level {
+	case LevelError:
+		return "error"
+	case LevelWarning:
+		return "warning"
+	default:
+		return "notice"
+	}
+}
+
+// githubData escapes the message of a workflow command.
+func githubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// githubProperty escapes a property value of a workflow command.
+func githubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
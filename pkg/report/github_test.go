@@ -0,0 +1,40 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/JCorners68/cssguard/pkg/validator"
+)
+
+func TestWriteGitHub(t *testing.T) {
+	result := &validator.Result{
+		Orphans: []string{"legacy", "translate-x-0", "w-1/2"},
+		OrphanLocations: map[string][]validator.Location{
+			"translate-x-0": {
+				{File: "public/index.html", Line: 12, Column: 3, Tag: "aside"},
+				{File: "public/a,b.html", Line: 4, Column: 1, Tag: "div"},
+			},
+			"legacy": {{File: "public/old.html", Line: 2, Column: 1}},
+		},
+		Suggestions: map[string][]validator.Suggestion{"translate-x-0": {{Class: "translate-x-1"}}},
+		Baseline:    &validator.BaselineStatus{New: []string{"translate-x-0", "w-1/2"}, Known: []string{"legacy"}},
+	}
+	opts := Options{Redundant: []Redundancy{{File: "vendor.css", CoveredBy: "main.css", Coverage: 91.5}}}
+
+	var buf bytes.Buffer
+	if err := WriteGitHub(&buf, result, opts); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"::notice file=public/old.html,line=2,col=1,title=cssguard orphan::Orphan class legacy [baseline]",
+		"::error file=public/index.html,line=12,col=3,title=cssguard orphan::Orphan class translate-x-0. Did you mean translate-x-1?",
+		"::error file=public/a%2Cb.html,line=4,col=1,title=cssguard orphan::Orphan class translate-x-0. Did you mean translate-x-1?",
+		"::error title=cssguard orphan::Orphan class w-1/2",
+		"::warning file=vendor.css,title=cssguard redundant::Stylesheet vendor.css is 91.5%25 covered by main.css",
+	}
+	if got := strings.Split(strings.TrimSpace(buf.String()), "\n"); strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+}
@@ -0,0 +1,77 @@
+package report
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/JCorners68/cssguard/pkg/validator"
+)
+
+// GitLab Code Quality report object model.
+type (
+	gitlabIssue struct {
+		Description string         `json:"description"`
+		CheckName   string         `json:"check_name"`
+		Fingerprint string         `json:"fingerprint"`
+		Severity    string         `json:"severity"`
+		Location    gitlabLocation `json:"location"`
+	}
+	gitlabLocation struct {
+		Path  string      `json:"path"`
+		Lines gitlabLines `json:"lines"`
+	}
+	gitlabLines struct {
+		Begin int `json:"begin"`
+	}
+)
+
+// WriteGitLab writes result as a GitLab Code Quality report, a JSON array
+// with an issue per place a finding occurs, so merge requests show the
+// findings inline. Error findings are "major" issues, warnings "minor" and
+// notes "info"; findings without a location cannot be shown and are left
+// out. The fingerprint hashes the rule, class and path, and which use of
+// the class in the file it is, so an issue keeps its identity when lines
+// move or its message changes. See annotations for what is included.
+func WriteGitLab(w io.Writer, result *validator.Result, opts Options) error {
+	issues := []gitlabIssue{} // Code Quality wants an array, even if empty
+	uses := make(map[string]int)
+	for _, a := range annotations(result, opts) {
+		if a.Location.File == "" {
+			continue
+		}
+		line := a.Location.Line
+		if line < 1 {
+			line = 1
+		}
+		path := filepath.ToSlash(a.Location.File)
+		key := a.Rule + "\x00" + a.Class + "\x00" + path
+		sum := md5.Sum([]byte(fmt.Sprintf("%s\x00%d", key, uses[key])))
+		uses[key]++
+		issues = append(issues, gitlabIssue{
+			Description: a.Message,
+			CheckName:   a.Rule,
+			Fingerprint: hex.EncodeToString(sum[:]),
+			Severity:    gitlabSeverity(a.Level),
+			Location:    gitlabLocation{Path: path, Lines: gitlabLines{Begin: line}},
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(issues)
+}
+
+// gitlabSeverity maps a severity level to a Code Quality severity.
+func gitlabSeverity(level string) string {
+	switch level {
+	case LevelError:
+		return "major"
+	case LevelWarning:
+		return "minor"
+	default:
+		return "info"
+	}
+}
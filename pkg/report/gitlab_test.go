@@ -0,0 +1,79 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/JCorners68/cssguard/pkg/validator"
+)
+
+func TestWriteGitLab(t *testing.T) {
+	result := &validator.Result{
+		Orphans: []string{"translate-x-0", "w-1/2"},
+		OrphanLocations: map[string][]validator.Location{
+			"translate-x-0": {
+				{File: "public/index.html", Line: 12, Column: 3, Tag: "aside"},
+				{File: "public/index.html", Line: 20, Column: 3, Tag: "aside"},
+			},
+		},
+		Contexts: map[string][]string{"receipt": {"@media print"}},
+		PrintOnly: map[string][]validator.Location{
+			"receipt": {{File: "public/order.html", Line: 7, Column: 5, Tag: "p"}},
+		},
+	}
+	opts := Options{Redundant: []Redundancy{{File: "vendor.css", CoveredBy: "main.css", Coverage: 91.5}}}
+
+	var buf bytes.Buffer
+	if err := WriteGitLab(&buf, result, opts); err != nil {
+		t.Fatal(err)
+	}
+	var issues []gitlabIssue
+	if err := json.Unmarshal(buf.Bytes(), &issues); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	// w-1/2 has no location, so GitLab cannot show it
+	if len(issues) != 4 {
+		t.Fatalf("got %d issues: %+v", len(issues), issues)
+	}
+	if i := issues[0]; i.CheckName != RuleOrphan || i.Severity != "major" || i.Description != "Orphan class translate-x-0" ||
+		i.Location.Path != "public/index.html" || i.Location.Lines.Begin != 12 {
+		t.Errorf("orphan issue = %+v", i)
+	}
+	if issues[0].Fingerprint == issues[1].Fingerprint {
+		t.Error("uses of the same orphan share a fingerprint")
+	}
+	if i := issues[2]; i.CheckName != RulePrintOnly || i.Severity != "minor" {
+		t.Errorf("print-only issue = %+v", i)
+	}
+	if i := issues[3]; i.CheckName != RuleRedundant || i.Location.Path != "vendor.css" || i.Location.Lines.Begin != 1 {
+		t.Errorf("redundant issue = %+v", i)
+	}
+
+	// Fingerprints survive moved lines and changed messages
+	result.OrphanLocations["translate-x-0"][0].Line = 14
+	result.Suggestions = map[string][]validator.Suggestion{"translate-x-0": {{Class: "translate-x-1"}}}
+	buf.Reset()
+	if err := WriteGitLab(&buf, result, opts); err != nil {
+		t.Fatal(err)
+	}
+	var moved []gitlabIssue
+	if err := json.Unmarshal(buf.Bytes(), &moved); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	for i := range issues {
+		if moved[i].Fingerprint != issues[i].Fingerprint {
+			t.Errorf("issue %d fingerprint changed: %+v", i, moved[i])
+		}
+	}
+
+	// An empty report is still an array
+	buf.Reset()
+	if err := WriteGitLab(&buf, &validator.Result{}, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "[]\n" {
+		t.Errorf("empty report = %q", got)
+	}
+}
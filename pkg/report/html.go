@@ -0,0 +1,454 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/JCorners68/cssguard/pkg/validator"
+)
+
+// htmlReport is the data the HTML report template renders.
+type htmlReport struct {
+	Version        string
+	Cards          []htmlCard
+	Orphans        []htmlOrphan
+	PrintOnly      []htmlFinding
+	ContextOrphans []htmlFinding
+	Suppressed     []htmlFinding
+	Unused         []htmlStylesheet
+	Redundant      []Redundancy
+	Pairs          []htmlPair
+	Site           *validator.SiteReport
+}
+
+// htmlCard is a number on the summary bar.
+type htmlCard struct {
+	Label string
+	Value string
+	Alert bool // Highlight the card
+}
+
+type htmlOrphan struct {
+	Class      string
+	Reason     string // What is missing for variant-prefixed classes
+	Suggestion string
+	Baseline   string // "new" or "known" when compared with a baseline
+	Files      int    // Distinct files using the class
+	Locations  []validator.Location
+}
+
+// htmlFinding is a class reported with a detail, such as the at-rules or
+// selectors defining it, and where it is used.
+type htmlFinding struct {
+	Class     string
+	Detail    string
+	Locations []validator.Location
+}
+
+// htmlPair is a pair of CSS files with the shares of their combined classes
+// that only the first defines, both define and only the second defines.
+type htmlPair struct {
+	FilePair
+	File1OnlyPercent, OverlapPercent, File2OnlyPercent float64
+}
+
+// htmlStylesheet lists the unused classes a CSS file defines.
+type htmlStylesheet struct {
+	File    string
+	Classes []htmlFinding
+}
+
+// WriteHTML writes result as a self-contained HTML page: summary cards, a
+// sortable orphan table with locations and suggestions, print-only and
+// context orphan classes and, as selected by opts, the unused classes
+// grouped by CSS file, redundant CSS files, the overlap of each pair of CSS
+// files, most overlapping first, and the per-page breakdown. The
+// page loads no external assets, so it can be kept as a CI artifact. Rules
+// set to LevelOff are left out.
+func WriteHTML(w io.Writer, result *validator.Result, opts Options) error {
+	enabled := func(rule string) bool {
+		return opts.Severity.Level(rule) != LevelOff
+	}
+	data := htmlReport{
+		Version: opts.ToolVersion,
+		Cards: []htmlCard{
+			{Label: "HTML classes", Value: fmt.Sprint(result.HTMLClasses)},
+			{Label: "CSS classes", Value: fmt.Sprint(result.CSSClasses)},
+			{Label: "Matched", Value: fmt.Sprint(result.Matched)},
+			{Label: "Coverage", Value: fmt.Sprintf("%.1f%%", result.CoveragePercent)},
+			{Label: "Orphans", Value: fmt.Sprint(result.OrphanCount), Alert: result.OrphanCount > 0},
+		},
+	}
+	if result.Baseline != nil {
+		data.Cards = append(data.Cards,
+			htmlCard{Label: "New orphans", Value: fmt.Sprint(len(result.Baseline.New)), Alert: len(result.Baseline.New) > 0},
+			htmlCard{Label: "Known orphans", Value: fmt.Sprint(len(result.Baseline.Known))},
+		)
+	}
+
+	if enabled(RuleOrphan) {
+		for _, class := range result.Orphans {
+			o := htmlOrphan{Class: class, Locations: result.OrphanLocations[class]}
+			if issue, ok := result.VariantIssues[class]; ok {
+				o.Reason = issue.Reason()
+			}
+			if s := result.Suggestions[class]; len(s) > 0 {
+				o.Suggestion = DidYouMean(s)
+			}
+			if result.Baseline != nil {
+				o.Baseline = "new"
+				if result.IsKnownOrphan(class) {
+					o.Baseline = "known"
+				}
+			}
+			files := make(map[string]struct{})
+			for _, loc := range o.Locations {
+				files[loc.File] = struct{}{}
+			}
+			o.Files = len(files)
+			data.Orphans = append(data.Orphans, o)
+		}
+		for _, class := range sortedKeys(result.Suppressed) {
+			data.Suppressed = append(data.Suppressed, htmlFinding{Class: class, Locations: result.Suppressed[class]})
+		}
+	}
+
+	if enabled(RulePrintOnly) {
+		for _, class := range result.PrintOnlyClasses() {
+			data.PrintOnly = append(data.PrintOnly, htmlFinding{
+				Class:     class,
+				Detail:    strings.Join(result.Contexts[class], ", "),
+				Locations: result.PrintOnly[class],
+			})
+		}
+	}
+
+	if enabled(RuleContextOrphan) {
+		for _, class := range result.ContextOrphanClasses() {
+			orphan := result.ContextOrphans[class]
+			data.ContextOrphans = append(data.ContextOrphans, htmlFinding{
+				Class:     class,
+				Detail:    strings.Join(orphan.Selectors, ", "),
+				Locations: orphan.Locations,
+			})
+		}
+	}
+
+	if opts.Unused && enabled(RuleUnused) {
+		data.Cards = append(data.Cards, htmlCard{Label: "Unused", Value: fmt.Sprint(result.UnusedCount)})
+		data.Unused = unusedByFile(result)
+	}
+	if enabled(RuleRedundant) {
+		data.Redundant = opts.Redundant
+		data.Pairs = htmlPairs(opts.Pairs)
+	}
+	if opts.Site != nil && len(opts.Site.Pages) > 0 {
+		data.Site = opts.Site
+	}
+
+	return htmlTemplate.Execute(w, data)
+}
+
+// unusedByFile groups the unused classes by the CSS files defining them; a
+// class defined in several files is listed under each. Classes without a
+// known definition are grouped under "(unknown)".
+func unusedByFile(result *validator.Result) []htmlStylesheet {
+	byFile := make(map[string][]htmlFinding)
+	for _, class := range result.Unused {
+		locs := result.UnusedLocations[class]
+		if len(locs) == 0 {
+			byFile["(unknown)"] = append(byFile["(unknown)"], htmlFinding{Class: class})
+			continue
+		}
+		seen := make(map[string]struct{})
+		for _, loc := range locs {
+			if _, ok := seen[loc.File]; ok {
+				continue
+			}
+			seen[loc.File] = struct{}{}
+			byFile[loc.File] = append(byFile[loc.File], htmlFinding{Class: class, Locations: []validator.Location{loc}})
+		}
+	}
+	sheets := make([]htmlStylesheet, 0, len(byFile))
+	for _, file := range sortedKeys(byFile) {
+		sheets = append(sheets, htmlStylesheet{File: file, Classes: byFile[file]})
+	}
+	return sheets
+}
+
+// htmlPairs computes the chart shares of pairs and sorts them by coverage,
+// highest first.
+func htmlPairs(pairs []FilePair) []htmlPair {
+	list := make([]htmlPair, len(pairs))
+	for i, p := range pairs {
+		list[i] = htmlPair{FilePair: p}
+		if total := p.File1Only + p.Overlap + p.File2Only; total > 0 {
+			list[i].File1OnlyPercent = float64(p.File1Only) / float64(total) * 100
+			list[i].OverlapPercent = float64(p.Overlap) / float64(total) * 100
+			list[i].File2OnlyPercent = float64(p.File2Only) / float64(total) * 100
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Coverage > list[j].Coverage
+	})
+	return list
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"base":    filepath.Base,
+	"percent": func(f float64) string { return fmt.Sprintf("%.1f", f) },
+}).Parse(htmlSource))
+
+const htmlSource = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>cssguard report</title>
+<style>
+body { font: 14px/1.5 system-ui, sans-serif; margin: 0 auto; max-width: 1200px; padding: 1.5rem; color: #1f2328; }
+h1 { font-size: 1.5rem; margin: 0 0 1rem; }
+h2 { font-size: 1.15rem; margin: 2rem 0 .5rem; border-bottom: 1px solid #d0d7de; padding-bottom: .25rem; }
+h3 { font-size: 1rem; margin: 1rem 0 .25rem; }
+code, td.mono { font-family: ui-monospace, monospace; font-size: 13px; }
+.cards { display: flex; flex-wrap: wrap; gap: .75rem; }
+.card { border: 1px solid #d0d7de; border-radius: 6px; padding: .5rem 1rem; min-width: 8rem; }
+.card .value { font-size: 1.5rem; font-weight: 600; }
+.card .label { color: #656d76; }
+.card.alert { border-color: #cf222e; }
+.card.alert .value { color: #cf222e; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: .3rem .6rem; border-bottom: 1px solid #eaeef2; vertical-align: top; }
+th[data-sort] { cursor: pointer; user-select: none; white-space: nowrap; }
+th[data-sort]::after { content: " \2195"; color: #8c959f; }
+td.num, th.num { text-align: right; }
+details summary { cursor: pointer; }
+ul.locations { margin: .25rem 0; padding-left: 1.25rem; }
+.muted { color: #656d76; }
+.badge { border-radius: 1em; padding: 0 .5em; font-size: 12px; }
+.badge.new { background: #ffebe9; color: #cf222e; }
+.badge.known { background: #eaeef2; color: #656d76; }
+.bar { background: #eaeef2; border-radius: 3px; height: .8rem; width: 12rem; display: inline-block; vertical-align: middle; }
+.bar span { background: #bf8700; border-radius: 3px; display: block; height: 100%; }
+.overlap { display: flex; height: .8rem; width: 16rem; border-radius: 3px; overflow: hidden; background: #eaeef2; }
+.overlap span { display: block; height: 100%; }
+.overlap .first { background: #0969da; }
+.overlap .both { background: #bf8700; }
+.overlap .second { background: #8c959f; }
+input[type=search] { padding: .3rem .5rem; margin-bottom: .5rem; width: 20rem; max-width: 100%; }
+</style>
+</head>
+<body>
+<h1>cssguard report</h1>
+
+<div class="cards">
+{{- range .Cards}}
+<div class="card{{if .Alert}} alert{{end}}"><div class="value">{{.Value}}</div><div class="label">{{.Label}}</div></div>
+{{- end}}
+</div>
+
+{{- if .Orphans}}
+<h2>Orphan classes ({{len .Orphans}})</h2>
+<p class="muted">Classes used in HTML that no CSS defines. Click a heading to sort.</p>
+<input type="search" placeholder="Filter classes" data-filter="orphans">
+<table class="sortable" id="orphans">
+<thead><tr><th data-sort>Class</th><th data-sort="number" class="num">Uses</th><th data-sort="number" class="num">Files</th>{{if (index .Orphans 0).Baseline}}<th data-sort>Baseline</th>{{end}}<th>Details</th></tr></thead>
+<tbody>
+{{- range .Orphans}}
+<tr>
+<td class="mono">{{.Class}}</td>
+<td class="num">{{len .Locations}}</td>
+<td class="num">{{.Files}}</td>
+{{- if .Baseline}}<td><span class="badge {{.Baseline}}">{{.Baseline}}</span></td>{{end}}
+<td>
+{{- if .Reason}}<div>{{.Reason}}</div>{{end}}
+{{- if .Suggestion}}<div>{{.Suggestion}}</div>{{end}}
+{{- template "locations" .Locations}}
+</td>
+</tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+
+{{- if .PrintOnly}}
+<h2>Print-only classes ({{len .PrintOnly}})</h2>
+<p class="muted">Used, but defined only for print.</p>
+{{template "findings" .PrintOnly}}
+{{- end}}
+
+{{- if .ContextOrphans}}
+<h2>Context orphans ({{len .ContextOrphans}})</h2>
+<p class="muted">Used where no selector defining them matches.</p>
+{{template "findings" .ContextOrphans}}
+{{- end}}
+
+{{- if .Unused}}
+<h2>Unused classes</h2>
+<p class="muted">Defined in CSS, never used in HTML.</p>
+{{- range .Unused}}
+<details>
+<summary><code>{{.File}}</code> ({{len .Classes}})</summary>
+<ul class="locations">
+{{- range .Classes}}
+<li><code>{{.Class}}</code>{{range .Locations}} <span class="muted">{{.}}</span>{{end}}</li>
+{{- end}}
+</ul>
+</details>
+{{- end}}
+{{- end}}
+
+{{- if .Redundant}}
+<h2>Redundant CSS ({{len .Redundant}})</h2>
+<p class="muted">Stylesheets whose classes another stylesheet mostly defines.</p>
+<table>
+<thead><tr><th>File</th><th>Covered by</th><th>Overlap</th></tr></thead>
+<tbody>
+{{- range .Redundant}}
+<tr><td class="mono" title="{{.File}}">{{base .File}}</td><td class="mono" title="{{.CoveredBy}}">{{base .CoveredBy}}</td><td><span class="bar"><span style="width: {{percent .Coverage}}%"></span></span> {{percent .Coverage}}%</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+
+{{- if .Pairs}}
+<h2>CSS file overlap ({{len .Pairs}} pair{{if gt (len .Pairs) 1}}s{{end}})</h2>
+<p class="muted">Classes each pair of stylesheets shares: <span style="color: #0969da">only the first file</span>, <span style="color: #bf8700">both</span>, <span style="color: #8c959f">only the second file</span>. Coverage is the share of the smaller file the other defines.</p>
+<table class="sortable">
+<thead><tr><th data-sort>File</th><th data-sort>Other file</th><th data-sort="number" class="num">Shared</th><th data-sort="number" class="num">Only first</th><th data-sort="number" class="num">Only second</th><th data-sort="number" class="num">Coverage</th><th>Overlap</th></tr></thead>
+<tbody>
+{{- range .Pairs}}
+<tr>
+<td class="mono" title="{{.File1}}">{{base .File1}}</td>
+<td class="mono" title="{{.File2}}">{{base .File2}}</td>
+<td class="num">{{.Overlap}}</td>
+<td class="num">{{.File1Only}}</td>
+<td class="num">{{.File2Only}}</td>
+<td class="num">{{percent .Coverage}}%</td>
+<td><div class="overlap"><span class="first" style="width: {{percent .File1OnlyPercent}}%"></span><span class="both" style="width: {{percent .OverlapPercent}}%"></span><span class="second" style="width: {{percent .File2OnlyPercent}}%"></span></div></td>
+</tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+
+{{- with .Site}}
+<h2>Pages ({{len .Pages}})</h2>
+<input type="search" placeholder="Filter pages" data-filter="pages">
+<table class="sortable" id="pages">
+<thead><tr><th data-sort>Page</th><th data-sort="number" class="num">Orphans</th><th data-sort="number" class="num">Classes</th><th data-sort="number" class="num">Matched</th><th data-sort="number" class="num">Coverage</th><th data-sort="number" class="num">CSS classes</th><th data-sort="number" class="num">% of CSS</th></tr></thead>
+<tbody>
+{{- range .Pages}}
+<tr>
+<td class="mono">{{if .Orphans}}<details><summary>{{.File}}</summary><ul class="locations">{{range .Orphans}}<li><code>{{.}}</code></li>{{end}}</ul></details>{{else}}{{.File}}{{end}}</td>
+<td class="num">{{.OrphanCount}}</td>
+<td class="num">{{.Classes}}</td>
+<td class="num">{{.Matched}}</td>
+<td class="num">{{percent .CoveragePercent}}%</td>
+<td class="num">{{len .CSSClasses}}</td>
+<td class="num">{{percent .CSSPercent}}%</td>
+</tr>
+{{- end}}
+</tbody>
+</table>
+
+<h2>Directories ({{len .Directories}})</h2>
+<table class="sortable">
+<thead><tr><th data-sort>Directory</th><th data-sort="number" class="num">Pages</th><th data-sort="number" class="num">With orphans</th><th data-sort="number" class="num">Orphans</th><th data-sort="number" class="num">Coverage</th><th data-sort="number" class="num">CSS classes</th><th data-sort="number" class="num">% of CSS</th></tr></thead>
+<tbody>
+{{- range .Directories}}
+<tr>
+<td class="mono">{{if .Orphans}}<details><summary>{{.Dir}}</summary><ul class="locations">{{range .Orphans}}<li><code>{{.}}</code></li>{{end}}</ul></details>{{else}}{{.Dir}}{{end}}</td>
+<td class="num">{{.Pages}}</td>
+<td class="num">{{.OrphanPages}}</td>
+<td class="num">{{.OrphanCount}}</td>
+<td class="num">{{percent .CoveragePercent}}%</td>
+<td class="num">{{.CSSClasses}}</td>
+<td class="num">{{percent .CSSPercent}}%</td>
+</tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+
+{{- if .Suppressed}}
+<h2>Suppressed orphans ({{len .Suppressed}})</h2>
+<p class="muted">Orphans silenced by inline cssguard-ignore comments.</p>
+<ul>
+{{- range .Suppressed}}
+<li><code>{{.Class}}</code>{{template "locations" .Locations}}</li>
+{{- end}}
+</ul>
+{{- end}}
+
+{{- if not (or .Orphans .PrintOnly .ContextOrphans .Unused .Redundant)}}
+<p>No findings.</p>
+{{- end}}
+
+<p class="muted">Generated by cssguard{{with .Version}} v{{.}}{{end}}.</p>
+
+<script>
+document.querySelectorAll("table.sortable th[data-sort]").forEach(function (th) {
+  th.addEventListener("click", function () {
+    var table = th.closest("table"), body = table.tBodies[0];
+    var col = Array.prototype.indexOf.call(th.parentNode.children, th);
+    var numeric = th.dataset.sort === "number";
+    var asc = th.dataset.dir !== "asc";
+    th.parentNode.querySelectorAll("th").forEach(function (h) { delete h.dataset.dir; });
+    th.dataset.dir = asc ? "asc" : "desc";
+    var key = function (row) {
+      var text = row.cells[col].querySelector("summary") ? row.cells[col].querySelector("summary").textContent : row.cells[col].textContent;
+      return numeric ? parseFloat(text) || 0 : text.trim().toLowerCase();
+    };
+    Array.prototype.slice.call(body.rows).sort(function (a, b) {
+      var x = key(a), y = key(b);
+      return (x < y ? -1 : x > y ? 1 : 0) * (asc ? 1 : -1);
+    }).forEach(function (row) { body.appendChild(row); });
+  });
+});
+document.querySelectorAll("input[data-filter]").forEach(function (input) {
+  input.addEventListener("input", function () {
+    var q = input.value.toLowerCase();
+    Array.prototype.forEach.call(document.getElementById(input.dataset.filter).tBodies[0].rows, function (row) {
+      row.hidden = row.cells[0].textContent.toLowerCase().indexOf(q) < 0;
+    });
+  });
+});
+</script>
+</body>
+</html>
+{{define "locations"}}
+{{- if .}}
+<details><summary>{{len .}} location{{if gt (len .) 1}}s{{end}}</summary>
+<ul class="locations">
+{{- range .}}
+<li><code>{{.}}</code>{{with .Tag}} <span class="muted">&lt;{{.}}&gt;</span>{{end}}</li>
+{{- end}}
+</ul>
+</details>
+{{- end}}
+{{- end}}
+{{define "findings"}}
+<table>
+<thead><tr><th>Class</th><th>Defined by</th><th>Uses</th></tr></thead>
+<tbody>
+{{- range .}}
+<tr><td class="mono">{{.Class}}</td><td class="mono">{{.Detail}}</td><td>{{template "locations" .Locations}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+`
@@ -0,0 +1,94 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/JCorners68/cssguard/pkg/validator"
+)
+
+func TestWriteHTML(t *testing.T) {
+	result := &validator.Result{
+		HTMLClasses: 4,
+		Orphans:     []string{"translate-x-0", `x"><script>alert(1)</script>`},
+		OrphanCount: 2,
+		Unused:      []string{"legacy", "old"},
+		UnusedCount: 2,
+		OrphanLocations: map[string][]validator.Location{
+			"translate-x-0": {{File: "public/index.html", Line: 12, Column: 3, Tag: "aside"}},
+		},
+		UnusedLocations: map[string][]validator.Location{
+			"legacy": {{File: "css/main.css", Line: 40, Column: 1}, {File: "css/vendor.css", Line: 2, Column: 1}},
+			"old":    {{File: "css/main.css", Line: 50, Column: 1}},
+		},
+		Suggestions: map[string][]validator.Suggestion{
+			"translate-x-0": {{Class: "translate-x-1"}},
+		},
+		ContextOrphans: map[string]validator.ContextOrphan{
+			"card-title": {
+				Selectors: []string{".card > .card-title"},
+				Locations: []validator.Location{{File: "public/index.html", Line: 20, Column: 5, Tag: "h2"}},
+			},
+		},
+	}
+	opts := Options{
+		ToolVersion: "1.2.3",
+		Unused:      true,
+		Redundant:   []Redundancy{{File: "css/vendor.css", CoveredBy: "css/main.css", Coverage: 91.5}},
+		Pairs: []FilePair{
+			{File1: "css/main.css", File2: "css/print.css", Overlap: 0, File1Only: 29, File2Only: 4},
+			{File1: "css/main.css", File2: "css/vendor.css", Overlap: 9, File1Only: 20, File2Only: 1, Coverage: 90},
+		},
+		Site: &validator.SiteReport{
+			Pages:       []validator.PageReport{{File: "public/index.html", Orphans: []string{"translate-x-0"}, OrphanCount: 1}},
+			Directories: []validator.DirectoryReport{{Dir: "/", Pages: 1}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, result, opts); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"<h2>Orphan classes (2)</h2>",
+		"translate-x-0",
+		"public/index.html:12:3",
+		"Did you mean translate-x-1?",
+		".card &gt; .card-title",
+		"<code>css/vendor.css</code> (1)",
+		"<code>css/main.css</code> (2)",
+		`style="width: 91.5%"`,
+		"<h2>CSS file overlap (2 pairs)</h2>",
+		`<span class="both" style="width: 30.0%">`,
+		"<h2>Pages (1)</h2>",
+		"cssguard v1.2.3",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report lacks %q", want)
+		}
+	}
+	if overlap := out[strings.Index(out, "CSS file overlap"):]; strings.Index(overlap, `title="css/vendor.css"`) > strings.Index(overlap, `title="css/print.css"`) {
+		t.Error("file pairs are not sorted by coverage")
+	}
+	if strings.Contains(out, "<script>alert") {
+		t.Error("class names are not escaped")
+	}
+	for _, external := range []string{"<link", "src=", "http://", "https://"} {
+		if strings.Contains(out, external) {
+			t.Errorf("report refers to an external asset (%s)", external)
+		}
+	}
+
+	// Off rules are left out
+	opts.Severity = Severity{RuleOrphan: LevelOff, RuleContextOrphan: LevelOff}
+	buf.Reset()
+	if err := WriteHTML(&buf, result, opts); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); strings.Contains(out, "Orphan classes") || strings.Contains(out, "Context orphans") {
+		t.Error("report includes rules set to off")
+	}
+}
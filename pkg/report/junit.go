@@ -0,0 +1,262 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/JCorners68/cssguard/pkg/validator"
+)
+
+// JUnit XML object model, limited to the elements CI dashboards read.
+type (
+	junitTestsuites struct {
+		XMLName  xml.Name         `xml:"testsuites"`
+		Name     string           `xml:"name,attr"`
+		Tests    int              `xml:"tests,attr"`
+		Failures int              `xml:"failures,attr"`
+		Suites   []junitTestsuite `xml:"testsuite"`
+	}
+	junitTestsuite struct {
+		Name     string          `xml:"name,attr"`
+		Tests    int             `xml:"tests,attr"`
+		Failures int             `xml:"failures,attr"`
+		Cases    []junitTestcase `xml:"testcase"`
+	}
+	junitTestcase struct {
+		Name      string        `xml:"name,attr"`
+		Classname string        `xml:"classname,attr"`
+		File      string        `xml:"file,attr,omitempty"`
+		Failure   *junitFailure `xml:"failure,omitempty"`
+		SystemOut string        `xml:"system-out,omitempty"`
+	}
+	junitFailure struct {
+		Message string `xml:"message,attr"`
+		Type    string `xml:"type,attr"`
+		Text    string `xml:",chardata"`
+	}
+)
+
+// junitFinding is one line of a testcase: a finding of a rule at a level.
+type junitFinding struct {
+	rule  string
+	level string
+	text  string
+}
+
+// junitFile collects the findings of a file.
+type junitFile map[string][]junitFinding
+
+func (f junitFile) add(file, rule, level, text string) {
+	f[file] = append(f[file], junitFinding{rule: rule, level: level, text: text})
+}
+
+// WriteJUnit writes result as JUnit XML with a testcase per file: the HTML
+// and source files in a "cssguard.pages" suite and, as selected by opts, the
+// CSS files with unused classes or covered by another file in a
+// "cssguard.stylesheets" suite. Pages in opts.Site without findings are
+// passing testcases. A file fails with its error-level findings, such as new
+// orphans, listed with their locations; findings at other levels, such as
+// redundant stylesheets or orphans accepted by a baseline, are listed in its
+// system-out. Rules set to LevelOff and orphans silenced by inline comments
+// are left out.
+func WriteJUnit(w io.Writer, result *validator.Result, opts Options) error {
+	levelOf := func(rule string) string {
+		return opts.Severity.Level(rule)
+	}
+	enabled := func(rule string) bool {
+		return levelOf(rule) != LevelOff
+	}
+
+	pages := make(junitFile)
+	if opts.Site != nil {
+		for _, p := range opts.Site.Pages {
+			pages[p.File] = nil
+		}
+	}
+	if enabled(RuleOrphan) {
+		for _, class := range result.Orphans {
+			level := levelOf(RuleOrphan)
+			text := "orphan class " + class
+			if result.IsKnownOrphan(class) {
+				level = LevelNote
+				text += " [baseline]"
+			}
+			if issue, ok := result.VariantIssues[class]; ok {
+				text += fmt.Sprintf(" (%s)", issue.Reason())
+			}
+			if s := result.Suggestions[class]; len(s) > 0 {
+				text += ". " + DidYouMean(s)
+			}
+			locs := result.OrphanLocations[class]
+			if len(locs) == 0 {
+				pages.add("", RuleOrphan, level, text)
+			}
+			for _, loc := range locs {
+				pages.add(loc.File, RuleOrphan, level, text+" at "+junitLocation(loc))
+			}
+		}
+	}
+	if enabled(RulePrintOnly) {
+		for _, class := range result.PrintOnlyClasses() {
+			text := fmt.Sprintf("class %s is only defined for print (%s)", class, strings.Join(result.Contexts[class], ", "))
+			for _, loc := range result.PrintOnly[class] {
+				pages.add(loc.File, RulePrintOnly, levelOf(RulePrintOnly), text+" at "+junitLocation(loc))
+			}
+		}
+	}
+	if enabled(RuleContextOrphan) {
+		for _, class := range result.ContextOrphanClasses() {
+			orphan := result.ContextOrphans[class]
+			text := fmt.Sprintf("class %s is used where no rule defining it matches (%s)", class, strings.Join(orphan.Selectors, ", "))
+			for _, loc := range orphan.Locations {
+				pages.add(loc.File, RuleContextOrphan, levelOf(RuleContextOrphan), text+" at "+junitLocation(loc))
+			}
+		}
+	}
+
+	stylesheets := make(junitFile)
+	if opts.Unused && enabled(RuleUnused) {
+		for _, class := range result.Unused {
+			locs := result.UnusedLocations[class]
+			if len(locs) == 0 {
+				stylesheets.add("", RuleUnused, levelOf(RuleUnused), "unused class "+class)
+			}
+			for _, loc := range locs {
+				stylesheets.add(loc.File, RuleUnused, levelOf(RuleUnused), "unused class "+class+" at "+junitLocation(loc))
+			}
+		}
+	}
+	if enabled(RuleRedundant) {
+		for _, r := range opts.Redundant {
+			stylesheets.add(r.File, RuleRedundant, levelOf(RuleRedundant), fmt.Sprintf("stylesheet is %.1f%% covered by %s", r.Coverage, r.CoveredBy))
+		}
+	}
+
+	suites := []junitTestsuite{newJUnitSuite("cssguard.pages", pages)}
+	if len(stylesheets) > 0 {
+		suites = append(suites, newJUnitSuite("cssguard.stylesheets", stylesheets))
+	}
+	return writeJUnit(w, suites)
+}
+
+// WriteRedundancyJUnit writes a redundancy comparison as JUnit XML with a
+// testcase per file pair, listing the overlap in its system-out, and the
+// files covered by another file as findings of the redundant rule at its
+// level in opts.Severity.
+func WriteRedundancyJUnit(w io.Writer, pairs []FilePair, redundant []Redundancy, opts Options) error {
+	level := opts.Severity.Level(RuleRedundant)
+	suite := junitTestsuite{Name: "cssguard.redundancy"}
+	for _, pair := range pairs {
+		tc := junitTestcase{
+			Name:      filepath.ToSlash(pair.File1) + " vs " + filepath.ToSlash(pair.File2),
+			Classname: "cssguard.redundancy",
+			SystemOut: fmt.Sprintf("overlap: %d classes (%.1f%% coverage)\nonly in %s: %d\nonly in %s: %d\n",
+				pair.Overlap, pair.Coverage, pair.File1, pair.File1Only, pair.File2, pair.File2Only),
+		}
+		var findings []junitFinding
+		if level != LevelOff {
+			for _, r := range redundant {
+				if r.File == pair.File1 && r.CoveredBy == pair.File2 || r.File == pair.File2 && r.CoveredBy == pair.File1 {
+					findings = append(findings, junitFinding{
+						rule:  RuleRedundant,
+						level: level,
+						text:  fmt.Sprintf("%s is %.1f%% covered by %s", r.File, r.Coverage, r.CoveredBy),
+					})
+				}
+			}
+		}
+		setJUnitFindings(&tc, findings)
+		suite.Cases = append(suite.Cases, tc)
+	}
+	countJUnitSuite(&suite)
+	return writeJUnit(w, []junitTestsuite{suite})
+}
+
+// newJUnitSuite builds a suite with a testcase per file, sorted by name.
+func newJUnitSuite(name string, files junitFile) junitTestsuite {
+	suite := junitTestsuite{Name: name}
+	for _, file := range sortedKeys(files) {
+		tc := junitTestcase{Name: filepath.ToSlash(file), Classname: name, File: filepath.ToSlash(file)}
+		if file == "" {
+			tc.Name = "(no location)"
+		}
+		setJUnitFindings(&tc, files[file])
+		suite.Cases = append(suite.Cases, tc)
+	}
+	countJUnitSuite(&suite)
+	return suite
+}
+
+// setJUnitFindings fails tc with its error-level findings, summarizing the
+// first in the failure message and listing all in its text, and lists the
+// others, prefixed with their level, in its system-out.
+func setJUnitFindings(tc *junitTestcase, findings []junitFinding) {
+	var errors, others []string
+	rules := make(map[string]struct{})
+	for _, f := range findings {
+		if f.level == LevelError {
+			errors = append(errors, f.text)
+			rules[f.rule] = struct{}{}
+		} else {
+			others = append(others, f.level+": "+f.text)
+		}
+	}
+	if len(errors) > 0 {
+		// Dashboards show the message as the summary, so it names the
+		// first finding with its location
+		msg := errors[0]
+		if len(errors) > 1 {
+			msg += fmt.Sprintf(" and %d more", len(errors)-1)
+		}
+		tc.Failure = &junitFailure{
+			Message: msg,
+			Type:    strings.Join(sortedKeys(rules), ","),
+			Text:    strings.Join(errors, "\n") + "\n",
+		}
+	}
+	if len(others) > 0 {
+		tc.SystemOut += strings.Join(others, "\n") + "\n"
+	}
+}
+
+func countJUnitSuite(suite *junitTestsuite) {
+	suite.Tests = len(suite.Cases)
+	for _, tc := range suite.Cases {
+		if tc.Failure != nil {
+			suite.Failures++
+		}
+	}
+}
+
+func writeJUnit(w io.Writer, suites []junitTestsuite) error {
+	doc := junitTestsuites{Name: "cssguard", Suites: suites}
+	for _, s := range suites {
+		doc.Tests += s.Tests
+		doc.Failures += s.Failures
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// junitLocation formats a location as "file:line:column <tag>".
+func junitLocation(loc validator.Location) string {
+	s := filepath.ToSlash(loc.File)
+	if loc.Line > 0 {
+		s = fmt.Sprintf("%s:%d:%d", s, loc.Line, loc.Column)
+	}
+	if loc.Tag != "" {
+		s += " <" + loc.Tag + ">"
+	}
+	return s
+}
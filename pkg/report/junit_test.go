@@ -0,0 +1,111 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/JCorners68/cssguard/pkg/validator"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	result := &validator.Result{
+		Orphans: []string{"legacy-btn", "translate-x-0"},
+		OrphanLocations: map[string][]validator.Location{
+			"translate-x-0": {
+				{File: "public/index.html", Line: 12, Column: 3, Tag: "aside"},
+				{File: "public/docs/index.html", Line: 4, Column: 1, Tag: "div"},
+			},
+			"legacy-btn": {{File: "public/index.html", Line: 30, Column: 5, Tag: "a"}},
+		},
+		Baseline: &validator.BaselineStatus{New: []string{"translate-x-0"}, Known: []string{"legacy-btn"}},
+		Unused:   []string{"old"},
+		UnusedLocations: map[string][]validator.Location{
+			"old": {{File: "css/main.css", Line: 50, Column: 1}},
+		},
+	}
+	opts := Options{
+		Unused:    true,
+		Redundant: []Redundancy{{File: "css/vendor.css", CoveredBy: "css/main.css", Coverage: 91.5}},
+		Site: &validator.SiteReport{Pages: []validator.PageReport{
+			{File: "public/index.html"}, {File: "public/about.html"},
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, result, opts); err != nil {
+		t.Fatal(err)
+	}
+	var doc junitTestsuites
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid XML: %v", err)
+	}
+	if doc.Tests != 5 || doc.Failures != 2 || len(doc.Suites) != 2 {
+		t.Fatalf("tests = %d, failures = %d, suites = %d", doc.Tests, doc.Failures, len(doc.Suites))
+	}
+
+	cases := make(map[string]junitTestcase)
+	for _, s := range doc.Suites {
+		for _, tc := range s.Cases {
+			cases[tc.Name] = tc
+		}
+	}
+	if tc := cases["public/about.html"]; tc.Failure != nil || tc.SystemOut != "" {
+		t.Errorf("clean page = %+v", tc)
+	}
+	index := cases["public/index.html"]
+	if index.Failure == nil || index.Failure.Type != RuleOrphan ||
+		!strings.Contains(index.Failure.Text, "orphan class translate-x-0 at public/index.html:12:3 <aside>") {
+		t.Errorf("index failure = %+v", index.Failure)
+	}
+	if index.Failure.Message != "orphan class translate-x-0 at public/index.html:12:3 <aside>" {
+		t.Errorf("index failure message = %q, want the first orphan with its location", index.Failure.Message)
+	}
+	if strings.Contains(index.Failure.Text, "legacy-btn") || !strings.Contains(index.SystemOut, "note: orphan class legacy-btn [baseline]") {
+		t.Errorf("known orphan not moved to system-out: %+v", index)
+	}
+	if tc := cases["public/docs/index.html"]; tc.Failure == nil {
+		t.Error("page without a Site entry is missing its failure")
+	}
+	if tc := cases["css/vendor.css"]; tc.Failure != nil || !strings.Contains(tc.SystemOut, "warning: stylesheet is 91.5% covered by css/main.css") {
+		t.Errorf("redundant stylesheet = %+v", tc)
+	}
+	if tc := cases["css/main.css"]; tc.Failure != nil || !strings.Contains(tc.SystemOut, "note: unused class old at css/main.css:50:1") {
+		t.Errorf("unused class = %+v", tc)
+	}
+}
+
+func TestWriteRedundancyJUnit(t *testing.T) {
+	pairs := []FilePair{
+		{File1: "main.css", File2: "vendor.css", Overlap: 9, File1Only: 20, File2Only: 1, Coverage: 90},
+		{File1: "main.css", File2: "print.css", Overlap: 0, File1Only: 29, File2Only: 4},
+	}
+	redundant := []Redundancy{{File: "vendor.css", CoveredBy: "main.css", Coverage: 90}}
+
+	for _, tt := range []struct {
+		severity Severity
+		failures int
+	}{
+		{nil, 0},
+		{Severity{RuleRedundant: LevelError}, 1},
+	} {
+		var buf bytes.Buffer
+		if err := WriteRedundancyJUnit(&buf, pairs, redundant, Options{Severity: tt.severity}); err != nil {
+			t.Fatal(err)
+		}
+		var doc junitTestsuites
+		if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+			t.Fatalf("invalid XML: %v", err)
+		}
+		if doc.Tests != 2 || doc.Failures != tt.failures {
+			t.Errorf("severity %v: tests = %d, failures = %d", tt.severity, doc.Tests, doc.Failures)
+		}
+		if tt.failures == 1 && doc.Suites[0].Cases[0].Failure.Message != "vendor.css is 90.0% covered by main.css" {
+			t.Errorf("failure message = %q", doc.Suites[0].Cases[0].Failure.Message)
+		}
+		if tt.failures == 0 && !strings.Contains(doc.Suites[0].Cases[0].SystemOut, "warning: vendor.css is 90.0% covered by main.css") {
+			t.Errorf("system-out = %q", doc.Suites[0].Cases[0].SystemOut)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/JCorners68/cssguard/pkg/gitdiff"
+	"github.com/JCorners68/cssguard/pkg/validator"
+)
+
+// maxMarkdownLocations limits how many usage sites are listed per class in
+// the Markdown summary.
+const maxMarkdownLocations = 5
+
+// WriteMarkdown writes a compact Markdown summary of result for PR comments
+// and job summaries: a table of the counts, the orphans with their
+// locations in a collapsible list, print-only classes and context orphans
+// likewise and, as selected by opts, redundant CSS files. Locations link to
+// opts.LinkBase when it is set, by their path relative to opts.LinkRoot.
+// Rules set to LevelOff are left out.
+func WriteMarkdown(w io.Writer, result *validator.Result, opts Options) error {
+	enabled := func(rule string) bool {
+		return opts.Severity.Level(rule) != LevelOff
+	}
+	var b strings.Builder
+
+	b.WriteString("## cssguard\n\n")
+	header := []string{"HTML classes", "Matched", "Coverage", "Orphans"}
+	row := []string{
+		fmt.Sprint(result.HTMLClasses),
+		fmt.Sprint(result.Matched),
+		fmt.Sprintf("%.1f%%", result.CoveragePercent),
+		fmt.Sprint(result.OrphanCount),
+	}
+	if opts.Unused && enabled(RuleUnused) {
+		header = append(header, "Unused")
+		row = append(row, fmt.Sprint(result.UnusedCount))
+	}
+	if result.Baseline != nil {
+		header = append(header, "New", "Known", "Resolved")
+		row = append(row, fmt.Sprint(len(result.Baseline.New)), fmt.Sprint(len(result.Baseline.Known)), fmt.Sprint(len(result.Baseline.Resolved)))
+	}
+	fmt.Fprintf(&b, "| %s |\n|%s\n| %s |\n", strings.Join(header, " | "), strings.Repeat(" ---: |", len(header)), strings.Join(row, " | "))
+
+	if enabled(RuleOrphan) && len(result.Orphans) > 0 {
+		fmt.Fprintf(&b, "\n<details>\n<summary>Orphan classes (%d)</summary>\n\n", len(result.Orphans))
+		for _, class := range result.Orphans {
+			line := "- " + markdownCode(class)
+			if issue, ok := result.VariantIssues[class]; ok {
+				line += fmt.Sprintf(" (%s)", issue.Reason())
+			}
+			if result.IsKnownOrphan(class) {
+				line += " [baseline]"
+			}
+			if s := result.Suggestions[class]; len(s) > 0 {
+				line += " — " + DidYouMean(s)
+			}
+			b.WriteString(line + "\n")
+			writeMarkdownLocations(&b, result.OrphanLocations[class], opts)
+		}
+		b.WriteString("\n</details>\n")
+	}
+
+	if enabled(RulePrintOnly) && len(result.PrintOnly) > 0 {
+		fmt.Fprintf(&b, "\n<details>\n<summary>⚠ Print-only classes (%d)</summary>\n\n", len(result.PrintOnly))
+		for _, class := range result.PrintOnlyClasses() {
+			fmt.Fprintf(&b, "- %s (%s)\n", markdownCode(class), strings.Join(result.Contexts[class], ", "))
+			writeMarkdownLocations(&b, result.PrintOnly[class], opts)
+		}
+		b.WriteString("\n</details>\n")
+	}
+
+	if enabled(RuleContextOrphan) && len(result.ContextOrphans) > 0 {
+		fmt.Fprintf(&b, "\n<details>\n<summary>⚠ Context orphans (%d)</summary>\n\n", len(result.ContextOrphans))
+		for _, class := range result.ContextOrphanClasses() {
+			orphan := result.ContextOrphans[class]
+			selectors := make([]string, len(orphan.Selectors))
+			for i, s := range orphan.Selectors {
+				selectors[i] = markdownCode(s)
+			}
+			fmt.Fprintf(&b, "- %s (%s)\n", markdownCode(class), strings.Join(selectors, ", "))
+			writeMarkdownLocations(&b, orphan.Locations, opts)
+		}
+		b.WriteString("\n</details>\n")
+	}
+
+	if enabled(RuleRedundant) && len(opts.Redundant) > 0 {
+		b.WriteString("\n**⚠ Redundant CSS:**\n\n")
+		for _, r := range opts.Redundant {
+			fmt.Fprintf(&b, "- %s is %.1f%% covered by %s\n", markdownCode(filepath.ToSlash(r.File)), r.Coverage, markdownCode(filepath.ToSlash(r.CoveredBy)))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeMarkdownLocations lists the first few locations as a nested list,
+// linked to opts.LinkBase if it is set.
+func writeMarkdownLocations(b *strings.Builder, locs []validator.Location, opts Options) {
+	for i, loc := range locs {
+		if i >= maxMarkdownLocations {
+			fmt.Fprintf(b, "  - … and %d more\n", len(locs)-maxMarkdownLocations)
+			break
+		}
+		file := filepath.ToSlash(loc.File)
+		text := markdownCode(fmt.Sprintf("%s:%d:%d", file, loc.Line, loc.Column))
+		if opts.LinkBase != "" {
+			text = fmt.Sprintf("[%s](%s%s#L%d)", text, opts.LinkBase, linkPath(loc.File, opts.LinkRoot), loc.Line)
+		}
+		if loc.Tag != "" {
+			text += " " + markdownCode("<"+loc.Tag+">")
+		}
+		fmt.Fprintf(b, "  - %s\n", text)
+	}
+}
+
+// linkPath returns file relative to root with forward slashes, for joining
+// onto a link base. A file outside root, or any file if root is empty, keeps
+// its path as given.
+func linkPath(file, root string) string {
+	if root != "" {
+		if rel, err := filepath.Rel(gitdiff.AbsPath(root), gitdiff.AbsPath(file)); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			file = rel
+		}
+	}
+	return filepath.ToSlash(file)
+}
+
+// markdownCode formats s as a code span, with a longer fence if s contains
+// a backtick.
+func markdownCode(s string) string {
+	if !strings.Contains(s, "`") {
+		return "`" + s + "`"
+	}
+	return "`` " + s + " ``"
+}
@@ -0,0 +1,84 @@
+package report
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JCorners68/cssguard/pkg/validator"
+)
+
+func TestWriteMarkdown(t *testing.T) {
+	result := &validator.Result{
+		HTMLClasses:     10,
+		Matched:         8,
+		CoveragePercent: 80,
+		Orphans:         []string{"legacy", "translate-x-0"},
+		OrphanCount:     2,
+		UnusedCount:     4,
+		OrphanLocations: map[string][]validator.Location{
+			"translate-x-0": {{File: "public/index.html", Line: 12, Column: 3, Tag: "aside"}},
+		},
+		Suggestions: map[string][]validator.Suggestion{"translate-x-0": {{Class: "translate-x-1"}}},
+		Baseline:    &validator.BaselineStatus{New: []string{"translate-x-0"}, Known: []string{"legacy"}, Resolved: []string{"gone"}},
+	}
+	opts := Options{
+		Unused:    true,
+		Redundant: []Redundancy{{File: "css/vendor.css", CoveredBy: "css/main.css", Coverage: 91.5}},
+		LinkBase:  "https://github.com/o/r/blob/abc/",
+	}
+
+	var buf bytes.Buffer
+	if err := WriteMarkdown(&buf, result, opts); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"| HTML classes | Matched | Coverage | Orphans | Unused | New | Known | Resolved |",
+		"| 10 | 8 | 80.0% | 2 | 4 | 1 | 1 | 1 |",
+		"<summary>Orphan classes (2)</summary>",
+		"- `legacy` [baseline]\n",
+		"- `translate-x-0` — Did you mean translate-x-1?\n  - [`public/index.html:12:3`](https://github.com/o/r/blob/abc/public/index.html#L12) `<aside>`\n",
+		"- `css/vendor.css` is 91.5% covered by `css/main.css`",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("summary lacks %q:\n%s", want, out)
+		}
+	}
+
+	// Without a link base, locations are plain code
+	opts.LinkBase = ""
+	buf.Reset()
+	if err := WriteMarkdown(&buf, result, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "  - `public/index.html:12:3` `<aside>`") {
+		t.Errorf("unlinked summary:\n%s", buf.String())
+	}
+}
+
+func TestLinkPath(t *testing.T) {
+	root := t.TempDir()
+	for _, tt := range []struct{ file, root, want string }{
+		{filepath.Join(root, "public", "index.html"), root, "public/index.html"},
+		{filepath.Join(root, "public", "index.html"), filepath.Join(root, "public") + string(filepath.Separator), "index.html"},
+		{filepath.Join(filepath.Dir(root), "other.html"), root, filepath.ToSlash(filepath.Join(filepath.Dir(root), "other.html"))},
+		{filepath.Join("public", "index.html"), "", "public/index.html"},
+	} {
+		if got := linkPath(tt.file, tt.root); got != tt.want {
+			t.Errorf("linkPath(%q, %q) = %q, want %q", tt.file, tt.root, got, tt.want)
+		}
+	}
+}
+
+func TestMarkdownCode(t *testing.T) {
+	for _, tt := range []struct{ in, want string }{
+		{"w-1/2", "`w-1/2`"},
+		{"a`b", "`` a`b ``"},
+	} {
+		if got := markdownCode(tt.in); got != tt.want {
+			t.Errorf("markdownCode(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Package report renders validation results in machine-readable formats.
+package report
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/JCorners68/cssguard/pkg/validator"
+)
+
+// Rule IDs shared by every report format.
+const (
+	RuleOrphan        = "orphan"
+	RuleUnused        = "unused"
+	RuleRedundant     = "redundant"
+	RulePrintOnly     = "print-only"
+	RuleContextOrphan = "context-orphan"
+)
+
+// Redundancy is a CSS file whose classes are mostly defined by another file.
+type Redundancy struct {
+	File      string  `json:"file"`
+	CoveredBy string  `json:"covered_by"`
+	Coverage  float64 `json:"coverage_percent"`
+}
+
+// String formats the redundancy as "main.css (85.2% covered by vendor.css)".
+func (r Redundancy) String() string {
+	return fmt.Sprintf("%s (%.1f%% covered by %s)", filepath.Base(r.File), r.Coverage, filepath.Base(r.CoveredBy))
+}
+
+// FilePair compares the classes of two CSS files.
+type FilePair struct {
+	File1     string  `json:"file1"`
+	File2     string  `json:"file2"`
+	Overlap   int     `json:"overlap"`
+	File1Only int     `json:"file1_only"`
+	File2Only int     `json:"file2_only"`
+	Coverage  float64 `json:"coverage_percent"` // % of smaller file covered by larger
+}
+
+// DidYouMean formats suggestions as "Did you mean text-gray-500 or text-gray-600?".
+func DidYouMean(suggestions []validator.Suggestion) string {
+	classes := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		classes[i] = s.Class
+	}
+	return "Did you mean " + strings.Join(classes, " or ") + "?"
+}
+
+// Options selects what a report includes beyond orphans.
+type Options struct {
+	ToolVersion string       // cssguard version recorded in the report
+	Unused      bool         // Report unused CSS classes
+	Redundant   []Redundancy // Redundant CSS files to report
+	Pairs       []FilePair   // Class overlap of each pair of CSS files; only the HTML report charts it
+	Severity    Severity     // Level of each rule; nil uses the defaults
+
+	// Site is the per-page breakdown to report; only the HTML report shows it.
+	Site *validator.SiteReport
+
+	// LinkBase is prepended to file paths to link locations in the Markdown
+	// summary, e.g. "https://github.com/owner/repo/blob/<sha>/"; empty for
+	// no links.
+	LinkBase string
+
+	// LinkRoot is the directory LinkBase points at, normally the repository
+	// root; linked paths are made relative to it. Empty links paths as given.
+	LinkRoot string
+}
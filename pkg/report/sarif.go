@@ -0,0 +1,255 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/JCorners68/cssguard/pkg/validator"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolURI      = "https://github.com/JCorners68/cssguard"
+)
+
+// SARIF 2.1.0 object model, limited to the properties cssguard emits.
+type (
+	sarifLog struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		Version        string      `json:"version,omitempty"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID                   string             `json:"id"`
+		Name                 string             `json:"name"`
+		ShortDescription     sarifMessage       `json:"shortDescription"`
+		FullDescription      sarifMessage       `json:"fullDescription"`
+		DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+		HelpURI              string             `json:"helpUri"`
+	}
+	sarifConfiguration struct {
+		Level string `json:"level"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifResult struct {
+		RuleID        string             `json:"ruleId"`
+		RuleIndex     int                `json:"ruleIndex"`
+		Level         string             `json:"level"`
+		Message       sarifMessage       `json:"message"`
+		Locations     []sarifLocation    `json:"locations,omitempty"`
+		BaselineState string             `json:"baselineState,omitempty"`
+		Suppressions  []sarifSuppression `json:"suppressions,omitempty"`
+	}
+	sarifSuppression struct {
+		Kind string `json:"kind"`
+	}
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Region           *sarifRegion          `json:"region,omitempty"`
+	}
+	sarifArtifactLocation struct {
+		URI string `json:"uri"`
+	}
+	sarifRegion struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn,omitempty"`
+	}
+)
+
+// sarifRules describes each rule; the index of a rule is its ruleIndex.
+var sarifRules = []sarifRule{
+	{
+		ID:                   RuleOrphan,
+		Name:                 "OrphanClass",
+		ShortDescription:     sarifMessage{Text: "Class used in HTML has no CSS definition"},
+		FullDescription:      sarifMessage{Text: "The class is applied to an element but no stylesheet defines it, so it silently has no effect."},
+		DefaultConfiguration: sarifConfiguration{Level: "error"},
+		HelpURI:              toolURI,
+	},
+	{
+		ID:                   RuleUnused,
+		Name:                 "UnusedClass",
+		ShortDescription:     sarifMessage{Text: "CSS class is never used in HTML"},
+		FullDescription:      sarifMessage{Text: "The class is defined in a stylesheet but no scanned HTML or source file uses it."},
+		DefaultConfiguration: sarifConfiguration{Level: "note"},
+		HelpURI:              toolURI,
+	},
+	{
+		ID:                   RuleRedundant,
+		Name:                 "RedundantStylesheet",
+		ShortDescription:     sarifMessage{Text: "CSS file is mostly covered by another file"},
+		FullDescription:      sarifMessage{Text: "Most classes in the stylesheet are also defined by another stylesheet, so it may be removable."},
+		DefaultConfiguration: sarifConfiguration{Level: "warning"},
+		HelpURI:              toolURI,
+	},
+	{
+		ID:                   RulePrintOnly,
+		Name:                 "PrintOnlyClass",
+		ShortDescription:     sarifMessage{Text: "Class used in HTML is only defined for print"},
+		FullDescription:      sarifMessage{Text: "Every rule defining the class is inside an @media rule that never matches screens, so the class has no effect on screen."},
+		DefaultConfiguration: sarifConfiguration{Level: "warning"},
+		HelpURI:              toolURI,
+	},
+	{
+		ID:                   RuleContextOrphan,
+		Name:                 "ContextOrphanClass",
+		ShortDescription:     sarifMessage{Text: "Class used in HTML is only defined for another structure"},
+		FullDescription:      sarifMessage{Text: "Every rule defining the class needs other classes on the element or around it, such as a .card parent for .card > .card-title, and the element does not have them, so no rule applies."},
+		DefaultConfiguration: sarifConfiguration{Level: "warning"},
+		HelpURI:              toolURI,
+	},
+}
+
+// WriteSARIF writes result as a SARIF 2.1.0 log with one result per orphan,
+// print-only and context orphan class and, as selected by opts, per unused class and
+// redundant CSS file. Orphans silenced by inline comments are included as
+// suppressed results. Rules set to LevelOff produce no results.
+func WriteSARIF(w io.Writer, result *validator.Result, opts Options) error {
+	rules := make([]sarifRule, len(sarifRules))
+	for i, rule := range sarifRules {
+		rule.DefaultConfiguration.Level = sarifLevel(opts.Severity.Level(rule.ID))
+		rules[i] = rule
+	}
+	enabled := func(rule string) bool {
+		return opts.Severity.Level(rule) != LevelOff
+	}
+
+	var results []sarifResult
+
+	if enabled(RuleOrphan) {
+		for _, class := range result.Orphans {
+			msg := fmt.Sprintf("Orphan class %s is used but not defined in CSS", class)
+			if issue, ok := result.VariantIssues[class]; ok {
+				msg += fmt.Sprintf(" (%s)", issue.Reason())
+			}
+			if s := result.Suggestions[class]; len(s) > 0 {
+				msg += ". " + DidYouMean(s)
+			}
+			r := newSARIFResult(rules, 0, msg, result.OrphanLocations[class])
+			if result.Baseline != nil {
+				r.BaselineState = "new"
+				if result.IsKnownOrphan(class) {
+					r.BaselineState = "unchanged"
+				}
+			}
+			results = append(results, r)
+		}
+
+		// Orphans silenced by inline comments, marked as suppressed in source
+		suppressed := make([]string, 0, len(result.Suppressed))
+		for class := range result.Suppressed {
+			suppressed = append(suppressed, class)
+		}
+		sort.Strings(suppressed)
+		for _, class := range suppressed {
+			msg := fmt.Sprintf("Orphan class %s is used but not defined in CSS", class)
+			r := newSARIFResult(rules, 0, msg, result.Suppressed[class])
+			r.Suppressions = []sarifSuppression{{Kind: "inSource"}}
+			results = append(results, r)
+		}
+	}
+
+	if opts.Unused && enabled(RuleUnused) {
+		for _, class := range result.Unused {
+			msg := fmt.Sprintf("Unused class %s is defined in CSS but never used", class)
+			results = append(results, newSARIFResult(rules, 1, msg, result.UnusedLocations[class]))
+		}
+	}
+
+	if enabled(RuleRedundant) {
+		for _, r := range opts.Redundant {
+			msg := fmt.Sprintf("Stylesheet %s is %.1f%% covered by %s", r.File, r.Coverage, r.CoveredBy)
+			results = append(results, newSARIFResult(rules, 2, msg, []validator.Location{{File: r.File}}))
+		}
+	}
+
+	if enabled(RulePrintOnly) {
+		for _, class := range result.PrintOnlyClasses() {
+			msg := fmt.Sprintf("Class %s is only defined for print", class)
+			if contexts := result.Contexts[class]; len(contexts) > 0 {
+				msg += fmt.Sprintf(" (%s)", strings.Join(contexts, ", "))
+			}
+			results = append(results, newSARIFResult(rules, 3, msg, result.PrintOnly[class]))
+		}
+	}
+
+	if enabled(RuleContextOrphan) {
+		for _, class := range result.ContextOrphanClasses() {
+			orphan := result.ContextOrphans[class]
+			msg := fmt.Sprintf("Class %s is used where no rule defining it matches (%s)", class, strings.Join(orphan.Selectors, ", "))
+			results = append(results, newSARIFResult(rules, 4, msg, orphan.Locations))
+		}
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "cssguard",
+				Version:        opts.ToolVersion,
+				InformationURI: toolURI,
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+	if log.Runs[0].Results == nil {
+		log.Runs[0].Results = []sarifResult{} // SARIF requires the array
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+func newSARIFResult(rules []sarifRule, ruleIndex int, msg string, locs []validator.Location) sarifResult {
+	rule := rules[ruleIndex]
+	r := sarifResult{
+		RuleID:    rule.ID,
+		RuleIndex: ruleIndex,
+		Level:     rule.DefaultConfiguration.Level,
+		Message:   sarifMessage{Text: msg},
+	}
+	for _, loc := range locs {
+		pl := sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(loc.File)},
+		}
+		if loc.Line > 0 {
+			pl.Region = &sarifRegion{StartLine: loc.Line, StartColumn: loc.Column}
+		}
+		r.Locations = append(r.Locations, sarifLocation{PhysicalLocation: pl})
+	}
+	return r
+}
+
+// sarifLevel maps a severity level to a SARIF level; SARIF calls "off" "none".
+func sarifLevel(level string) string {
+	if level == LevelOff {
+		return "none"
+	}
+	return level
+}
@@ -0,0 +1,188 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/JCorners68/cssguard/pkg/validator"
+)
+
+func TestWriteSARIF(t *testing.T) {
+	result := &validator.Result{
+		Orphans: []string{"md:bg-brand", "translate-x-0"},
+		Unused:  []string{"legacy"},
+		OrphanLocations: map[string][]validator.Location{
+			"translate-x-0": {
+				{File: "public/index.html", Line: 12, Column: 3, Tag: "aside"},
+				{File: `public\docs\index.html`, Line: 4, Column: 1, Tag: "div"},
+			},
+		},
+		UnusedLocations: map[string][]validator.Location{
+			"legacy": {{File: "css/main.css", Line: 40, Column: 1}},
+		},
+		VariantIssues: map[string]validator.VariantIssue{
+			"md:bg-brand": {Variants: []string{"md"}, Base: "bg-brand", MissingUtility: true},
+		},
+		Contexts: map[string][]string{"receipt": {"@media print"}},
+		PrintOnly: map[string][]validator.Location{
+			"receipt": {{File: "public/order.html", Line: 7, Column: 5, Tag: "p"}},
+		},
+		ContextOrphans: map[string]validator.ContextOrphan{
+			"card-title": {
+				Selectors: []string{".card > .card-title"},
+				Locations: []validator.Location{{File: "public/index.html", Line: 20, Column: 5, Tag: "h2"}},
+			},
+		},
+	}
+	opts := Options{
+		ToolVersion: "1.2.3",
+		Unused:      true,
+		Redundant:   []Redundancy{{File: "vendor.css", CoveredBy: "main.css", Coverage: 91.5}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, result, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("version = %q, runs = %d", log.Version, len(log.Runs))
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Version != "1.2.3" || len(run.Tool.Driver.Rules) != 5 {
+		t.Errorf("driver = %+v", run.Tool.Driver)
+	}
+
+	wantRules := []string{RuleOrphan, RuleOrphan, RuleUnused, RuleRedundant, RulePrintOnly, RuleContextOrphan}
+	wantLevels := []string{"error", "error", "note", "warning", "warning", "warning"}
+	if len(run.Results) != len(wantRules) {
+		t.Fatalf("got %d results, want %d", len(run.Results), len(wantRules))
+	}
+	for i, r := range run.Results {
+		if r.RuleID != wantRules[i] || r.Level != wantLevels[i] {
+			t.Errorf("result %d = %s/%s, want %s/%s", i, r.RuleID, r.Level, wantRules[i], wantLevels[i])
+		}
+		if run.Tool.Driver.Rules[r.RuleIndex].ID != r.RuleID {
+			t.Errorf("result %d ruleIndex %d does not point at %s", i, r.RuleIndex, r.RuleID)
+		}
+	}
+
+	if msg := run.Results[0].Message.Text; msg != "Orphan class md:bg-brand is used but not defined in CSS (missing utility bg-brand)" {
+		t.Errorf("message = %q", msg)
+	}
+	if msg := run.Results[4].Message.Text; msg != "Class receipt is only defined for print (@media print)" {
+		t.Errorf("print-only message = %q", msg)
+	}
+	if msg := run.Results[5].Message.Text; msg != "Class card-title is used where no rule defining it matches (.card > .card-title)" {
+		t.Errorf("context orphan message = %q", msg)
+	}
+	locs := run.Results[1].Locations
+	if len(locs) != 2 {
+		t.Fatalf("got %d locations, want 2", len(locs))
+	}
+	if uri := locs[1].PhysicalLocation.ArtifactLocation.URI; uri != "public/docs/index.html" && uri != `public\docs\index.html` {
+		t.Errorf("uri = %q", uri)
+	}
+	if region := locs[0].PhysicalLocation.Region; region == nil || region.StartLine != 12 || region.StartColumn != 3 {
+		t.Errorf("region = %+v, want 12:3", region)
+	}
+	if region := run.Results[3].Locations[0].PhysicalLocation.Region; region != nil {
+		t.Errorf("redundant file result has region %+v, want none", region)
+	}
+}
+
+func TestWriteSARIFEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, &validator.Result{}, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`"results": []`)) {
+		t.Errorf("empty log must contain an empty results array:\n%s", buf.String())
+	}
+}
+
+func TestWriteSARIFSeverity(t *testing.T) {
+	result := &validator.Result{Orphans: []string{"legacy-btn"}, Unused: []string{"legacy"}}
+	opts := Options{
+		Unused:    true,
+		Redundant: []Redundancy{{File: "vendor.css", CoveredBy: "main.css", Coverage: 91.5}},
+		Severity:  Severity{RuleOrphan: LevelWarning, RuleUnused: LevelOff},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, result, opts); err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	run := log.Runs[0]
+	if len(run.Results) != 2 {
+		t.Fatalf("got %d results, want 2 (unused is off)", len(run.Results))
+	}
+	if r := run.Results[0]; r.RuleID != RuleOrphan || r.Level != "warning" {
+		t.Errorf("orphan result = %s/%s, want orphan/warning", r.RuleID, r.Level)
+	}
+	if level := run.Tool.Driver.Rules[1].DefaultConfiguration.Level; level != "none" {
+		t.Errorf("unused rule level = %q, want none", level)
+	}
+}
+
+func TestWriteSARIFInlineSuppressions(t *testing.T) {
+	result := &validator.Result{
+		Orphans: []string{"custom"},
+		Suppressed: map[string][]validator.Location{
+			"js-toggle": {{File: "index.html", Line: 3, Column: 1, Tag: "button"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, result, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	results := log.Runs[0].Results
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if len(results[0].Suppressions) != 0 {
+		t.Errorf("orphan custom has suppressions %v", results[0].Suppressions)
+	}
+	if s := results[1].Suppressions; len(s) != 1 || s[0].Kind != "inSource" {
+		t.Errorf("suppressions = %v, want one inSource suppression", s)
+	}
+}
+
+func TestWriteSARIFSuggestions(t *testing.T) {
+	result := &validator.Result{
+		Orphans: []string{"text-grey-500"},
+		Suggestions: map[string][]validator.Suggestion{
+			"text-grey-500": {{Class: "text-gray-500", Score: 0.92}, {Class: "text-gray-600", Score: 0.85}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, result, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	want := "Orphan class text-grey-500 is used but not defined in CSS. Did you mean text-gray-500 or text-gray-600?"
+	if msg := log.Runs[0].Results[0].Message.Text; msg != want {
+		t.Errorf("message = %q, want %q", msg, want)
+	}
+}
@@ -0,0 +1,106 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Severity levels a rule can be set to. Findings of a rule set to LevelOff
+// are not reported, and only LevelError findings fail a run.
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+	LevelNote    = "note"
+	LevelOff     = "off"
+)
+
+// Rules lists every rule ID.
+var Rules = []string{RuleOrphan, RuleUnused, RuleRedundant, RulePrintOnly, RuleContextOrphan}
+
+var levels = []string{LevelError, LevelWarning, LevelNote, LevelOff}
+
+// defaultSeverity is the level of each rule unless configured otherwise.
+var defaultSeverity = map[string]string{
+	RuleOrphan:        LevelError,
+	RuleUnused:        LevelNote,
+	RuleRedundant:     LevelWarning,
+	RulePrintOnly:     LevelWarning,
+	RuleContextOrphan: LevelWarning,
+}
+
+// Severity maps rule IDs to levels. Rules missing from the map use their
+// default level: orphan=error, unused=note, redundant=warning,
+// print-only=warning, context-orphan=warning.
+type Severity map[string]string
+
+// Level returns the configured level of a rule.
+func (s Severity) Level(rule string) string {
+	if level, ok := s[rule]; ok {
+		return level
+	}
+	return defaultSeverity[rule]
+}
+
+// Set validates and sets the level of a rule.
+func (s Severity) Set(rule, level string) error {
+	if !contains(Rules, rule) {
+		return fmt.Errorf("unknown rule %q (want one of: %s)", rule, strings.Join(Rules, ", "))
+	}
+	if !contains(levels, level) {
+		return fmt.Errorf("unknown level %q for rule %s (want one of: %s)", level, rule, strings.Join(levels, ", "))
+	}
+	s[rule] = level
+	return nil
+}
+
+// Effective returns the level of every rule, defaults included.
+func (s Severity) Effective() Severity {
+	eff := make(Severity, len(Rules))
+	for _, rule := range Rules {
+		eff[rule] = s.Level(rule)
+	}
+	return eff
+}
+
+// String formats the severity as "orphan=error,unused=off", sorted by rule.
+func (s Severity) String() string {
+	rules := make([]string, 0, len(s))
+	for rule := range s {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+	pairs := make([]string, len(rules))
+	for i, rule := range rules {
+		pairs[i] = rule + "=" + s[rule]
+	}
+	return strings.Join(pairs, ",")
+}
+
+// ParseSeverity parses a comma-separated list of rule=level pairs.
+func ParseSeverity(value string) (Severity, error) {
+	s := make(Severity)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		rule, level, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid severity %q (want rule=level)", pair)
+		}
+		if err := s.Set(strings.TrimSpace(rule), strings.TrimSpace(level)); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package report
+
+import "testing"
+
+func TestParseSeverity(t *testing.T) {
+	s, err := ParseSeverity("orphan=warning, unused=off")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Level(RuleOrphan) != LevelWarning || s.Level(RuleUnused) != LevelOff {
+		t.Errorf("ParseSeverity() = %v", s)
+	}
+	if got := s.Level(RuleRedundant); got != LevelWarning {
+		t.Errorf("Level(redundant) = %q, want default %q", got, LevelWarning)
+	}
+	if got := s.String(); got != "orphan=warning,unused=off" {
+		t.Errorf("String() = %q", got)
+	}
+
+	for _, bad := range []string{"orphan", "orphans=error", "orphan=fatal"} {
+		if _, err := ParseSeverity(bad); err == nil {
+			t.Errorf("ParseSeverity(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestSeverityDefaults(t *testing.T) {
+	var s Severity
+	eff := s.Effective()
+	want := Severity{RuleOrphan: LevelError, RuleUnused: LevelNote, RuleRedundant: LevelWarning, RulePrintOnly: LevelWarning, RuleContextOrphan: LevelWarning}
+	if eff.String() != want.String() {
+		t.Errorf("Effective() = %v, want %v", eff, want)
+	}
+}
@@ -4,10 +4,17 @@ package srcscan
 
 import (
 	"bufio"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/JCorners68/cssguard/pkg/cache"
+	"github.com/JCorners68/cssguard/pkg/parallel"
+	"github.com/JCorners68/cssguard/pkg/suppress"
 )
 
 // DefaultExtensions are the file extensions to scan by default.
@@ -21,8 +28,10 @@ var DefaultExcludes = []string{
 }
 
 // classTokenRegex matches valid CSS class tokens.
-// Includes # for Tailwind arbitrary values like bg-[#ff0000]
-var classTokenRegex = regexp.MustCompile(`^[A-Za-z0-9:_\-\[\]/#%.]+$`)
+// Includes # ( ) , % for Tailwind arbitrary values like bg-[#ff0000] and
+// w-[calc(100%-2rem)], ! for the important modifier (!mt-0), and & > * +
+// for arbitrary variants like [&>*]:p-4
+var classTokenRegex = regexp.MustCompile(`^[A-Za-z0-9:_\-\[\]/#%.!(),&>*+]+$`)
 
 // Patterns for extracting class strings from source code.
 var (
@@ -34,10 +43,46 @@ var (
 	helperRegex = regexp.MustCompile(`(?:clsx|classnames|twMerge|cva|cn)\s*\(\s*["']([^"']+)["']`)
 )
 
+// Patterns matching a line that ends inside an unterminated class string,
+// as while one is being typed.
+var (
+	openClassAttrRegex = regexp.MustCompile(`(?:class|className)\s*=\s*["'][^"']*$`)
+	openHelperRegex    = regexp.MustCompile(`(?:clsx|classnames|twMerge|cva|cn)\s*\(\s*["'][^"']*$`)
+)
+
+// InClassString reports whether the end of prefix, the start of a line up to
+// the cursor, is inside a class attribute or class helper string.
+func InClassString(prefix string) bool {
+	return openClassAttrRegex.MatchString(prefix) || openHelperRegex.MatchString(prefix)
+}
+
+// Occurrence records one class token found in a source file.
+type Occurrence struct {
+	Class  string `json:"class"`
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+
+	// Suppressed is set when a cssguard-ignore comment silences orphan
+	// reports for this occurrence.
+	Suppressed bool `json:"suppressed,omitempty"`
+}
+
+// ClassSet returns the set of class names used by occs.
+func ClassSet(occs []Occurrence) map[string]struct{} {
+	classes := make(map[string]struct{}, len(occs))
+	for _, o := range occs {
+		classes[o.Class] = struct{}{}
+	}
+	return classes
+}
+
 // Options configures source scanning behavior.
 type Options struct {
-	Extensions []string // File extensions to scan (e.g., ".tsx")
-	Excludes   []string // Directories to exclude (e.g., "node_modules")
+	Extensions []string     // File extensions to scan (e.g., ".tsx")
+	Excludes   []string     // Directories to exclude (e.g., "node_modules")
+	Jobs       int          // Files to scan at a time; 0 means one per CPU
+	Cache      *cache.Cache // Reuses the occurrences of unchanged files; may be nil
 }
 
 // DefaultOptions returns the default scanning options.
@@ -66,7 +111,17 @@ func New(opts Options) *Scanner {
 
 // ScanPaths scans the given paths (files or directories) and returns all found class tokens.
 func (s *Scanner) ScanPaths(paths []string) (map[string]struct{}, error) {
-	classes := make(map[string]struct{})
+	occs, err := s.ScanOccurrences(paths)
+	if err != nil {
+		return nil, err
+	}
+	return ClassSet(occs), nil
+}
+
+// ScanOccurrences scans the given paths (files or directories) and returns
+// every class token found, ordered by path and then by position.
+func (s *Scanner) ScanOccurrences(paths []string) ([]Occurrence, error) {
+	var occs []Occurrence
 
 	for _, path := range paths {
 		info, err := os.Stat(path)
@@ -75,130 +130,213 @@ func (s *Scanner) ScanPaths(paths []string) (map[string]struct{}, error) {
 		}
 
 		if info.IsDir() {
-			dirClasses, err := s.scanDir(path)
+			dirOccs, err := s.scanDirOccurrences(path)
 			if err != nil {
 				return nil, err
 			}
-			for c := range dirClasses {
-				classes[c] = struct{}{}
-			}
+			occs = append(occs, dirOccs...)
 		} else {
-			fileClasses, err := s.scanFile(path)
+			fileOccs, err := s.scanCached(path)
 			if err != nil {
 				continue // Skip files that can't be read
 			}
-			for c := range fileClasses {
-				classes[c] = struct{}{}
-			}
+			occs = append(occs, fileOccs...)
 		}
 	}
 
-	return classes, nil
+	return occs, nil
 }
 
 // scanDir recursively scans a directory for source files.
 func (s *Scanner) scanDir(dir string) (map[string]struct{}, error) {
-	classes := make(map[string]struct{})
+	occs, err := s.scanDirOccurrences(dir)
+	if err != nil {
+		return nil, err
+	}
+	return ClassSet(occs), nil
+}
 
+// scanDirOccurrences recursively scans a directory for class token
+// occurrences, scanning files in parallel.
+func (s *Scanner) scanDirOccurrences(dir string) ([]Occurrence, error) {
+	var paths []string
 	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil // Skip errors
 		}
 
-		// Check for excluded directories
-		if info.IsDir() {
-			for _, exclude := range s.opts.Excludes {
-				if info.Name() == exclude {
-					return filepath.SkipDir
-				}
+		if !s.Includes(path, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
 			}
 			return nil
 		}
-
-		// Check file extension
-		ext := strings.ToLower(filepath.Ext(path))
-		hasExt := false
-		for _, e := range s.opts.Extensions {
-			if ext == e {
-				hasExt = true
-				break
-			}
-		}
-		if !hasExt {
-			return nil
+		if !info.IsDir() {
+			paths = append(paths, path)
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		// Scan the file
-		fileClasses, err := s.scanFile(path)
+	perFile, _ := parallel.Map(paths, s.opts.Jobs, func(path string) ([]Occurrence, error) {
+		occs, err := s.scanCached(path)
 		if err != nil {
-			return nil // Skip files that can't be read
+			return nil, nil // Skip files that can't be read
 		}
-		for c := range fileClasses {
-			classes[c] = struct{}{}
-		}
-
-		return nil
+		return occs, nil
 	})
+	return parallel.Concat(perFile), nil
+}
 
-	return classes, err
+// Includes reports whether a directory scan descends into a directory or
+// scans a file: directories must not be excluded and files need one of the
+// scanned extensions.
+func (s *Scanner) Includes(path string, isDir bool) bool {
+	if isDir {
+		name := filepath.Base(path)
+		for _, exclude := range s.opts.Excludes {
+			if name == exclude {
+				return false
+			}
+		}
+		return true
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range s.opts.Extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
 }
 
 // scanFile extracts class tokens from a single source file.
 func (s *Scanner) scanFile(path string) (map[string]struct{}, error) {
+	occs, err := s.ScanFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ClassSet(occs), nil
+}
+
+// ScanFile extracts class token occurrences from a single source file,
+// whatever its extension.
+func (s *Scanner) ScanFile(path string) ([]Occurrence, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
+	return s.ScanReader(f, path)
+}
 
-	classes := make(map[string]struct{})
-	scanner := bufio.NewScanner(f)
+// scanCached is ScanFile served from the cache when the file is unchanged.
+func (s *Scanner) scanCached(path string) ([]Occurrence, error) {
+	return cache.Load(s.opts.Cache, cache.KindSrc, path, s.ScanReader)
+}
+
+// ScanReader extracts class token occurrences from source code read from r.
+// File is recorded on each occurrence as given. A // cssguard-ignore comment
+// suppresses the tokens on its line and // cssguard-ignore-next-line those on
+// the following line.
+func (s *Scanner) ScanReader(r io.Reader, file string) ([]Occurrence, error) {
+	var occs []Occurrence
+	scanner := bufio.NewScanner(r)
 
 	// Increase buffer for long lines
 	buf := make([]byte, 0, 64*1024)
 	scanner.Buffer(buf, 1024*1024) // 1MB max line
 
-	for scanner.Scan() {
+	var fromPreviousLine *suppress.Directive
+	for lineNum := 1; scanner.Scan(); lineNum++ {
 		line := scanner.Text()
 
-		// Extract from class/className attributes (only quoted strings, not template literals)
-		matches := classAttrRegex.FindAllStringSubmatch(line, -1)
-		for _, match := range matches {
-			if len(match) > 1 {
-				// Skip if the captured value contains interpolation markers
-				if strings.Contains(match[1], "${") || strings.Contains(match[1], "` +") {
-					continue
-				}
-				extractTokens(match[1], classes)
+		// Suppression comments for this line
+		directives := []*suppress.Directive{fromPreviousLine}
+		fromPreviousLine = nil
+		if d, ok := suppress.FindInLine(line); ok {
+			if d.NextLine {
+				fromPreviousLine = d
+			} else {
+				directives = append(directives, d)
 			}
 		}
 
-		// Extract from helper functions (only string literal arguments)
-		matches = helperRegex.FindAllStringSubmatch(line, -1)
-		for _, match := range matches {
-			if len(match) > 1 {
-				// Skip if the captured value contains interpolation markers
-				if strings.Contains(match[1], "${") || strings.Contains(match[1], "` +") {
-					continue
-				}
-				extractTokens(match[1], classes)
+		addTokens := func(start, end int) {
+			value := line[start:end]
+			// Skip if the captured value contains interpolation markers
+			if strings.Contains(value, "${") || strings.Contains(value, "` +") {
+				return
+			}
+			for _, t := range tokenSpans(value) {
+				offset := start + t.offset
+				occs = append(occs, Occurrence{
+					Class:      t.token,
+					File:       file,
+					Line:       lineNum,
+					Column:     utf8.RuneCountInString(line[:offset]) + 1,
+					Suppressed: covers(directives, t.token),
+				})
 			}
 		}
+
+		// Extract from class/className attributes (only quoted strings, not template literals)
+		for _, match := range classAttrRegex.FindAllStringSubmatchIndex(line, -1) {
+			addTokens(match[2], match[3])
+		}
+
+		// Extract from helper functions (only string literal arguments)
+		for _, match := range helperRegex.FindAllStringSubmatchIndex(line, -1) {
+			addTokens(match[2], match[3])
+		}
 	}
 
-	return classes, scanner.Err()
+	return occs, scanner.Err()
+}
+
+// covers reports whether any of the directives suppresses class.
+func covers(directives []*suppress.Directive, class string) bool {
+	for _, d := range directives {
+		if d.Covers(class) {
+			return true
+		}
+	}
+	return false
 }
 
 // extractTokens splits a class string and adds valid tokens to the set.
 func extractTokens(s string, classes map[string]struct{}) {
-	tokens := strings.Fields(s)
-	for _, token := range tokens {
-		token = strings.TrimSpace(token)
+	for _, t := range tokenSpans(s) {
+		classes[t.token] = struct{}{}
+	}
+}
+
+// tokenSpan is a class token and its byte offset in the class string.
+type tokenSpan struct {
+	token  string
+	offset int
+}
 
-		// Skip empty tokens
-		if token == "" {
+// tokenSpans splits a class string into valid class tokens.
+func tokenSpans(s string) []tokenSpan {
+	var spans []tokenSpan
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if unicode.IsSpace(r) {
+			i += size
 			continue
 		}
+		start := i
+		for i < len(s) {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if unicode.IsSpace(r) {
+				break
+			}
+			i += size
+		}
+		token := s[start:i]
 
 		// Skip tokens that are too long (sanity limit)
 		if len(token) > 128 {
@@ -210,8 +348,9 @@ func extractTokens(s string, classes map[string]struct{}) {
 			continue
 		}
 
-		classes[token] = struct{}{}
+		spans = append(spans, tokenSpan{token: token, offset: start})
 	}
+	return spans
 }
 
 // ParseExtensions parses a comma-separated list of extensions.
@@ -1,8 +1,11 @@
 package srcscan
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -22,6 +25,11 @@ func TestExtractTokens(t *testing.T) {
 			input:    "w-[100px] bg-[#ff0000] text-[14px]",
 			expected: []string{"w-[100px]", "bg-[#ff0000]", "text-[14px]"},
 		},
+		{
+			name:     "tailwind important and arbitrary properties",
+			input:    "!mt-0 mt-0! w-[calc(100%-2rem)] [mask-type:luminance] [&>*]:p-4",
+			expected: []string{"!mt-0", "mt-0!", "w-[calc(100%-2rem)]", "[mask-type:luminance]", "[&>*]:p-4"},
+		},
 		{
 			name:     "negative margins",
 			input:    "-mt-4 -translate-x-1/2",
@@ -248,3 +256,94 @@ func TestParseExcludes(t *testing.T) {
 		}
 	}
 }
+
+func TestScanOccurrences_Suppression(t *testing.T) {
+	content := `export function Menu() {
+  return <nav className="menu js-menu">{/* cssguard-ignore: js-menu */}
+    {/* cssguard-ignore-next-line */}
+    <button className="js-toggle btn">Open</button>
+    <span className="js-menu">x</span>
+  </nav>;
+}
+`
+	tmpFile := filepath.Join(t.TempDir(), "menu.tsx")
+	if err := os.WriteFile(tmpFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	occs, err := New(DefaultOptions()).ScanOccurrences([]string{tmpFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Occurrence{
+		{Class: "menu", File: tmpFile, Line: 2, Column: 26},
+		{Class: "js-menu", File: tmpFile, Line: 2, Column: 31, Suppressed: true},
+		{Class: "js-toggle", File: tmpFile, Line: 4, Column: 24, Suppressed: true},
+		{Class: "btn", File: tmpFile, Line: 4, Column: 34, Suppressed: true},
+		{Class: "js-menu", File: tmpFile, Line: 5, Column: 22},
+	}
+	if len(occs) != len(expected) {
+		t.Fatalf("got %d occurrences %+v, want %d", len(occs), occs, len(expected))
+	}
+	for i, want := range expected {
+		if occs[i] != want {
+			t.Errorf("occurrence %d = %+v, want %+v", i, occs[i], want)
+		}
+	}
+}
+
+// writeComponents writes n JSX components into nested directories.
+func writeComponents(tb testing.TB, dir string, n int) {
+	tb.Helper()
+	var src strings.Builder
+	src.WriteString("export function Card() {\n  return (\n")
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&src, "    <div className=\"flex p-%d card-%d\">{clsx(\"btn\", \"btn-%d\")}</div>\n", i%8, i%50, i%5)
+	}
+	src.WriteString("  )\n}\n")
+	for i := 0; i < n; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("feature-%d", i%10), fmt.Sprintf("Card%d.tsx", i))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			tb.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src.String()), 0644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+func TestScanOccurrences_Deterministic(t *testing.T) {
+	dir := t.TempDir()
+	writeComponents(t, dir, 40)
+
+	want, err := New(Options{Jobs: 1}).ScanOccurrences([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, jobs := range []int{0, 4, 16} {
+		got, err := New(Options{Jobs: jobs}).ScanOccurrences([]string{dir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("jobs=%d: occurrences differ from a sequential run", jobs)
+		}
+	}
+}
+
+func BenchmarkScanOccurrences(b *testing.B) {
+	dir := b.TempDir()
+	writeComponents(b, dir, 300)
+
+	for _, jobs := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("jobs=%d", jobs), func(b *testing.B) {
+			s := New(Options{Jobs: jobs})
+			for i := 0; i < b.N; i++ {
+				if _, err := s.ScanOccurrences([]string{dir}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
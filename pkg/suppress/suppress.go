@@ -0,0 +1,101 @@
+// Package suppress parses inline cssguard-ignore comments, which silence
+// orphan reports for one element or line in HTML and source files.
+package suppress
+
+import "strings"
+
+// Comment markers. Either may be followed by a colon and the classes to
+// suppress; without a list every class is suppressed. Text after " -- " is a
+// reason and is ignored.
+const (
+	Ignore         = "cssguard-ignore"           // This element (HTML) or line (source)
+	IgnoreNextLine = "cssguard-ignore-next-line" // The following line
+)
+
+// commentOpeners start the comments a directive may appear in, in source files.
+var commentOpeners = []string{"//", "/*", "<!--"}
+
+// Directive is a parsed cssguard-ignore comment.
+type Directive struct {
+	NextLine bool                // Applies to the following line
+	Classes  map[string]struct{} // Classes to suppress; nil suppresses all
+}
+
+// Covers reports whether the directive suppresses class.
+func (d *Directive) Covers(class string) bool {
+	if d == nil {
+		return false
+	}
+	if d.Classes == nil {
+		return true
+	}
+	_, ok := d.Classes[class]
+	return ok
+}
+
+// Parse parses the text of a comment, without its delimiters.
+func Parse(comment string) (*Directive, bool) {
+	text := strings.TrimSpace(comment)
+	text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(text, "-->"), "*/"))
+
+	d := &Directive{}
+	switch {
+	case strings.HasPrefix(text, IgnoreNextLine):
+		d.NextLine = true
+		text = text[len(IgnoreNextLine):]
+	case strings.HasPrefix(text, Ignore):
+		text = text[len(Ignore):]
+	default:
+		return nil, false
+	}
+
+	if reason := strings.Index(text, " -- "); reason >= 0 {
+		text = text[:reason]
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return d, true
+	}
+	if text[0] != ':' {
+		return nil, false // e.g. cssguard-ignored
+	}
+
+	d.Classes = make(map[string]struct{})
+	for _, class := range strings.FieldsFunc(text[1:], isSeparator) {
+		d.Classes[class] = struct{}{}
+	}
+	return d, true
+}
+
+// FindInLine finds a directive in a line of source code, inside a //, /* */
+// or <!-- --> comment.
+func FindInLine(line string) (*Directive, bool) {
+	if !strings.Contains(line, Ignore) {
+		return nil, false
+	}
+	for _, opener := range commentOpeners {
+		rest := line
+		for {
+			i := strings.Index(rest, opener)
+			if i < 0 {
+				break
+			}
+			rest = rest[i+len(opener):]
+			comment := rest
+			if end := strings.Index(comment, "*/"); end >= 0 {
+				comment = comment[:end]
+			}
+			if end := strings.Index(comment, "-->"); end >= 0 {
+				comment = comment[:end]
+			}
+			if d, ok := Parse(comment); ok {
+				return d, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func isSeparator(r rune) bool {
+	return r == ',' || r == ' ' || r == '\t'
+}
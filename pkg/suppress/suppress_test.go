@@ -0,0 +1,83 @@
+package suppress
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		comment  string
+		ok       bool
+		nextLine bool
+		classes  []string // nil: all classes
+	}{
+		{comment: " cssguard-ignore ", ok: true},
+		{comment: "cssguard-ignore: js-toggle", ok: true, classes: []string{"js-toggle"}},
+		{comment: " cssguard-ignore: js-toggle, js-menu modal-open ", ok: true, classes: []string{"js-toggle", "js-menu", "modal-open"}},
+		{comment: "cssguard-ignore-next-line", ok: true, nextLine: true},
+		{comment: "cssguard-ignore-next-line: js-toggle -- toggled by menu.js", ok: true, nextLine: true, classes: []string{"js-toggle"}},
+		{comment: "cssguard-ignore -- third-party widget", ok: true},
+		{comment: "cssguard-ignored", ok: false},
+		{comment: "see cssguard-ignore", ok: false},
+		{comment: "TODO: tidy up", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.comment, func(t *testing.T) {
+			d, ok := Parse(tt.comment)
+			if ok != tt.ok {
+				t.Fatalf("Parse() ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if d.NextLine != tt.nextLine {
+				t.Errorf("NextLine = %v, want %v", d.NextLine, tt.nextLine)
+			}
+			if tt.classes == nil {
+				if d.Classes != nil {
+					t.Errorf("Classes = %v, want all", d.Classes)
+				}
+				return
+			}
+			if len(d.Classes) != len(tt.classes) {
+				t.Errorf("Classes = %v, want %v", d.Classes, tt.classes)
+			}
+			for _, class := range tt.classes {
+				if !d.Covers(class) {
+					t.Errorf("directive does not cover %q", class)
+				}
+			}
+		})
+	}
+}
+
+func TestFindInLine(t *testing.T) {
+	tests := []struct {
+		line     string
+		ok       bool
+		nextLine bool
+		covers   string
+	}{
+		{line: `el.classList.add("js-open") // cssguard-ignore`, ok: true, covers: "js-open"},
+		{line: `<div className="js-x">{/* cssguard-ignore: js-x */}</div>`, ok: true, covers: "js-x"},
+		{line: `  // cssguard-ignore-next-line: js-y`, ok: true, nextLine: true, covers: "js-y"},
+		{line: `<!-- cssguard-ignore-next-line -->`, ok: true, nextLine: true, covers: "anything"},
+		{line: `const url = "https://example.com" // cssguard-ignore: a`, ok: true, covers: "a"},
+		{line: `const s = "cssguard-ignore"`, ok: false},
+		{line: `// plain comment`, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			d, ok := FindInLine(tt.line)
+			if ok != tt.ok {
+				t.Fatalf("FindInLine() ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if d.NextLine != tt.nextLine || !d.Covers(tt.covers) {
+				t.Errorf("FindInLine() = %+v, want next line %v covering %q", d, tt.nextLine, tt.covers)
+			}
+		})
+	}
+}
@@ -27,10 +27,41 @@ type Pattern struct {
 
 // Config represents the trained configuration.
 type Config struct {
-	Version        string    `json:"version"`
-	Patterns       []Pattern `json:"patterns"`
-	LiteralClasses []string  `json:"literal_classes"` // Classes that don't fit patterns
-	Ignored        []string  `json:"ignored"`         // Classes to always ignore
+	Version          string    `json:"version"`
+	Patterns         []Pattern `json:"patterns"`
+	LiteralClasses   []string  `json:"literal_classes"`             // Classes that don't fit patterns
+	Variants         []string  `json:"variants,omitempty"`          // Variant prefixes seen in the CSS (md, hover, dark, ...)
+	ModifierBases    []string  `json:"modifier_bases,omitempty"`    // Base utilities the CSS only defines behind a modifier (flex of md:flex)
+	ModifierPatterns []Pattern `json:"modifier_patterns,omitempty"` // Patterns over those bases
+	Important        bool      `json:"important,omitempty"`         // CSS contains important-modifier utilities (!mt-0)
+	Ignored          []string  `json:"ignored"`                     // Classes to always ignore
+	IgnoreRules      []Rule    `json:"ignore_rules,omitempty"`      // Orphans to suppress by glob or regex
+	Safelist         []Rule    `json:"safelist,omitempty"`          // CSS classes that may be unused
+
+	// Contexts maps the classes defined only inside at-rules (@media,
+	// @supports, ...) to those at-rules, and PrintOnly lists the classes
+	// defined only for print.
+	Contexts  map[string][]string `json:"contexts,omitempty"`
+	PrintOnly []string            `json:"print_only,omitempty"`
+}
+
+// Rule matches class names by glob or regex. In a glob, * matches any run of
+// characters and ? a single character; everything else, including the
+// brackets of arbitrary values, is literal. A regex must match the whole
+// class name. A rule stops applying after its expiry date.
+type Rule struct {
+	Glob    string `json:"glob,omitempty" yaml:"glob,omitempty"`
+	Regex   string `json:"regex,omitempty" yaml:"regex,omitempty"`
+	Reason  string `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Expires string `json:"expires,omitempty" yaml:"expires,omitempty"` // YYYY-MM-DD, last day the rule applies
+}
+
+// Pattern returns the rule's glob, or its regex between slashes.
+func (r Rule) Pattern() string {
+	if r.Regex != "" {
+		return "/" + r.Regex + "/"
+	}
+	return r.Glob
 }
 
 // Trainer learns regex patterns from CSS class names.
@@ -56,42 +87,113 @@ func (t *Trainer) AddClasses(classes map[string]struct{}) {
 	}
 }
 
-// Train generates regex patterns from the collected classes.
-func (t *Trainer) Train() *Config {
-	// Group classes by prefix patterns
-	prefixGroups := t.groupByPrefix()
-
-	// Generate patterns for each group
-	for prefix, classes := range prefixGroups {
-		if len(classes) >= 3 { // Only create patterns for groups with 3+ classes
-			pattern := t.generatePattern(prefix, classes)
-			if pattern != nil {
-				t.config.Patterns = append(t.config.Patterns, *pattern)
-			}
-		} else {
-			// Add as literal classes
-			t.config.LiteralClasses = append(t.config.LiteralClasses, classes...)
+// AddContexts records the at-rule contexts of the classes defined only
+// inside at-rules, and the classes defined only for print.
+func (t *Trainer) AddContexts(contexts map[string][]string, printOnly map[string]struct{}) {
+	for class, list := range contexts {
+		if t.config.Contexts == nil {
+			t.config.Contexts = make(map[string][]string)
 		}
+		t.config.Contexts[class] = list
 	}
+	for class := range printOnly {
+		t.config.PrintOnly = append(t.config.PrintOnly, class)
+	}
+	sort.Strings(t.config.PrintOnly)
+}
+
+// Train generates regex patterns from the collected classes. Variant chains
+// and important modifiers are split off first: patterns and literals describe
+// the base utilities the CSS defines without modifiers, and the modifiers are
+// recorded separately so any known variant can prefix any known utility.
+// Bases the CSS only defines behind a modifier (flex of md:flex) get patterns
+// and literals of their own, which only prefixed classes are checked against.
+// Arbitrary-value classes are always kept as literals.
+func (t *Trainer) Train() *Config {
+	plain, modified, variants, important := t.splitVariants()
+	for v := range variants {
+		t.config.Variants = append(t.config.Variants, v)
+	}
+	t.config.Important = important
+
+	t.config.Patterns, t.config.LiteralClasses = t.learn(plain)
+	t.config.ModifierPatterns, t.config.ModifierBases = t.learn(modified)
 
 	// Add well-known Tailwind patterns
 	t.addTailwindPatterns()
 
 	// Sort for deterministic output
-	sort.Slice(t.config.Patterns, func(i, j int) bool {
-		return t.config.Patterns[i].Name < t.config.Patterns[j].Name
-	})
+	for _, patterns := range [][]Pattern{t.config.Patterns, t.config.ModifierPatterns} {
+		sort.Slice(patterns, func(i, j int) bool {
+			return patterns[i].Name < patterns[j].Name
+		})
+	}
 	sort.Strings(t.config.LiteralClasses)
+	sort.Strings(t.config.ModifierBases)
+	sort.Strings(t.config.Variants)
 
 	return t.config
 }
 
+// learn generates patterns for the groups of similar classes and returns the
+// classes that fit no pattern as literals. Arbitrary values never generalize
+// to a pattern.
+func (t *Trainer) learn(classes map[string]struct{}) (patterns []Pattern, literals []string) {
+	rest := make(map[string]struct{}, len(classes))
+	for class := range classes {
+		if IsArbitrary(class) {
+			literals = append(literals, class)
+		} else {
+			rest[class] = struct{}{}
+		}
+	}
+
+	// Group classes by prefix patterns
+	for prefix, group := range groupByPrefix(rest) {
+		if len(group) >= 3 { // Only create patterns for groups with 3+ classes
+			if pattern := t.generatePattern(prefix, group); pattern != nil {
+				patterns = append(patterns, *pattern)
+			}
+		} else {
+			literals = append(literals, group...)
+		}
+	}
+	return patterns, literals
+}
+
+// splitVariants separates the collected classes into the base utilities
+// defined without modifiers, those defined only behind a variant or the
+// important modifier, and the set of variants that prefix them, and reports
+// whether any class carries the important modifier.
+func (t *Trainer) splitVariants() (plain, modified, variants map[string]struct{}, important bool) {
+	plain = make(map[string]struct{})
+	modified = make(map[string]struct{})
+	variants = make(map[string]struct{})
+	for class := range t.classes {
+		vs, base := SplitVariants(class)
+		base, imp := SplitImportant(base)
+		important = important || imp
+		if len(vs) == 0 && !imp {
+			plain[base] = struct{}{}
+		} else {
+			modified[base] = struct{}{}
+		}
+		for _, v := range vs {
+			variants[v] = struct{}{}
+		}
+	}
+	for base := range plain {
+		delete(modified, base)
+	}
+	return plain, modified, variants, important
+}
+
 // groupByPrefix groups classes by their prefix (before first number or dash-number).
-func (t *Trainer) groupByPrefix() map[string][]string {
+func groupByPrefix(classes map[string]struct{}) map[string][]string {
 	groups := make(map[string][]string)
 	prefixRegex := regexp.MustCompile(`^([a-zA-Z-]+?)(?:-?\d|$)`)
 
-	for class := range t.classes {
+	for class := range classes {
 		match := prefixRegex.FindStringSubmatch(class)
 		var prefix string
 		if len(match) > 1 {
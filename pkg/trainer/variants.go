@@ -0,0 +1,60 @@
+package trainer
+
+import "strings"
+
+// SplitVariants splits a utility class into its variant chain and base
+// utility, e.g. "md:hover:bg-blue-500" -> ["md", "hover"], "bg-blue-500".
+// Colons inside [] or () (arbitrary values and variants) do not split.
+// A class without variants returns nil and the class unchanged.
+func SplitVariants(class string) (variants []string, base string) {
+	depth := 0
+	start := 0
+	for i := 0; i < len(class); i++ {
+		switch class[i] {
+		case '[', '(':
+			depth++
+		case ']', ')':
+			if depth > 0 {
+				depth--
+			}
+		case ':':
+			if depth == 0 {
+				variants = append(variants, class[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if len(variants) == 0 || start == len(class) {
+		return nil, class
+	}
+	for _, v := range variants {
+		if v == "" {
+			return nil, class
+		}
+	}
+	return variants, class[start:]
+}
+
+// SplitImportant strips Tailwind's important modifier from a base utility,
+// written as a prefix (!mt-0, v3) or a suffix (mt-0!, v4).
+func SplitImportant(base string) (utility string, important bool) {
+	if len(base) < 2 {
+		return base, false
+	}
+	if strings.HasPrefix(base, "!") {
+		return base[1:], true
+	}
+	if strings.HasSuffix(base, "!") {
+		return base[:len(base)-1], true
+	}
+	return base, false
+}
+
+// IsArbitrary reports whether a base utility carries an arbitrary value
+// (bg-[#1da1f2], w-[calc(100%-2rem)]) or is an arbitrary property
+// ([mask-type:luminance]). Such classes only exist in CSS verbatim, so they
+// can never be inferred from a pattern.
+func IsArbitrary(base string) bool {
+	open := strings.IndexByte(base, '[')
+	return open >= 0 && strings.IndexByte(base[open:], ']') > 0
+}
@@ -0,0 +1,114 @@
+package trainer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitVariants(t *testing.T) {
+	tests := []struct {
+		class    string
+		variants []string
+		base     string
+	}{
+		{class: "flex", variants: nil, base: "flex"},
+		{class: "md:hover:bg-blue-500", variants: []string{"md", "hover"}, base: "bg-blue-500"},
+		{class: "dark:text-white", variants: []string{"dark"}, base: "text-white"},
+		{class: "group-hover:opacity-100", variants: []string{"group-hover"}, base: "opacity-100"},
+		{class: "bg-[url(a:b)]", variants: nil, base: "bg-[url(a:b)]"},
+		{class: "supports-[display:grid]:grid", variants: []string{"supports-[display:grid]"}, base: "grid"},
+		{class: "[&>*]:p-4", variants: []string{"[&>*]"}, base: "p-4"},
+		{class: "md:", variants: nil, base: "md:"},
+		{class: ":flex", variants: nil, base: ":flex"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.class, func(t *testing.T) {
+			variants, base := SplitVariants(tt.class)
+			if strings.Join(variants, ",") != strings.Join(tt.variants, ",") || base != tt.base {
+				t.Errorf("SplitVariants(%q) = %v, %q; want %v, %q",
+					tt.class, variants, base, tt.variants, tt.base)
+			}
+		})
+	}
+}
+
+func TestTrainRecordsVariants(t *testing.T) {
+	tr := New()
+	tr.AddClasses(map[string]struct{}{
+		"flex": {}, "md:flex": {}, "hover:underline": {}, "md:hover:underline": {},
+	})
+	config := tr.Train()
+
+	if strings.Join(config.Variants, ",") != "hover,md" {
+		t.Errorf("Variants = %v, want [hover md]", config.Variants)
+	}
+	for _, literal := range config.LiteralClasses {
+		if strings.Contains(literal, ":") {
+			t.Errorf("literal %q still carries a variant", literal)
+		}
+	}
+}
+
+func TestSplitImportant(t *testing.T) {
+	tests := []struct {
+		base      string
+		utility   string
+		important bool
+	}{
+		{base: "mt-0", utility: "mt-0"},
+		{base: "!mt-0", utility: "mt-0", important: true},
+		{base: "mt-0!", utility: "mt-0", important: true},
+		{base: "!-mt-4", utility: "-mt-4", important: true},
+		{base: "!", utility: "!"},
+	}
+
+	for _, tt := range tests {
+		utility, important := SplitImportant(tt.base)
+		if utility != tt.utility || important != tt.important {
+			t.Errorf("SplitImportant(%q) = %q, %v; want %q, %v",
+				tt.base, utility, important, tt.utility, tt.important)
+		}
+	}
+}
+
+func TestTrainKeepsArbitraryValuesLiteral(t *testing.T) {
+	tr := New()
+	tr.AddClasses(map[string]struct{}{
+		"bg-[#111]": {}, "bg-[#222]": {}, "bg-[#333]": {}, "!mt-0": {},
+	})
+	config := tr.Train()
+
+	literals := strings.Join(config.LiteralClasses, ",")
+	if literals != "bg-[#111],bg-[#222],bg-[#333]" {
+		t.Errorf("LiteralClasses = %v", config.LiteralClasses)
+	}
+	if strings.Join(config.ModifierBases, ",") != "mt-0" {
+		t.Errorf("ModifierBases = %v, want [mt-0]", config.ModifierBases)
+	}
+	if !config.Important {
+		t.Error("Important = false, want true")
+	}
+}
+
+func TestTrainSeparatesModifierOnlyBases(t *testing.T) {
+	tr := New()
+	tr.AddClasses(map[string]struct{}{
+		"md:flex": {}, "hover:underline": {}, "p-4": {}, "md:p-4": {},
+	})
+	config := tr.Train()
+
+	if got := strings.Join(config.LiteralClasses, ","); got != "p-4" {
+		t.Errorf("LiteralClasses = %v, want [p-4]", config.LiteralClasses)
+	}
+	if got := strings.Join(config.ModifierBases, ","); got != "flex,underline" {
+		t.Errorf("ModifierBases = %v, want [flex underline]", config.ModifierBases)
+	}
+	hasDisplay := false
+	for _, p := range config.Patterns {
+		hasDisplay = hasDisplay || p.Name == "display"
+	}
+	if !hasDisplay {
+		t.Error("built-in display pattern missing")
+	}
+}
@@ -0,0 +1,63 @@
+package validator
+
+import (
+	"sort"
+
+	"github.com/JCorners68/cssguard/pkg/trainer"
+)
+
+// AddContexts records the at-rule contexts of the used classes that are
+// defined only inside at-rules, and where the classes defined only for print
+// are used. contexts maps classes defined only inside at-rules to those
+// at-rules, printOnly holds the classes defined only for print and used the
+// uses of each class. Classes with a print variant (print:hidden) are meant
+// for print and not flagged.
+func (r *Result) AddContexts(contexts map[string][]string, printOnly map[string]struct{}, used map[string][]Location) {
+	for class, locs := range used {
+		if len(locs) == 0 {
+			continue
+		}
+		if list, ok := contexts[class]; ok {
+			if r.Contexts == nil {
+				r.Contexts = make(map[string][]string)
+			}
+			r.Contexts[class] = list
+		}
+		if _, ok := printOnly[class]; ok && !hasVariant(class, "print") {
+			if r.PrintOnly == nil {
+				r.PrintOnly = make(map[string][]Location)
+			}
+			r.PrintOnly[class] = append(r.PrintOnly[class], locs...)
+		}
+	}
+}
+
+// PrintOnlyClasses returns the used classes defined only for print, sorted.
+func (r *Result) PrintOnlyClasses() []string {
+	classes := make([]string, 0, len(r.PrintOnly))
+	for class := range r.PrintOnly {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	return classes
+}
+
+// Contexts returns the at-rule contexts and print-only classes recorded in
+// the trained config.
+func (v *Validator) Contexts() (contexts map[string][]string, printOnly map[string]struct{}) {
+	printOnly = make(map[string]struct{}, len(v.config.PrintOnly))
+	for _, class := range v.config.PrintOnly {
+		printOnly[class] = struct{}{}
+	}
+	return v.config.Contexts, printOnly
+}
+
+func hasVariant(class, variant string) bool {
+	variants, _ := trainer.SplitVariants(class)
+	for _, v := range variants {
+		if v == variant {
+			return true
+		}
+	}
+	return false
+}
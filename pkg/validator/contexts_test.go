@@ -0,0 +1,51 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/JCorners68/cssguard/pkg/trainer"
+)
+
+func TestAddContexts(t *testing.T) {
+	contexts := map[string][]string{
+		"receipt":      {"@media print"},
+		"grid":         {"@supports (display: grid)"},
+		"print:hidden": {"@media print"},
+		"unused":       {"@media print"},
+	}
+	printOnly := setOf("receipt", "print:hidden", "unused")
+	used := map[string][]Location{
+		"receipt":      {{File: "order.html", Line: 7}, {File: "cart.html", Line: 2}},
+		"grid":         {{File: "index.html", Line: 3}},
+		"print:hidden": {{File: "index.html", Line: 9}},
+		"flex":         {{File: "index.html", Line: 3}},
+	}
+
+	r := &Result{}
+	r.AddContexts(contexts, printOnly, used)
+
+	if len(r.Contexts) != 3 || r.Contexts["grid"][0] != "@supports (display: grid)" {
+		t.Errorf("Contexts = %v, want receipt, grid and print:hidden", r.Contexts)
+	}
+	if got := r.PrintOnlyClasses(); len(got) != 1 || got[0] != "receipt" {
+		t.Errorf("PrintOnlyClasses() = %v, want [receipt]", got)
+	}
+	if locs := r.PrintOnly["receipt"]; len(locs) != 2 {
+		t.Errorf("PrintOnly[receipt] = %v, want both uses", locs)
+	}
+}
+
+func TestValidatorContexts(t *testing.T) {
+	tr := trainer.New()
+	tr.AddClasses(setOf("receipt", "flex"))
+	tr.AddContexts(map[string][]string{"receipt": {"@media print"}}, setOf("receipt"))
+	v, err := New(tr.Train())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contexts, printOnly := v.Contexts()
+	if len(contexts) != 1 || len(printOnly) != 1 {
+		t.Errorf("Contexts() = %v, %v, want receipt in both", contexts, printOnly)
+	}
+}
@@ -0,0 +1,157 @@
+package validator
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PageReport is the result for one HTML page.
+type PageReport struct {
+	File            string   `json:"file"`
+	Classes         int      `json:"classes"` // Distinct classes the page uses
+	Matched         int      `json:"matched"`
+	Orphans         []string `json:"orphans"`
+	OrphanCount     int      `json:"orphan_count"`
+	CoveragePercent float64  `json:"coverage_percent"` // Matched / classes
+	CSSClasses      []string `json:"css_classes"`      // CSS classes the page uses
+	CSSPercent      float64  `json:"css_percent"`      // CSS classes used / all CSS classes
+}
+
+// DirectoryReport sums up the pages of a directory.
+type DirectoryReport struct {
+	Dir             string   `json:"dir"` // e.g. "/blog/"; "/" for pages at the top
+	Pages           int      `json:"pages"`
+	OrphanPages     int      `json:"orphan_pages"` // Pages with orphans
+	Classes         int      `json:"classes"`      // Distinct classes the pages use
+	Matched         int      `json:"matched"`
+	Orphans         []string `json:"orphans"`
+	OrphanCount     int      `json:"orphan_count"`
+	CoveragePercent float64  `json:"coverage_percent"`
+	CSSClasses      int      `json:"css_classes"` // Distinct CSS classes the pages use
+	CSSPercent      float64  `json:"css_percent"`
+}
+
+// SiteReport breaks a result down by page and by directory.
+type SiteReport struct {
+	Pages       []PageReport      `json:"pages"`       // Most orphans first
+	Directories []DirectoryReport `json:"directories"` // Most orphans first
+}
+
+// PerPage breaks the result down by page. pages maps each HTML file to the
+// classes it uses, leaving out uses silenced by inline comments, and
+// cssClasses holds every class the CSS defines. A class is an orphan on a
+// page if it is one of the result's orphans. Pages are grouped by their
+// first directory below root, so root/blog/2024/post.html counts towards
+// "/blog/".
+func (r *Result) PerPage(root string, pages map[string]map[string]struct{}, cssClasses map[string]struct{}) *SiteReport {
+	orphans := make(map[string]struct{}, len(r.Orphans))
+	for _, class := range r.Orphans {
+		orphans[class] = struct{}{}
+	}
+
+	type dirTotals struct {
+		report          DirectoryReport
+		classes, orphan map[string]struct{}
+		css             map[string]struct{}
+	}
+	dirs := make(map[string]*dirTotals)
+
+	site := &SiteReport{}
+	for file, classes := range pages {
+		page := PageReport{File: file, Classes: len(classes)}
+		for class := range classes {
+			if _, ok := orphans[class]; ok {
+				page.Orphans = append(page.Orphans, class)
+				continue
+			}
+			page.Matched++
+			if _, ok := cssClasses[class]; ok {
+				page.CSSClasses = append(page.CSSClasses, class)
+			}
+		}
+		sort.Strings(page.Orphans)
+		sort.Strings(page.CSSClasses)
+		page.OrphanCount = len(page.Orphans)
+		page.CoveragePercent = percent(page.Matched, page.Classes)
+		page.CSSPercent = percent(len(page.CSSClasses), len(cssClasses))
+		site.Pages = append(site.Pages, page)
+
+		dir := topDirectory(root, file)
+		d, ok := dirs[dir]
+		if !ok {
+			d = &dirTotals{
+				report:  DirectoryReport{Dir: dir},
+				classes: make(map[string]struct{}),
+				orphan:  make(map[string]struct{}),
+				css:     make(map[string]struct{}),
+			}
+			dirs[dir] = d
+		}
+		d.report.Pages++
+		if page.OrphanCount > 0 {
+			d.report.OrphanPages++
+		}
+		for class := range classes {
+			d.classes[class] = struct{}{}
+		}
+		for _, class := range page.Orphans {
+			d.orphan[class] = struct{}{}
+		}
+		for _, class := range page.CSSClasses {
+			d.css[class] = struct{}{}
+		}
+	}
+
+	for _, d := range dirs {
+		report := d.report
+		report.Classes = len(d.classes)
+		report.Matched = len(d.classes) - len(d.orphan)
+		for class := range d.orphan {
+			report.Orphans = append(report.Orphans, class)
+		}
+		sort.Strings(report.Orphans)
+		report.OrphanCount = len(report.Orphans)
+		report.CoveragePercent = percent(report.Matched, report.Classes)
+		report.CSSClasses = len(d.css)
+		report.CSSPercent = percent(report.CSSClasses, len(cssClasses))
+		site.Directories = append(site.Directories, report)
+	}
+
+	sort.Slice(site.Pages, func(i, j int) bool {
+		a, b := site.Pages[i], site.Pages[j]
+		if a.OrphanCount != b.OrphanCount {
+			return a.OrphanCount > b.OrphanCount
+		}
+		return a.File < b.File
+	})
+	sort.Slice(site.Directories, func(i, j int) bool {
+		a, b := site.Directories[i], site.Directories[j]
+		if a.OrphanCount != b.OrphanCount {
+			return a.OrphanCount > b.OrphanCount
+		}
+		return a.Dir < b.Dir
+	})
+	return site
+}
+
+// topDirectory returns the first directory of file below root as "/blog/",
+// or "/" for a file directly in root.
+func topDirectory(root, file string) string {
+	rel, err := filepath.Rel(root, file)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		rel = file
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	if len(parts) < 2 {
+		return "/"
+	}
+	return "/" + parts[0] + "/"
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total) * 100
+}
@@ -0,0 +1,61 @@
+package validator
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPerPage(t *testing.T) {
+	set := func(classes ...string) map[string]struct{} {
+		m := make(map[string]struct{})
+		for _, c := range classes {
+			m[c] = struct{}{}
+		}
+		return m
+	}
+	root := "public"
+	pages := map[string]map[string]struct{}{
+		filepath.Join(root, "index.html"):                set("a", "x"),
+		filepath.Join(root, "blog", "one", "index.html"): set("a", "x", "y"),
+		filepath.Join(root, "blog", "two.html"):          set("b", "y", "z", "hover:a"),
+		filepath.Join(root, "docs", "index.html"):        set("c"),
+	}
+	css := set("a", "b", "c", "d")
+	r := &Result{Orphans: []string{"x", "y", "z"}}
+
+	site := r.PerPage(root, pages, css)
+
+	var files []string
+	for _, p := range site.Pages {
+		files = append(files, filepath.ToSlash(p.File))
+	}
+	wantFiles := []string{"public/blog/one/index.html", "public/blog/two.html", "public/index.html", "public/docs/index.html"}
+	if !reflect.DeepEqual(files, wantFiles) {
+		t.Errorf("pages = %v, want %v", files, wantFiles)
+	}
+
+	two := site.Pages[1]
+	want := PageReport{
+		File:            filepath.Join(root, "blog", "two.html"),
+		Classes:         4,
+		Matched:         2,
+		Orphans:         []string{"y", "z"},
+		OrphanCount:     2,
+		CoveragePercent: 50,
+		CSSClasses:      []string{"b"},
+		CSSPercent:      25,
+	}
+	if !reflect.DeepEqual(two, want) {
+		t.Errorf("page = %+v, want %+v", two, want)
+	}
+
+	wantDirs := []DirectoryReport{
+		{Dir: "/blog/", Pages: 2, OrphanPages: 2, Classes: 6, Matched: 3, Orphans: []string{"x", "y", "z"}, OrphanCount: 3, CoveragePercent: 50, CSSClasses: 2, CSSPercent: 50},
+		{Dir: "/", Pages: 1, OrphanPages: 1, Classes: 2, Matched: 1, Orphans: []string{"x"}, OrphanCount: 1, CoveragePercent: 50, CSSClasses: 1, CSSPercent: 25},
+		{Dir: "/docs/", Pages: 1, Classes: 1, Matched: 1, CoveragePercent: 100, CSSClasses: 1, CSSPercent: 25},
+	}
+	if !reflect.DeepEqual(site.Directories, wantDirs) {
+		t.Errorf("directories = %+v\nwant %+v", site.Directories, wantDirs)
+	}
+}
@@ -0,0 +1,142 @@
+package validator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/JCorners68/cssguard/pkg/trainer"
+)
+
+// Rule kinds reported in RuleUsage.
+const (
+	RuleKindIgnore   = "ignore"
+	RuleKindSafelist = "safelist"
+)
+
+// now is the clock used for rule expiry; tests replace it.
+var now = time.Now
+
+// RuleUsage reports how an ignore or safelist rule was applied.
+type RuleUsage struct {
+	Kind       string `json:"kind"`    // ignore or safelist
+	Pattern    string `json:"pattern"` // Glob, or regex between slashes
+	Reason     string `json:"reason,omitempty"`
+	Expires    string `json:"expires,omitempty"`
+	Expired    bool   `json:"expired,omitempty"` // Past its expiry date, so not applied
+	Suppressed int    `json:"suppressed"`        // Classes the rule suppressed
+}
+
+// Stale reports whether the rule no longer does anything: it is expired or
+// matched no class.
+func (u RuleUsage) Stale() bool {
+	return u.Expired || u.Suppressed == 0
+}
+
+// String formats the usage as "ignore js-* (reason)".
+func (u RuleUsage) String() string {
+	s := u.Kind + " " + u.Pattern
+	if u.Reason != "" {
+		s += " (" + u.Reason + ")"
+	}
+	return s
+}
+
+// compiledRule is an ignore or safelist rule ready for matching.
+type compiledRule struct {
+	rule    trainer.Rule
+	re      *regexp.Regexp
+	expired bool
+}
+
+// compileRules validates and compiles rules, marking those past their expiry date.
+func compileRules(kind string, rules []trainer.Rule) ([]compiledRule, error) {
+	today := now()
+	compiled := make([]compiledRule, 0, len(rules))
+	for _, r := range rules {
+		var expr string
+		switch {
+		case r.Glob != "" && r.Regex != "":
+			return nil, fmt.Errorf("%s rule %q: set glob or regex, not both", kind, r.Pattern())
+		case r.Glob != "":
+			expr = globToRegex(r.Glob)
+		case r.Regex != "":
+			expr = "^(?:" + r.Regex + ")$"
+		default:
+			return nil, fmt.Errorf("%s rule needs a glob or regex", kind)
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s rule %q: %w", kind, r.Pattern(), err)
+		}
+
+		c := compiledRule{rule: r, re: re}
+		if r.Expires != "" {
+			last, err := time.ParseInLocation("2006-01-02", r.Expires, today.Location())
+			if err != nil {
+				return nil, fmt.Errorf("%s rule %q: invalid expiry date %q (want YYYY-MM-DD)", kind, r.Pattern(), r.Expires)
+			}
+			c.expired = !today.Before(last.AddDate(0, 0, 1))
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+// globToRegex converts a glob where * and ? are the only wildcards.
+func globToRegex(glob string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// ruleCounter applies a list of rules and counts what each suppresses.
+type ruleCounter struct {
+	kind   string
+	rules  []compiledRule
+	counts []int
+}
+
+func newRuleCounter(kind string, rules []compiledRule) *ruleCounter {
+	return &ruleCounter{kind: kind, rules: rules, counts: make([]int, len(rules))}
+}
+
+// match reports whether a rule that has not expired matches the class, and
+// counts the class against the first such rule.
+func (c *ruleCounter) match(class string) bool {
+	for i, r := range c.rules {
+		if !r.expired && r.re.MatchString(class) {
+			c.counts[i]++
+			return true
+		}
+	}
+	return false
+}
+
+// usage returns what each rule suppressed, in rule order.
+func (c *ruleCounter) usage() []RuleUsage {
+	usage := make([]RuleUsage, len(c.rules))
+	for i, r := range c.rules {
+		usage[i] = RuleUsage{
+			Kind:       c.kind,
+			Pattern:    r.rule.Pattern(),
+			Reason:     r.rule.Reason,
+			Expires:    r.rule.Expires,
+			Expired:    r.expired,
+			Suppressed: c.counts[i],
+		}
+	}
+	return usage
+}
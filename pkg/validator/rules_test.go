@@ -0,0 +1,131 @@
+package validator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/JCorners68/cssguard/pkg/trainer"
+)
+
+func TestGlobToRegex(t *testing.T) {
+	tests := []struct {
+		glob  string
+		class string
+		want  bool
+	}{
+		{"js-*", "js-toggle", true},
+		{"js-*", "is-js-toggle", false},
+		{"wp-block-*", "wp-block-group__inner", true},
+		{"col-?", "col-4", true},
+		{"col-?", "col-12", false},
+		{"w-[*]", "w-[200px]", true},
+		{"w-[*]", "w-2", false},
+		{"md:hidden", "md:hidden", true},
+	}
+
+	for _, tt := range tests {
+		rules, err := compileRules(RuleKindIgnore, []trainer.Rule{{Glob: tt.glob}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := rules[0].re.MatchString(tt.class); got != tt.want {
+			t.Errorf("glob %q matching %q = %v, want %v", tt.glob, tt.class, got, tt.want)
+		}
+	}
+}
+
+func TestCompileRulesErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		rule trainer.Rule
+	}{
+		{"empty", trainer.Rule{Reason: "nothing to match"}},
+		{"glob and regex", trainer.Rule{Glob: "js-*", Regex: "js-.*"}},
+		{"bad regex", trainer.Rule{Regex: "js-("}},
+		{"bad expiry", trainer.Rule{Glob: "js-*", Expires: "31/12/2026"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(&trainer.Config{IgnoreRules: []trainer.Rule{tt.rule}}); err == nil {
+				t.Error("New() succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestValidateAgainstPatternsIgnoreRules(t *testing.T) {
+	defer func(orig func() time.Time) { now = orig }(now)
+	now = func() time.Time { return time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC) }
+
+	config := &trainer.Config{
+		LiteralClasses: []string{"flex", "js-defined"},
+		IgnoreRules: []trainer.Rule{
+			{Glob: "js-*", Reason: "JS hooks"},
+			{Regex: `wp-block-[a-z]+`, Reason: "WordPress blocks"},
+			{Glob: "legacy-*", Expires: "2026-02-28"},
+			{Glob: "old-*", Expires: "2026-03-01"},
+			{Glob: "unused-rule-*"},
+		},
+	}
+	v, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := v.ValidateAgainstPatterns(setOf(
+		"flex", "js-defined", "js-toggle", "js-menu", "wp-block-group", "wp-block-1", "legacy-btn", "old-nav",
+	))
+
+	want := []string{"legacy-btn", "wp-block-1"}
+	if len(result.Orphans) != len(want) || result.Orphans[0] != want[0] || result.Orphans[1] != want[1] {
+		t.Errorf("Orphans = %v, want %v", result.Orphans, want)
+	}
+
+	wantSuppressed := []int{2, 1, 0, 1, 0}
+	if len(result.Rules) != len(wantSuppressed) {
+		t.Fatalf("got %d rule usages, want %d", len(result.Rules), len(wantSuppressed))
+	}
+	for i, u := range result.Rules {
+		if u.Suppressed != wantSuppressed[i] {
+			t.Errorf("rule %s suppressed %d, want %d", u, u.Suppressed, wantSuppressed[i])
+		}
+	}
+	if !result.Rules[2].Expired || result.Rules[3].Expired {
+		t.Errorf("expired = %v/%v, want legacy-* expired and old-* still applying on its last day",
+			result.Rules[2].Expired, result.Rules[3].Expired)
+	}
+
+	stale := result.StaleRules()
+	if len(stale) != 2 || stale[0].Pattern != "legacy-*" || stale[1].Pattern != "unused-rule-*" {
+		t.Errorf("StaleRules() = %v, want legacy-* and unused-rule-*", stale)
+	}
+	if result.SuppressedCount() != 4 {
+		t.Errorf("SuppressedCount() = %d, want 4", result.SuppressedCount())
+	}
+}
+
+func TestValidateDirectlySafelist(t *testing.T) {
+	v, err := New(&trainer.Config{
+		IgnoreRules: []trainer.Rule{{Glob: "js-*"}},
+		Safelist:    []trainer.Rule{{Glob: "prose-*", Reason: "Markdown content"}, {Regex: `bg-(red|green)-\d+`}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := v.ValidateDirectly(
+		setOf("flex", "js-toggle"),
+		setOf("flex", "js-toggle", "prose-lg", "bg-red-500", "bg-blue-500"),
+	)
+
+	if len(result.Unused) != 1 || result.Unused[0] != "bg-blue-500" {
+		t.Errorf("Unused = %v, want [bg-blue-500]", result.Unused)
+	}
+	// js-toggle is defined in the CSS, so the ignore rule suppresses nothing
+	if u := result.Rules[0]; u.Kind != RuleKindIgnore || u.Suppressed != 0 {
+		t.Errorf("ignore rule = %+v, want 0 suppressed", u)
+	}
+	if u := result.Rules[1]; u.Kind != RuleKindSafelist || u.Suppressed != 1 {
+		t.Errorf("safelist rule = %+v, want 1 suppressed", u)
+	}
+}
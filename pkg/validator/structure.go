@@ -0,0 +1,142 @@
+package validator
+
+import (
+	"sort"
+
+	"github.com/JCorners68/cssguard/pkg/extractor"
+	"github.com/JCorners68/cssguard/pkg/parser"
+)
+
+// ContextOrphan is a class used on elements none of the rules defining it
+// can match, because every rule needs other classes on the element or around
+// it, as .card > .card-title needs a .card parent.
+type ContextOrphan struct {
+	Selectors []string   `json:"selectors"` // Selectors defining the class, none matching the uses
+	Locations []Location `json:"locations"` // Uses no selector matches
+}
+
+// structuralRule is a selector defining a class only in some structure.
+type structuralRule struct {
+	selector  string
+	compounds []parser.Compound
+}
+
+// AddContextOrphans checks the uses of classes in pages against the
+// structure of the selectors defining them in defs. A class all of whose
+// definitions are structural (see parser.Definition.Structural) is a context
+// orphan on every element that no such selector matches; only the classes
+// in the selectors are compared, so tags, attributes and pseudo-classes
+// count as matching. Uses silenced by inline comments are skipped.
+func (r *Result) AddContextOrphans(defs []parser.Definition, pages []extractor.Page) {
+	rules := make(map[string][]structuralRule)
+	plain := make(map[string]struct{}) // Classes with a rule of their own
+	for _, d := range defs {
+		if !d.Structural() {
+			plain[d.Class] = struct{}{}
+			continue
+		}
+		if !hasSelector(rules[d.Class], d.Selector) {
+			rules[d.Class] = append(rules[d.Class], structuralRule{selector: d.Selector, compounds: d.Compounds()})
+		}
+	}
+	for class := range plain {
+		delete(rules, class)
+	}
+	if len(rules) == 0 {
+		return
+	}
+
+	for _, page := range pages {
+		for i, e := range page.Elements {
+			for _, class := range e.Classes {
+				classRules, ok := rules[class]
+				if !ok || hasString(e.Suppressed, class) || matchesAny(page.Elements, i, classRules) {
+					continue
+				}
+				if r.ContextOrphans == nil {
+					r.ContextOrphans = make(map[string]ContextOrphan)
+				}
+				orphan := r.ContextOrphans[class]
+				if orphan.Selectors == nil {
+					for _, rule := range classRules {
+						orphan.Selectors = append(orphan.Selectors, rule.selector)
+					}
+				}
+				orphan.Locations = append(orphan.Locations, Location{File: page.File, Line: e.Line, Column: e.Column, Tag: e.Tag})
+				r.ContextOrphans[class] = orphan
+			}
+		}
+	}
+}
+
+// ContextOrphanClasses returns the context orphans, sorted.
+func (r *Result) ContextOrphanClasses() []string {
+	classes := make([]string, 0, len(r.ContextOrphans))
+	for class := range r.ContextOrphans {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	return classes
+}
+
+func hasSelector(rules []structuralRule, selector string) bool {
+	for _, rule := range rules {
+		if rule.selector == selector {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAny reports whether any of rules matches elements[i].
+func matchesAny(elements []extractor.Element, i int, rules []structuralRule) bool {
+	for _, rule := range rules {
+		if matches(elements, i, rule.compounds, len(rule.compounds)-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether elements[i] matches compounds[k] and the
+// compounds before it match the elements around it as their combinators
+// require.
+func matches(elements []extractor.Element, i int, compounds []parser.Compound, k int) bool {
+	e := elements[i]
+	for _, class := range compounds[k].Classes {
+		if !hasString(e.Classes, class) {
+			return false
+		}
+	}
+	if k == 0 {
+		return true
+	}
+	switch compounds[k].Combinator {
+	case ">":
+		return e.Parent >= 0 && matches(elements, e.Parent, compounds, k-1)
+	case "+":
+		return e.Prev >= 0 && matches(elements, e.Prev, compounds, k-1)
+	case "~":
+		for j := e.Prev; j >= 0; j = elements[j].Prev {
+			if matches(elements, j, compounds, k-1) {
+				return true
+			}
+		}
+	default:
+		for j := e.Parent; j >= 0; j = elements[j].Parent {
+			if matches(elements, j, compounds, k-1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
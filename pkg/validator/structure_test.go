@@ -0,0 +1,66 @@
+package validator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/JCorners68/cssguard/pkg/extractor"
+	"github.com/JCorners68/cssguard/pkg/parser"
+)
+
+func TestAddContextOrphans(t *testing.T) {
+	css := `.card > .card-title { }
+.nav .active { } .tabs .active { }
+.btn.is-primary { }
+.peer:checked ~ .peer-checked\:block { }
+.label { } .form .label { }
+.list > li { }`
+	html := `<div class="card"><h2 class="card-title">ok</h2></div>
+<h2 class="card-title">no card</h2>
+<ul class="tabs"><li><a class="active">ok</a></li></ul>
+<a class="active">no nav</a>
+<button class="btn is-primary">ok</button><span class="is-primary">no btn</span>
+<input class="peer"><p class="peer-checked:block">ok</p>
+<div><p class="peer-checked:block">no peer</p></div>
+<span class="label">plain rule</span> <span class="list">hook</span>
+<!-- cssguard-ignore: card-title -->
+<h3 class="card-title">suppressed</h3>`
+
+	defs, err := parser.ParseDefinitionsFromReader(strings.NewReader(css), "site.css")
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, err := extractor.ExtractPageFromReader(strings.NewReader(html), "index.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &Result{}
+	r.AddContextOrphans(defs, []extractor.Page{page})
+
+	expected := map[string]ContextOrphan{
+		"card-title": {
+			Selectors: []string{".card > .card-title"},
+			Locations: []Location{{File: "index.html", Line: 2, Column: 1, Tag: "h2"}},
+		},
+		"active": {
+			Selectors: []string{".nav .active", ".tabs .active"},
+			Locations: []Location{{File: "index.html", Line: 4, Column: 1, Tag: "a"}},
+		},
+		"is-primary": {
+			Selectors: []string{".btn.is-primary"},
+			Locations: []Location{{File: "index.html", Line: 5, Column: 43, Tag: "span"}},
+		},
+		"peer-checked:block": {
+			Selectors: []string{`.peer:checked ~ .peer-checked\:block`},
+			Locations: []Location{{File: "index.html", Line: 7, Column: 6, Tag: "p"}},
+		},
+	}
+	if !reflect.DeepEqual(r.ContextOrphans, expected) {
+		t.Errorf("got %+v\nwant %+v", r.ContextOrphans, expected)
+	}
+	if got := r.ContextOrphanClasses(); !reflect.DeepEqual(got, []string{"active", "card-title", "is-primary", "peer-checked:block"}) {
+		t.Errorf("ContextOrphanClasses() = %v", got)
+	}
+}
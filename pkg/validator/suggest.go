@@ -0,0 +1,188 @@
+package validator
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/JCorners68/cssguard/pkg/trainer"
+)
+
+// DefaultSuggestThreshold is the similarity a class needs to be suggested
+// for an orphan.
+const DefaultSuggestThreshold = 0.8
+
+// maxSuggestions limits how many suggestions are kept per orphan.
+const maxSuggestions = 3
+
+// Suggestion is a known class an orphan may have been meant to be.
+type Suggestion struct {
+	Class string  `json:"class"`
+	Score float64 `json:"score"` // Similarity from 0 to 1
+}
+
+// KnownClasses returns the classes a trained config knows by name: its
+// literals and the examples of its patterns.
+func (v *Validator) KnownClasses() map[string]struct{} {
+	known := make(map[string]struct{}, len(v.literalSet))
+	for class := range v.literalSet {
+		known[class] = struct{}{}
+	}
+	for _, p := range v.config.Patterns {
+		for _, class := range p.Examples {
+			known[class] = struct{}{}
+		}
+	}
+	return known
+}
+
+// Suggest records, for each orphan whose utility is unknown, up to three
+// known classes at least threshold similar to it, best first. Variants and
+// the important modifier are kept as written and only the base utility is
+// compared, so md:text-grey-500 suggests md:text-gray-500. Similarity is the
+// mean of the edit-distance similarity of the whole names and the similarity
+// of their dash-separated tokens.
+func (r *Result) Suggest(known map[string]struct{}, threshold float64) {
+	bases := newUtilitySet(known).bases
+	candidates := make([]string, 0, len(bases))
+	for base := range bases {
+		candidates = append(candidates, base)
+	}
+	sort.Strings(candidates)
+
+	for _, class := range r.Orphans {
+		if issue, ok := r.VariantIssues[class]; ok && !issue.MissingUtility {
+			continue // Only a variant or modifier is missing
+		}
+		variants, base := trainer.SplitVariants(class)
+		base, important := trainer.SplitImportant(base)
+
+		var found []Suggestion
+		for _, candidate := range candidates {
+			if candidate == base {
+				continue
+			}
+			if score := similarity(base, candidate, threshold); score >= threshold {
+				found = append(found, Suggestion{Class: candidate, Score: score})
+			}
+		}
+		if len(found) == 0 {
+			continue
+		}
+
+		sort.SliceStable(found, func(i, j int) bool { return found[i].Score > found[j].Score })
+		if len(found) > maxSuggestions {
+			found = found[:maxSuggestions]
+		}
+		for i := range found {
+			found[i].Class = withModifiers(variants, important, found[i].Class)
+			found[i].Score = float64(int(found[i].Score*100+0.5)) / 100
+		}
+		if r.Suggestions == nil {
+			r.Suggestions = make(map[string][]Suggestion)
+		}
+		r.Suggestions[class] = found
+	}
+}
+
+// withModifiers puts variants and the important modifier back on a base utility.
+func withModifiers(variants []string, important bool, base string) string {
+	if important {
+		base = "!" + base
+	}
+	if len(variants) == 0 {
+		return base
+	}
+	return strings.Join(variants, ":") + ":" + base
+}
+
+// similarity scores how alike two class names are, from 0 to 1. Pairs that
+// cannot reach min are rejected early with a score of 0.
+func similarity(a, b string, min float64) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 0
+	}
+
+	// The token score is at most 1, so the edit score must reach 2*min-1;
+	// the length difference is a lower bound on the edit distance.
+	diff := len(ra) - len(rb)
+	if diff < 0 {
+		diff = -diff
+	}
+	if 1-float64(diff)/float64(longest) < 2*min-1 {
+		return 0
+	}
+
+	edit := 1 - float64(levenshtein(ra, rb))/float64(longest)
+	return (edit + tokenSimilarity(splitTokens(a), splitTokens(b))) / 2
+}
+
+// tokenSimilarity matches every token to its most similar token on the
+// other side and averages the scores in both directions, so grey-500 and
+// gray-500 share one exact and one close token.
+func tokenSimilarity(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	best := func(from, to []string) float64 {
+		total := 0.0
+		for _, x := range from {
+			max := 0.0
+			for _, y := range to {
+				rx, ry := []rune(x), []rune(y)
+				longest := len(rx)
+				if len(ry) > longest {
+					longest = len(ry)
+				}
+				if s := 1 - float64(levenshtein(rx, ry))/float64(longest); s > max {
+					max = s
+				}
+			}
+			total += max
+		}
+		return total / float64(len(from))
+	}
+	return (best(a, b) + best(b, a)) / 2
+}
+
+// splitTokens splits a class name at dashes, underscores and slashes.
+func splitTokens(class string) []string {
+	return strings.FieldsFunc(class, func(r rune) bool {
+		return r == '-' || r == '_' || r == '/'
+	})
+}
+
+// levenshtein returns the edit distance between two rune slices.
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
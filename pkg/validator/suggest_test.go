@@ -0,0 +1,70 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/JCorners68/cssguard/pkg/trainer"
+)
+
+func TestResultSuggest(t *testing.T) {
+	css := setOf("text-gray-500", "text-gray-600", "btn-primary", "md:flex", "bg-red-500", "mb-4")
+	html := setOf("text-grey-500", "md:text-grey-500", "!btn-primry", "dark:bg-red-500", "mt-4", "zzz")
+
+	result := ValidateDirectly(html, css)
+	result.Suggest(css, DefaultSuggestThreshold)
+
+	expected := map[string]string{
+		"text-grey-500":    "text-gray-500",
+		"md:text-grey-500": "md:text-gray-500",
+		"!btn-primry":      "!btn-primary",
+	}
+	for class, want := range expected {
+		got := result.Suggestions[class]
+		if len(got) == 0 || got[0].Class != want {
+			t.Errorf("Suggestions[%q] = %v, want %s first", class, got, want)
+		}
+	}
+	if got := result.Suggestions["text-grey-500"]; len(got) != 2 || got[0].Score < got[1].Score {
+		t.Errorf("Suggestions[text-grey-500] = %v, want gray-500 then gray-600", got)
+	}
+
+	// Only the dark variant is missing, a short class is not close enough and
+	// nothing resembles zzz
+	for _, class := range []string{"dark:bg-red-500", "mt-4", "zzz"} {
+		if got, ok := result.Suggestions[class]; ok {
+			t.Errorf("Suggestions[%q] = %v, want none", class, got)
+		}
+	}
+}
+
+func TestKnownClasses(t *testing.T) {
+	v, err := New(&trainer.Config{
+		Patterns:       []trainer.Pattern{{Name: "text", Regex: `^text-.*$`, Examples: []string{"text-gray-500"}}},
+		LiteralClasses: []string{"container"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	known := v.KnownClasses()
+	if len(known) != 2 {
+		t.Errorf("KnownClasses() = %v, want container and text-gray-500", known)
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"grey", "gray", 1},
+		{"flex", "", 4},
+		{"kitten", "sitting", 3},
+	}
+	for _, tt := range tests {
+		if got := levenshtein([]rune(tt.a), []rune(tt.b)); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
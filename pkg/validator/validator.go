@@ -11,14 +11,46 @@ import (
 
 // Result represents the validation result.
 type Result struct {
-	HTMLClasses     int      `json:"html_classes"`
-	CSSClasses      int      `json:"css_classes"`
-	Orphans         []string `json:"orphans"` // HTML classes with no CSS
-	Unused          []string `json:"unused"`  // CSS classes not in HTML
-	Matched         int      `json:"matched"` // Classes in both
-	OrphanCount     int      `json:"orphan_count"`
-	UnusedCount     int      `json:"unused_count"`
-	CoveragePercent float64  `json:"coverage_percent"` // Matched / HTML classes
+	HTMLClasses     int                      `json:"html_classes"`
+	CSSClasses      int                      `json:"css_classes"`
+	Orphans         []string                 `json:"orphans"` // HTML classes with no CSS
+	Unused          []string                 `json:"unused"`  // CSS classes not in HTML
+	Matched         int                      `json:"matched"` // Classes in both
+	OrphanCount     int                      `json:"orphan_count"`
+	UnusedCount     int                      `json:"unused_count"`
+	CoveragePercent float64                  `json:"coverage_percent"`           // Matched / HTML classes
+	OrphanLocations map[string][]Location    `json:"orphan_locations,omitempty"` // Orphan -> where it is used
+	UnusedLocations map[string][]Location    `json:"unused_locations,omitempty"` // Unused -> where it is defined
+	VariantIssues   map[string]VariantIssue  `json:"variant_issues,omitempty"`   // Orphan with modifiers -> what is missing
+	Baseline        *BaselineStatus          `json:"baseline,omitempty"`         // Set when compared with a baseline
+	Rules           []RuleUsage              `json:"rules,omitempty"`            // Ignore and safelist rules applied
+	Suppressed      map[string][]Location    `json:"suppressed,omitempty"`       // Orphans silenced by inline comments -> where
+	Suggestions     map[string][]Suggestion  `json:"suggestions,omitempty"`      // Orphan -> likely intended classes
+	Contexts        map[string][]string      `json:"contexts,omitempty"`         // Used class defined only inside at-rules -> those at-rules
+	PrintOnly       map[string][]Location    `json:"print_only,omitempty"`       // Used class defined only for print -> where it is used
+	ContextOrphans  map[string]ContextOrphan `json:"context_orphans,omitempty"`  // Class used outside the structure its selectors need
+}
+
+// BaselineStatus splits orphans into those accepted by a baseline and new
+// ones, and lists baseline entries that no longer occur.
+type BaselineStatus struct {
+	New      []string `json:"new"`
+	Known    []string `json:"known"`
+	Resolved []string `json:"resolved"`
+}
+
+// Location is a place where a class is used.
+type Location struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Tag    string `json:"tag,omitempty"`   // Element carrying the class, for HTML
+	Entry  string `json:"entry,omitempty"` // Stylesheet that imported the file, for CSS
+}
+
+// String formats the location as file:line:column.
+func (l Location) String() string {
+	return fmt.Sprintf("%s:%d:%d", l.File, l.Line, l.Column)
 }
 
 // Validator validates HTML classes against CSS or trained patterns.
@@ -26,15 +58,21 @@ type Validator struct {
 	config           *trainer.Config
 	compiledPatterns []*regexp.Regexp
 	literalSet       map[string]struct{}
+	utilities        *utilitySet // Variants, modifiers and bases of the literals
+	modifierBases    map[string]struct{}
+	modifierPatterns []*regexp.Regexp
 	ignoredSet       map[string]struct{}
+	ignoreRules      []compiledRule
+	safelist         []compiledRule
 }
 
 // New creates a validator from a trained config.
 func New(config *trainer.Config) (*Validator, error) {
 	v := &Validator{
-		config:     config,
-		literalSet: make(map[string]struct{}),
-		ignoredSet: make(map[string]struct{}),
+		config:        config,
+		literalSet:    make(map[string]struct{}),
+		modifierBases: make(map[string]struct{}),
+		ignoredSet:    make(map[string]struct{}),
 	}
 
 	// Compile regex patterns
@@ -51,11 +89,40 @@ func New(config *trainer.Config) (*Validator, error) {
 		v.literalSet[class] = struct{}{}
 	}
 
+	// Bases the CSS defines only behind a modifier, for prefixed classes
+	for _, p := range config.ModifierPatterns {
+		re, err := regexp.Compile(p.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", p.Name, err)
+		}
+		v.modifierPatterns = append(v.modifierPatterns, re)
+	}
+	for _, base := range config.ModifierBases {
+		v.modifierBases[base] = struct{}{}
+	}
+
+	// Index variants and modifiers. Configs trained before they were split
+	// off keep prefixed literals, so learn from those as well.
+	v.utilities = newUtilitySet(v.literalSet)
+	for _, variant := range config.Variants {
+		v.utilities.variants[variant] = struct{}{}
+	}
+	v.utilities.important = v.utilities.important || config.Important
+
 	// Build ignored class set
 	for _, class := range config.Ignored {
 		v.ignoredSet[class] = struct{}{}
 	}
 
+	// Compile ignore and safelist rules
+	var err error
+	if v.ignoreRules, err = compileRules(RuleKindIgnore, config.IgnoreRules); err != nil {
+		return nil, err
+	}
+	if v.safelist, err = compileRules(RuleKindSafelist, config.Safelist); err != nil {
+		return nil, err
+	}
+
 	return v, nil
 }
 
@@ -64,6 +131,7 @@ func (v *Validator) ValidateAgainstPatterns(htmlClasses map[string]struct{}) *Re
 	result := &Result{
 		HTMLClasses: len(htmlClasses),
 	}
+	ignore := newRuleCounter(RuleKindIgnore, v.ignoreRules)
 
 	for class := range htmlClasses {
 		// Skip ignored classes
@@ -72,28 +140,29 @@ func (v *Validator) ValidateAgainstPatterns(htmlClasses map[string]struct{}) *Re
 			continue
 		}
 
-		// Check literal classes first
-		if _, found := v.literalSet[class]; found {
+		// Check literal classes and patterns. A base the CSS only defines
+		// behind modifiers is not defined bare, whatever pattern it matches
+		if _, modifierOnly := v.modifierBases[class]; !modifierOnly && v.matchesUtility(class) {
 			result.Matched++
 			continue
 		}
 
-		// Check against patterns
-		matched := false
-		for _, re := range v.compiledPatterns {
-			if re.MatchString(class) {
-				matched = true
-				break
-			}
+		// Check variants, important modifier and base utility separately
+		issue, ok := checkModifiers(class, v.utilities, v.matchesBase)
+		if ok {
+			result.Matched++
+			continue
 		}
 
-		if matched {
+		if ignore.match(class) {
 			result.Matched++
-		} else {
-			result.Orphans = append(result.Orphans, class)
+			continue
 		}
+		result.Orphans = append(result.Orphans, class)
+		result.addVariantIssue(class, issue)
 	}
 
+	result.Rules = ignore.usage()
 	result.OrphanCount = len(result.Orphans)
 	if result.HTMLClasses > 0 {
 		result.CoveragePercent = float64(result.Matched) / float64(result.HTMLClasses) * 100
@@ -103,29 +172,95 @@ func (v *Validator) ValidateAgainstPatterns(htmlClasses map[string]struct{}) *Re
 	return result
 }
 
+// matchesUtility reports whether a class is a trained literal or matches a
+// pattern. Arbitrary-value classes must be literals.
+func (v *Validator) matchesUtility(class string) bool {
+	if _, found := v.literalSet[class]; found {
+		return true
+	}
+	if trainer.IsArbitrary(class) {
+		return false
+	}
+	for _, re := range v.compiledPatterns {
+		if re.MatchString(class) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesBase reports whether a base utility exists behind modifiers: as a
+// trained utility, as a base the CSS defines only behind modifiers, or as
+// the base of a literal with modifiers.
+func (v *Validator) matchesBase(base string) bool {
+	if v.utilities.hasBase(base) || v.matchesUtility(base) {
+		return true
+	}
+	if _, found := v.modifierBases[base]; found {
+		return true
+	}
+	if trainer.IsArbitrary(base) {
+		return false
+	}
+	for _, re := range v.modifierPatterns {
+		if re.MatchString(base) {
+			return true
+		}
+	}
+	return false
+}
+
 // ValidateDirectly compares HTML classes directly against CSS classes (no patterns).
 func ValidateDirectly(htmlClasses, cssClasses map[string]struct{}) *Result {
+	v, _ := New(&trainer.Config{}) // an empty config is always valid
+	return v.ValidateDirectly(htmlClasses, cssClasses)
+}
+
+// ValidateDirectly compares HTML classes directly against CSS classes,
+// applying the validator's ignored classes, ignore rules and safelist. Its
+// patterns and literals are not used.
+func (v *Validator) ValidateDirectly(htmlClasses, cssClasses map[string]struct{}) *Result {
 	result := &Result{
 		HTMLClasses: len(htmlClasses),
 		CSSClasses:  len(cssClasses),
 	}
+	ignore := newRuleCounter(RuleKindIgnore, v.ignoreRules)
+	safelist := newRuleCounter(RuleKindSafelist, v.safelist)
+
+	// Variants, modifiers and base utilities the CSS provides
+	cssUtilities := newUtilitySet(cssClasses)
 
 	// Find orphans (HTML classes not in CSS)
 	for class := range htmlClasses {
+		if _, ignored := v.ignoredSet[class]; ignored {
+			result.Matched++
+			continue
+		}
 		if _, found := cssClasses[class]; found {
 			result.Matched++
-		} else {
-			result.Orphans = append(result.Orphans, class)
+			continue
 		}
+		issue, ok := checkModifiers(class, cssUtilities, cssUtilities.hasBase)
+		if ok {
+			result.Matched++
+			continue
+		}
+		if ignore.match(class) {
+			result.Matched++
+			continue
+		}
+		result.Orphans = append(result.Orphans, class)
+		result.addVariantIssue(class, issue)
 	}
 
 	// Find unused (CSS classes not in HTML)
 	for class := range cssClasses {
-		if _, found := htmlClasses[class]; !found {
+		if _, found := htmlClasses[class]; !found && !safelist.match(class) {
 			result.Unused = append(result.Unused, class)
 		}
 	}
 
+	result.Rules = append(ignore.usage(), safelist.usage()...)
 	result.OrphanCount = len(result.Orphans)
 	result.UnusedCount = len(result.Unused)
 	if result.HTMLClasses > 0 {
@@ -137,6 +272,157 @@ func ValidateDirectly(htmlClasses, cssClasses map[string]struct{}) *Result {
 	return result
 }
 
+// addVariantIssue records why an orphan with modifiers did not match.
+func (r *Result) addVariantIssue(class string, issue VariantIssue) {
+	if issue.Base == "" {
+		return
+	}
+	if r.VariantIssues == nil {
+		r.VariantIssues = make(map[string]VariantIssue)
+	}
+	r.VariantIssues[class] = issue
+}
+
+// AddLocations records where each orphan is used. Locations of classes that
+// are not orphans are ignored; locations for the same orphan accumulate.
+func (r *Result) AddLocations(locations map[string][]Location) {
+	r.OrphanLocations = addLocations(r.OrphanLocations, r.Orphans, locations)
+}
+
+// AddUnusedLocations records where each unused CSS class is defined.
+func (r *Result) AddUnusedLocations(locations map[string][]Location) {
+	r.UnusedLocations = addLocations(r.UnusedLocations, r.Unused, locations)
+}
+
+func addLocations(dst map[string][]Location, classes []string, locations map[string][]Location) map[string][]Location {
+	for _, class := range classes {
+		locs := locations[class]
+		if len(locs) == 0 {
+			continue
+		}
+		if dst == nil {
+			dst = make(map[string][]Location)
+		}
+		dst[class] = append(dst[class], locs...)
+	}
+	return dst
+}
+
+// Suppress moves orphans whose every use is silenced by an inline
+// cssguard-ignore comment from Orphans to Suppressed. suppressed holds the
+// silenced uses of each class and used the others; an orphan with any use
+// in used stays an orphan.
+func (r *Result) Suppress(suppressed, used map[string][]Location) {
+	var orphans []string
+	for _, class := range r.Orphans {
+		if len(suppressed[class]) == 0 || len(used[class]) > 0 {
+			orphans = append(orphans, class)
+			continue
+		}
+		if r.Suppressed == nil {
+			r.Suppressed = make(map[string][]Location)
+		}
+		r.Suppressed[class] = suppressed[class]
+		delete(r.VariantIssues, class)
+		r.Matched++
+	}
+
+	r.Orphans = orphans
+	r.OrphanCount = len(orphans)
+	if r.HTMLClasses > 0 {
+		r.CoveragePercent = float64(r.Matched) / float64(r.HTMLClasses) * 100
+	}
+}
+
+// Restrict keeps only the findings at a location accepted by keep, such as
+// the lines changed in a pull request. Orphan, suppressed, print-only and
+// context orphan locations are filtered, and orphans left without a
+// location are dropped. used holds every use of each class, silenced or
+// not; the counts and coverage are recomputed for the classes used at a
+// kept location. Unused classes and rule usage are dropped, since they
+// describe the whole scan rather than a location, as are resolved baseline
+// entries.
+func (r *Result) Restrict(keep func(Location) bool, used map[string][]Location) {
+	filter := func(locs []Location) []Location {
+		var kept []Location
+		for _, loc := range locs {
+			if keep(loc) {
+				kept = append(kept, loc)
+			}
+		}
+		return kept
+	}
+
+	var orphans []string
+	for _, class := range r.Orphans {
+		if locs := filter(r.OrphanLocations[class]); len(locs) > 0 {
+			r.OrphanLocations[class] = locs
+			orphans = append(orphans, class)
+			continue
+		}
+		delete(r.OrphanLocations, class)
+		delete(r.VariantIssues, class)
+		delete(r.Suggestions, class)
+	}
+	r.Orphans = orphans
+	r.OrphanCount = len(orphans)
+	if b := r.Baseline; b != nil {
+		kept := make(map[string]struct{}, len(orphans))
+		for _, class := range orphans {
+			kept[class] = struct{}{}
+		}
+		b.New = keepClasses(b.New, kept)
+		b.Known = keepClasses(b.Known, kept)
+		b.Resolved = nil // Cannot tell from part of the findings
+	}
+
+	for _, m := range []map[string][]Location{r.Suppressed, r.PrintOnly} {
+		for class, locs := range m {
+			if locs = filter(locs); len(locs) > 0 {
+				m[class] = locs
+			} else {
+				delete(m, class)
+			}
+		}
+	}
+
+	for class, orphan := range r.ContextOrphans {
+		if orphan.Locations = filter(orphan.Locations); len(orphan.Locations) > 0 {
+			r.ContextOrphans[class] = orphan
+		} else {
+			delete(r.ContextOrphans, class)
+		}
+	}
+
+	r.HTMLClasses = 0
+	for _, locs := range used {
+		if len(filter(locs)) > 0 {
+			r.HTMLClasses++
+		}
+	}
+	r.Matched = r.HTMLClasses - r.OrphanCount
+	r.CoveragePercent = 0
+	if r.HTMLClasses > 0 {
+		r.CoveragePercent = float64(r.Matched) / float64(r.HTMLClasses) * 100
+	}
+
+	r.Unused = nil
+	r.UnusedLocations = nil
+	r.UnusedCount = 0
+	r.Rules = nil
+}
+
+// keepClasses returns the classes in list that are in keep, in order.
+func keepClasses(list []string, keep map[string]struct{}) []string {
+	var kept []string
+	for _, class := range list {
+		if _, ok := keep[class]; ok {
+			kept = append(kept, class)
+		}
+	}
+	return kept
+}
+
 // Summary returns a human-readable summary of the result.
 func (r *Result) Summary() string {
 	var s string
@@ -149,6 +435,18 @@ func (r *Result) Summary() string {
 	if r.UnusedCount > 0 {
 		s += fmt.Sprintf("Unused:       %d (CSS classes not in HTML)\n", r.UnusedCount)
 	}
+	if rules, inline := r.SuppressedCount(), len(r.Suppressed); rules+inline > 0 {
+		s += fmt.Sprintf("Suppressed:   %d (%d by rules, %d by inline comments)\n", rules+inline, rules, inline)
+	}
+	if b := r.Baseline; b != nil {
+		s += fmt.Sprintf("Baseline:     %d new, %d known, %d resolved\n", len(b.New), len(b.Known), len(b.Resolved))
+	}
+	if len(r.PrintOnly) > 0 {
+		s += fmt.Sprintf("Print-only:   %d (used classes defined only for print)\n", len(r.PrintOnly))
+	}
+	if len(r.ContextOrphans) > 0 {
+		s += fmt.Sprintf("Context:      %d (classes used outside the structure their selectors need)\n", len(r.ContextOrphans))
+	}
 	return s
 }
 
@@ -157,6 +455,46 @@ func (r *Result) HasOrphans() bool {
 	return r.OrphanCount > 0
 }
 
+// HasNewOrphans returns true if there are orphans not accepted by a baseline.
+// Without a baseline every orphan is new.
+func (r *Result) HasNewOrphans() bool {
+	if r.Baseline != nil {
+		return len(r.Baseline.New) > 0
+	}
+	return r.HasOrphans()
+}
+
+// IsKnownOrphan returns true if the orphan is accepted by a baseline.
+func (r *Result) IsKnownOrphan(class string) bool {
+	if r.Baseline == nil {
+		return false
+	}
+	known := r.Baseline.Known // sorted, like Orphans
+	i := sort.SearchStrings(known, class)
+	return i < len(known) && known[i] == class
+}
+
+// SuppressedCount returns how many classes ignore and safelist rules
+// suppressed. Orphans silenced by inline comments are in Suppressed.
+func (r *Result) SuppressedCount() int {
+	n := 0
+	for _, u := range r.Rules {
+		n += u.Suppressed
+	}
+	return n
+}
+
+// StaleRules returns the rules that are expired or matched no class.
+func (r *Result) StaleRules() []RuleUsage {
+	var stale []RuleUsage
+	for _, u := range r.Rules {
+		if u.Stale() {
+			stale = append(stale, u)
+		}
+	}
+	return stale
+}
+
 // HasUnused returns true if there are unused CSS classes.
 func (r *Result) HasUnused() bool {
 	return r.UnusedCount > 0
@@ -1,7 +1,11 @@
 package validator
 
 import (
+	"fmt"
+	"reflect"
 	"testing"
+
+	"github.com/JCorners68/cssguard/pkg/trainer"
 )
 
 func TestValidateDirectly(t *testing.T) {
@@ -173,3 +177,218 @@ func findSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestResultAddLocations(t *testing.T) {
+	r := ValidateDirectly(setOf("flex", "translate-x-0"), setOf("flex"))
+	r.AddLocations(map[string][]Location{
+		"flex":          {{File: "index.html", Line: 1, Column: 1, Tag: "div"}},
+		"translate-x-0": {{File: "drawer.html", Line: 12, Column: 5, Tag: "aside"}},
+	})
+
+	if _, ok := r.OrphanLocations["flex"]; ok {
+		t.Error("locations recorded for matched class flex")
+	}
+	locs := r.OrphanLocations["translate-x-0"]
+	if len(locs) != 1 || locs[0].String() != "drawer.html:12:5" {
+		t.Errorf("OrphanLocations[translate-x-0] = %v, want [drawer.html:12:5]", locs)
+	}
+}
+
+func TestValidateDirectlyVariants(t *testing.T) {
+	css := setOf("bg-blue-500", "hover:text-white", "md:flex", "opacity-100")
+	html := setOf("md:hover:bg-blue-500", "dark:opacity-100", "md:bg-red-500", "lg:ring")
+
+	result := ValidateDirectly(html, css)
+
+	if result.Matched != 1 {
+		t.Errorf("Matched = %d, want 1", result.Matched)
+	}
+	expected := map[string]string{
+		"dark:opacity-100": "missing variant dark (no dark: class in the CSS)",
+		"md:bg-red-500":    "missing utility bg-red-500",
+		"lg:ring":          "missing variant lg (no lg: class in the CSS); missing utility ring",
+	}
+	for class, reason := range expected {
+		issue, ok := result.VariantIssues[class]
+		if !ok {
+			t.Errorf("no variant issue for %q", class)
+			continue
+		}
+		if issue.Reason() != reason {
+			t.Errorf("Reason() for %q = %q, want %q", class, issue.Reason(), reason)
+		}
+	}
+}
+
+func TestValidateAgainstPatternsVariants(t *testing.T) {
+	config := &trainer.Config{
+		Patterns:       []trainer.Pattern{{Name: "bg", Regex: `^bg-[a-z]+-\d+$`}},
+		LiteralClasses: []string{"flex", "focus:outline-none"},
+		Variants:       []string{"md", "hover"},
+	}
+	v, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := v.ValidateAgainstPatterns(setOf(
+		"md:hover:bg-blue-500", "focus:flex", "md:outline-none", "dark:flex",
+	))
+
+	if result.Matched != 3 {
+		t.Errorf("Matched = %d, want 3 (orphans %v)", result.Matched, result.Orphans)
+	}
+	if len(result.Orphans) != 1 || result.Orphans[0] != "dark:flex" {
+		t.Errorf("Orphans = %v, want [dark:flex]", result.Orphans)
+	}
+	if got := result.VariantIssues["dark:flex"].Reason(); got != "missing variant dark (no dark: class in the CSS)" {
+		t.Errorf("Reason() = %q, want %q", got, "missing variant dark (no dark: class in the CSS)")
+	}
+}
+
+func TestValidateAgainstPatternsModifierOnlyBases(t *testing.T) {
+	css := setOf("md:flex", "hover:underline", "p-4")
+	html := setOf("flex", "underline", "p-4", "md:flex", "hover:underline", "md:underline", "block")
+
+	tr := trainer.New()
+	tr.AddClasses(css)
+	v, err := New(tr.Train())
+	if err != nil {
+		t.Fatal(err)
+	}
+	patterns := v.ValidateAgainstPatterns(html)
+	direct := ValidateDirectly(html, css)
+
+	// block matches the built-in display pattern; flex does too, but the
+	// CSS only defines it behind md:
+	want := []string{"flex", "underline"}
+	if !reflect.DeepEqual(patterns.Orphans, want) {
+		t.Errorf("pattern Orphans = %v, want %v", patterns.Orphans, want)
+	}
+	want = []string{"block", "flex", "underline"}
+	if !reflect.DeepEqual(direct.Orphans, want) {
+		t.Errorf("direct Orphans = %v, want %v", direct.Orphans, want)
+	}
+}
+
+func TestValidateDirectlyImportantAndArbitrary(t *testing.T) {
+	css := setOf("mt-0", "!p-4", "bg-[#1da1f2]", "md:flex", "[mask-type:luminance]")
+	html := setOf("!mt-0", "md:!mt-0", "mt-0!", "bg-[#1da1f2]", "md:bg-[#1da1f2]", "bg-[#000]", "[mask-type:luminance]")
+
+	result := ValidateDirectly(html, css)
+
+	if len(result.Orphans) != 1 || result.Orphans[0] != "bg-[#000]" {
+		t.Errorf("Orphans = %v, want [bg-[#000]]", result.Orphans)
+	}
+
+	// Without any important utility in the CSS the modifier itself is missing
+	result = ValidateDirectly(setOf("!mt-0"), setOf("mt-0"))
+	if got := result.VariantIssues["!mt-0"].Reason(); got != "missing important modifier" {
+		t.Errorf("Reason() = %q, want %q", got, "missing important modifier")
+	}
+}
+
+func TestValidateAgainstPatternsArbitraryValues(t *testing.T) {
+	config := &trainer.Config{
+		Patterns:       []trainer.Pattern{{Name: "flex", Regex: `^(flex|grow|shrink|basis)-?(.*)$`}, {Name: "mt", Regex: `^mt-\d+$`}},
+		LiteralClasses: []string{"bg-[#1da1f2]"},
+		Important:      true,
+	}
+	v, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := v.ValidateAgainstPatterns(setOf("bg-[#1da1f2]", "flex-[2_2_0%]", "!mt-4", "mt-4!"))
+
+	if len(result.Orphans) != 1 || result.Orphans[0] != "flex-[2_2_0%]" {
+		t.Errorf("Orphans = %v, want [flex-[2_2_0%%]] (arbitrary values must not match patterns)", result.Orphans)
+	}
+}
+
+func TestValidatorValidateDirectlyIgnored(t *testing.T) {
+	v, err := New(&trainer.Config{Ignored: []string{"js-toggle"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := v.ValidateDirectly(setOf("flex", "js-toggle", "custom"), setOf("flex"))
+
+	if result.Matched != 2 {
+		t.Errorf("Matched = %d, want 2", result.Matched)
+	}
+	if len(result.Orphans) != 1 || result.Orphans[0] != "custom" {
+		t.Errorf("Orphans = %v, want [custom]", result.Orphans)
+	}
+}
+
+func TestResultSuppress(t *testing.T) {
+	r := ValidateDirectly(setOf("flex", "js-toggle", "js-menu", "md:js-x"), setOf("flex"))
+	r.Suppress(
+		map[string][]Location{
+			"js-toggle": {{File: "index.html", Line: 3, Column: 1, Tag: "button"}},
+			"js-menu":   {{File: "index.html", Line: 4, Column: 1, Tag: "nav"}},
+			"md:js-x":   {{File: "src/app.tsx", Line: 9, Column: 20}},
+		},
+		map[string][]Location{
+			"js-menu": {{File: "about.html", Line: 7, Column: 1, Tag: "nav"}},
+		},
+	)
+
+	if len(r.Orphans) != 1 || r.Orphans[0] != "js-menu" || r.OrphanCount != 1 {
+		t.Errorf("Orphans = %v, want [js-menu] (also used without a suppression)", r.Orphans)
+	}
+	if len(r.Suppressed) != 2 || len(r.Suppressed["js-toggle"]) != 1 || len(r.Suppressed["md:js-x"]) != 1 {
+		t.Errorf("Suppressed = %v, want js-toggle and md:js-x", r.Suppressed)
+	}
+	if _, ok := r.VariantIssues["md:js-x"]; ok {
+		t.Error("variant issue kept for suppressed orphan md:js-x")
+	}
+	if r.Matched != 3 || r.CoveragePercent != 75 {
+		t.Errorf("Matched = %d (%.1f%%), want 3 (75%%)", r.Matched, r.CoveragePercent)
+	}
+}
+
+func TestResultRestrict(t *testing.T) {
+	r := ValidateDirectly(setOf("flex", "old-a", "new-b", "md:new-c", "no-loc"), setOf("flex", "grid"))
+	r.AddLocations(map[string][]Location{
+		"old-a":    {{File: "index.html", Line: 3}},
+		"new-b":    {{File: "index.html", Line: 3}, {File: "index.html", Line: 12}},
+		"md:new-c": {{File: "src/app.tsx", Line: 5}},
+	})
+	r.Suggestions = map[string][]Suggestion{"old-a": {{Class: "flex", Score: 0.8}}}
+	r.Suppressed = map[string][]Location{"js-x": {{File: "index.html", Line: 3}}}
+	r.Baseline = &BaselineStatus{New: []string{"md:new-c", "new-b"}, Known: []string{"old-a"}, Resolved: []string{"gone"}}
+
+	used := map[string][]Location{
+		"flex":     {{File: "index.html", Line: 3}, {File: "index.html", Line: 12}},
+		"old-a":    {{File: "index.html", Line: 3}},
+		"new-b":    {{File: "index.html", Line: 3}, {File: "index.html", Line: 12}},
+		"md:new-c": {{File: "src/app.tsx", Line: 5}},
+		"js-x":     {{File: "index.html", Line: 3}},
+	}
+
+	changed := map[string]bool{"index.html:12": true, "src/app.tsx:5": true}
+	r.Restrict(func(l Location) bool { return changed[fmt.Sprintf("%s:%d", l.File, l.Line)] }, used)
+
+	if len(r.Orphans) != 2 || r.Orphans[0] != "md:new-c" || r.Orphans[1] != "new-b" || r.OrphanCount != 2 {
+		t.Errorf("Orphans = %v, want [md:new-c new-b]", r.Orphans)
+	}
+	if locs := r.OrphanLocations["new-b"]; len(locs) != 1 || locs[0].Line != 12 {
+		t.Errorf("new-b locations = %v, want only line 12", locs)
+	}
+	if _, ok := r.Suggestions["old-a"]; ok {
+		t.Error("suggestion kept for dropped orphan old-a")
+	}
+	if _, ok := r.VariantIssues["md:new-c"]; !ok {
+		t.Error("variant issue dropped for kept orphan md:new-c")
+	}
+	if b := r.Baseline; len(b.New) != 2 || len(b.Known) != 0 || len(b.Resolved) != 0 {
+		t.Errorf("Baseline = %+v, want only the kept orphans as new", b)
+	}
+	if len(r.Suppressed) != 0 || len(r.Unused) != 0 || r.UnusedCount != 0 || len(r.Rules) != 0 {
+		t.Errorf("Suppressed = %v, Unused = %v, Rules = %v, want none", r.Suppressed, r.Unused, r.Rules)
+	}
+	if r.HTMLClasses != 3 || r.Matched != 1 || fmt.Sprintf("%.1f", r.CoveragePercent) != "33.3" {
+		t.Errorf("HTMLClasses = %d, Matched = %d (%.1f%%), want 3 and 1 (33.3%%)", r.HTMLClasses, r.Matched, r.CoveragePercent)
+	}
+}
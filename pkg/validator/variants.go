@@ -0,0 +1,101 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/JCorners68/cssguard/pkg/trainer"
+)
+
+// VariantIssue explains why a class with modifiers (md:hover:bg-blue-500,
+// !mt-0) is an orphan: a variant, the important modifier, the base utility,
+// or a combination of them is missing from the CSS.
+type VariantIssue struct {
+	Variants         []string `json:"variants,omitempty"`
+	Important        bool     `json:"important,omitempty"`
+	Base             string   `json:"base"`
+	MissingVariants  []string `json:"missing_variants,omitempty"`
+	MissingImportant bool     `json:"missing_important,omitempty"`
+	MissingUtility   bool     `json:"missing_utility"`
+}
+
+// Reason describes what is missing, e.g. "missing variant md (no md: class
+// in the CSS); missing utility bg-blue-500". Variants are known by the class
+// prefixes in the CSS, not by its @media rules or pseudo-classes, so the
+// reason says so.
+func (vi VariantIssue) Reason() string {
+	var parts []string
+	if len(vi.MissingVariants) == 1 {
+		v := vi.MissingVariants[0]
+		parts = append(parts, fmt.Sprintf("missing variant %s (no %s: class in the CSS)", v, v))
+	} else if len(vi.MissingVariants) > 1 {
+		parts = append(parts, "missing variants "+strings.Join(vi.MissingVariants, ", ")+" (no class in the CSS uses them)")
+	}
+	if vi.MissingImportant {
+		parts = append(parts, "missing important modifier")
+	}
+	if vi.MissingUtility {
+		parts = append(parts, fmt.Sprintf("missing utility %s", vi.Base))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// utilitySet indexes the parts of a set of classes: the variants that prefix
+// them, their base utilities, and whether any carries the important modifier.
+// A variant is known once any class carries it as a prefix; the @media rule
+// or pseudo-class it compiles to is not looked at.
+type utilitySet struct {
+	variants  map[string]struct{}
+	bases     map[string]struct{}
+	important bool
+}
+
+func newUtilitySet(classes map[string]struct{}) *utilitySet {
+	s := &utilitySet{
+		variants: make(map[string]struct{}),
+		bases:    make(map[string]struct{}),
+	}
+	for class := range classes {
+		vs, base := trainer.SplitVariants(class)
+		base, important := trainer.SplitImportant(base)
+		s.important = s.important || important
+		s.bases[base] = struct{}{}
+		for _, v := range vs {
+			s.variants[v] = struct{}{}
+		}
+	}
+	return s
+}
+
+func (s *utilitySet) hasVariant(v string) bool {
+	_, ok := s.variants[v]
+	return ok
+}
+
+func (s *utilitySet) hasBase(base string) bool {
+	_, ok := s.bases[base]
+	return ok
+}
+
+// checkModifiers splits class into variants, important modifier and base
+// utility and checks each part separately. It returns ok when the class has a
+// modifier, every modifier is known and the base utility matches; otherwise
+// the issue describes the gap. Classes without modifiers return ok == false
+// and a zero issue.
+func checkModifiers(class string, known *utilitySet, hasBase func(string) bool) (issue VariantIssue, ok bool) {
+	variants, base := trainer.SplitVariants(class)
+	base, important := trainer.SplitImportant(base)
+	if len(variants) == 0 && !important {
+		return VariantIssue{}, false
+	}
+
+	issue = VariantIssue{Variants: variants, Important: important, Base: base}
+	for _, v := range variants {
+		if !known.hasVariant(v) {
+			issue.MissingVariants = append(issue.MissingVariants, v)
+		}
+	}
+	issue.MissingImportant = important && !known.important
+	issue.MissingUtility = !hasBase(base)
+	return issue, len(issue.MissingVariants) == 0 && !issue.MissingImportant && !issue.MissingUtility
+}
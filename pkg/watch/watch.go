@@ -0,0 +1,144 @@
+// Package watch detects file changes by polling, so cssguard can re-validate
+// during development without platform-specific file notification APIs.
+package watch
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// FileState is what a snapshot records about a file to notice changes.
+type FileState struct {
+	ModTime time.Time
+	Size    int64
+}
+
+// Snapshot maps file paths to their state.
+type Snapshot map[string]FileState
+
+// IncludeFunc reports whether to watch a file. For a directory, returning
+// false skips it and everything below it.
+type IncludeFunc func(path string, d fs.DirEntry) bool
+
+// Take records the state of the files under roots accepted by include. A
+// root may be a file, which is watched as long as include accepts it.
+// Missing roots are skipped, so they are picked up once they appear.
+func Take(roots []string, include IncludeFunc) (Snapshot, error) {
+	s := make(Snapshot)
+	err := s.Add(roots, include)
+	return s, err
+}
+
+// Add records the files under roots accepted by include, as Take does.
+func (s Snapshot) Add(roots []string, include IncludeFunc) error {
+	for _, root := range roots {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() {
+				if path != root && !include(path, d) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !include(path, d) {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil // Removed while walking
+			}
+			s[path] = FileState{ModTime: info.ModTime(), Size: info.Size()}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Changes lists the files that differ between two snapshots, sorted.
+type Changes struct {
+	Changed []string // Added or modified
+	Removed []string
+}
+
+// Len returns the number of changed and removed files.
+func (c Changes) Len() int {
+	return len(c.Changed) + len(c.Removed)
+}
+
+// Diff returns what changed from old to cur.
+func Diff(old, cur Snapshot) Changes {
+	var c Changes
+	for path, state := range cur {
+		if prev, ok := old[path]; !ok || prev != state {
+			c.Changed = append(c.Changed, path)
+		}
+	}
+	for path := range old {
+		if _, ok := cur[path]; !ok {
+			c.Removed = append(c.Removed, path)
+		}
+	}
+	sort.Strings(c.Changed)
+	sort.Strings(c.Removed)
+	return c
+}
+
+// Watcher polls for changes and reports them once files stop changing.
+type Watcher struct {
+	Scan     func() (Snapshot, error) // Takes the current snapshot
+	Start    Snapshot                 // Snapshot to report changes against; taken by Run if nil
+	Interval time.Duration            // Time between polls
+	Debounce time.Duration            // Quiet time required before reporting
+}
+
+// Run polls until stop is closed. Changes are collected until no file has
+// changed for the debounce time and then passed to onChange in one call, so
+// a full site rebuild triggers one run.
+func (w *Watcher) Run(stop <-chan struct{}, onChange func(Changes)) error {
+	reported := w.Start
+	if reported == nil {
+		var err error
+		if reported, err = w.Scan(); err != nil {
+			return err
+		}
+	}
+	seen := reported
+	var lastChange time.Time
+
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case now := <-ticker.C:
+			cur, err := w.Scan()
+			if err != nil {
+				return err
+			}
+			if Diff(seen, cur).Len() > 0 {
+				seen = cur
+				lastChange = now
+				continue
+			}
+			if now.Sub(lastChange) < w.Debounce {
+				continue
+			}
+			if changes := Diff(reported, seen); changes.Len() > 0 {
+				onChange(changes)
+			}
+			reported = seen
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package watch
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTakeAndDiff(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("index.html", "<div></div>")
+	write("about.html", "<p></p>")
+	write("notes.txt", "skip")
+	write("node_modules/lib.html", "skip")
+
+	include := func(path string, d fs.DirEntry) bool {
+		if d.IsDir() {
+			return d.Name() != "node_modules"
+		}
+		return strings.HasSuffix(path, ".html")
+	}
+	roots := []string{dir, filepath.Join(dir, "missing")}
+
+	before, err := Take(roots, include)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(before) != 2 {
+		t.Fatalf("Take() = %v, want index.html and about.html", before)
+	}
+
+	write("index.html", "<div class=\"flex\"></div>")
+	write("new.html", "<span></span>")
+	if err := os.Remove(filepath.Join(dir, "about.html")); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := Take(roots, include)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := Diff(before, after)
+	if len(c.Changed) != 2 || c.Changed[0] != filepath.Join(dir, "index.html") || c.Changed[1] != filepath.Join(dir, "new.html") {
+		t.Errorf("Changed = %v, want index.html and new.html", c.Changed)
+	}
+	if len(c.Removed) != 1 || c.Removed[0] != filepath.Join(dir, "about.html") {
+		t.Errorf("Removed = %v, want about.html", c.Removed)
+	}
+}
+
+func TestWatcherDebounces(t *testing.T) {
+	// A rebuild that touches a file on every poll for a while, then settles
+	var mu sync.Mutex
+	polls := 0
+	scan := func() (Snapshot, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		polls++
+		s := Snapshot{"index.html": {Size: 1}}
+		if polls > 1 {
+			step := polls
+			if step > 6 {
+				step = 6
+			}
+			s["index.html"] = FileState{Size: int64(step)}
+			s["about.html"] = FileState{Size: 1}
+		}
+		return s, nil
+	}
+
+	w := &Watcher{Scan: scan, Interval: time.Millisecond, Debounce: 20 * time.Millisecond}
+	stop := make(chan struct{})
+	calls := make(chan Changes, 10)
+	done := make(chan error)
+	go func() {
+		done <- w.Run(stop, func(c Changes) { calls <- c })
+	}()
+
+	select {
+	case c := <-calls:
+		if len(c.Changed) != 2 || len(c.Removed) != 0 {
+			t.Errorf("changes = %+v, want about.html and index.html changed", c)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no changes reported")
+	}
+
+	// Nothing changes after the rebuild, so there is no second report
+	time.Sleep(50 * time.Millisecond)
+	close(stop)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 0 {
+		t.Errorf("got %d extra reports, want one report for the whole rebuild", len(calls))
+	}
+}